@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -223,11 +224,15 @@ func parseAndAugment(xctx XContext, pkg *PackageData, isTest bool, fileSet *toke
 		r.Close()
 		if err != nil {
 			if list, isList := err.(scanner.ErrorList); isList {
-				if len(list) > 10 {
-					list = append(list[:10], &scanner.Error{Pos: list[9].Pos, Msg: "too many errors"})
-				}
 				for _, entry := range list {
-					errList = append(errList, entry)
+					errList = append(errList, &compiler.Diagnostic{
+						Package:  pkg.ImportPath,
+						Phase:    compiler.PhaseParse,
+						Severity: compiler.SeverityError,
+						Pos:      entry.Pos,
+						Msg:      entry.Msg,
+						Err:      entry,
+					})
 				}
 				continue
 			}
@@ -305,6 +310,24 @@ type Options struct {
 	BuildTags      []string
 	TestedPackage  string
 	NoCache        bool
+	Format         compiler.OutputFormat
+	BuildMode      compiler.BuildMode
+	// Maximum number of packages compiled concurrently. Defaults to
+	// GOMAXPROCS if not positive.
+	Parallelism int
+	// Report all errors of a package, rather than the first
+	// compiler.DefaultMaxErrors of them.
+	AllErrors bool
+	// Print errors as JSON objects, one per line, for consumption by tools.
+	JSON bool
+	// Coverage analysis mode of the package under test, one of CoverModes, or
+	// empty if coverage analysis is disabled.
+	CoverMode string
+	// Write TypeScript declarations for the program's exports next to the
+	// output file, if the program exports anything.
+	CreateDeclarationFile bool
+	// Count heap allocations, see compiler.CompileOptions.TrackAllocations.
+	TrackAllocations bool
 }
 
 // PrintError message to the terminal.
@@ -336,7 +359,12 @@ type PackageData struct {
 	*build.Package
 	JSFiles []JSFile
 	// IsTest is true if the package is being built for running tests.
-	IsTest     bool
+	IsTest bool
+	// The most recent modification time of the package's sources, its
+	// dependencies and the gopherjs executable, populated when it's built.
+	//
+	// Deprecated: The build cache doesn't depend on modification times, archives
+	// are reused if the contents of their sources are the same.
 	SrcModTime time.Time
 	UpToDate   bool
 	// If true, the package does not have a corresponding physical directory on disk.
@@ -352,6 +380,9 @@ func (p PackageData) String() string {
 // FileModTime returns the most recent modification time of the package's source
 // files. This includes all .go and .inc.js that would be included in the build,
 // but excludes any dependencies.
+//
+// Deprecated: The build cache doesn't depend on modification times, archives
+// are reused if the contents of their sources are the same.
 func (p PackageData) FileModTime() time.Time {
 	newest := time.Time{}
 	for _, file := range p.JSFiles {
@@ -403,6 +434,9 @@ func (p *PackageData) TestPackage() *PackageData {
 			Dir:        p.Dir,
 			GoFiles:    append(p.GoFiles, p.TestGoFiles...),
 			Imports:    append(p.Imports, p.TestImports...),
+
+			EmbedPatterns:   append(append([]string{}, p.EmbedPatterns...), p.TestEmbedPatterns...),
+			EmbedPatternPos: mergeEmbedPatternPos(p.EmbedPatternPos, p.TestEmbedPatternPos),
 		},
 		IsTest:  true,
 		JSFiles: p.JSFiles,
@@ -419,6 +453,9 @@ func (p *PackageData) XTestPackage() *PackageData {
 			Dir:        p.Dir,
 			GoFiles:    p.XTestGoFiles,
 			Imports:    p.XTestImports,
+
+			EmbedPatterns:   p.XTestEmbedPatterns,
+			EmbedPatternPos: p.XTestEmbedPatternPos,
 		},
 		IsTest: true,
 		bctx:   p.bctx,
@@ -454,9 +491,33 @@ type Session struct {
 	// Binary archives produced during the current session and assumed to be
 	// up to date with input sources and dependencies. In the -w ("watch") mode
 	// must be cleared upon entering watching.
+	//
+	// Packages are built concurrently, so UpToDateArchives and Types must only
+	// be accessed directly while no build is in progress.
 	UpToDateArchives map[string]*compiler.Archive
 	Types            map[string]*types.Package
 	Watcher          *fsnotify.Watcher
+
+	// Files embedded into the packages built during the session, which the
+	// watcher must react to in addition to the source files, mapped to import
+	// paths of the packages embedding them.
+	watchedEmbeds map[string]map[string]bool
+	// Directories of the packages loaded during the session, keyed by import
+	// path.
+	pkgDirs map[string]string
+	// Packages invalidated by the last change in watch mode, see invalidate.
+	stale    map[string]*staleBuild
+	affected map[string]bool
+	// Invalidated packages rebuilt with the same type information as before
+	// the change, see keptTypes.
+	typesKept map[string]bool
+	// Packages being built, keyed by import path.
+	tasks map[string]*buildTask
+	// Source hashes of the packages in UpToDateArchives, see srcHash.
+	srcHashes map[string]string
+
+	mu       sync.Mutex // Guards all of the above, except Watcher.
+	reportMu sync.Mutex // Serializes progress reporting.
 }
 
 // NewSession creates a new GopherJS build session.
@@ -466,6 +527,7 @@ func NewSession(options *Options) (*Session, error) {
 	s := &Session{
 		options:          options,
 		UpToDateArchives: make(map[string]*compiler.Archive),
+		srcHashes:        make(map[string]string),
 	}
 	s.xctx = NewBuildContext(s.InstallSuffix(), s.options.BuildTags)
 	env := s.xctx.Env()
@@ -475,14 +537,27 @@ func NewSession(options *Options) (*Session, error) {
 		return nil, err
 	}
 
+	cacheBackend, err := cache.ConfiguredBackend()
+	if err != nil {
+		return nil, err
+	}
 	s.buildCache = cache.BuildCache{
 		GOOS:          env.GOOS,
 		GOARCH:        env.GOARCH,
-		GOROOT:        env.GOROOT,
-		GOPATH:        env.GOPATH,
+		GoVersion:     compiler.GoRelease(env.GOROOT),
 		BuildTags:     append([]string{}, env.BuildTags...),
 		Minify:        options.Minify,
 		TestedPackage: options.TestedPackage,
+		CoverMode:     options.CoverMode,
+		Backend:       cacheBackend,
+
+		TrackAllocations: options.TrackAllocations,
+		Library:          options.BuildMode == compiler.BuildModeLibrary,
+		Declarations:     options.CreateDeclarationFile,
+	}
+	// The cache is trimmed as it's written, make sure the limit is valid.
+	if _, err := cache.MaxSize(); err != nil {
+		return nil, err
 	}
 	s.Types = make(map[string]*types.Package)
 	if options.Watch {
@@ -554,10 +629,7 @@ func (s *Session) BuildFiles(filenames []string, pkgObj string, cwd string) erro
 			ImportPath: "main",
 			Dir:        dirList[0],
 		},
-		// This ephemeral package doesn't have a unique import path to be used as a
-		// build cache key, so we never cache it.
-		SrcModTime: time.Now().Add(time.Hour),
-		bctx:       &goCtx(s.xctx.Env()).bctx,
+		bctx: &goCtx(s.xctx.Env()).bctx,
 	}
 
 	for _, file := range filenames {
@@ -581,6 +653,24 @@ func (s *Session) BuildFiles(filenames []string, pkgObj string, cwd string) erro
 		})
 	}
 
+	// Imports are listed for the package to be cached like any other, so that
+	// its source hash accounts for the dependencies, see srcHash.
+	importSet := map[string]bool{}
+	fileSet := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(fileSet, filepath.Join(pkg.Dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range file.Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil && !importSet[path] {
+				importSet[path] = true
+				pkg.Imports = append(pkg.Imports, path)
+			}
+		}
+	}
+	sort.Strings(pkg.Imports)
+
 	archive, err := s.BuildPackage(pkg)
 	if err != nil {
 		return err
@@ -621,84 +711,22 @@ func (s *Session) buildImportPathWithSrcDir(path string, srcDir string) (*Packag
 }
 
 // BuildPackage compiles an already loaded package.
+//
+// Imported packages which are not up to date are built first, concurrently
+// where the import graph allows. BuildPackage may be called concurrently.
 func (s *Session) BuildPackage(pkg *PackageData) (*compiler.Archive, error) {
-	if archive, ok := s.UpToDateArchives[pkg.ImportPath]; ok {
+	if archive, ok := s.upToDateArchive(pkg.ImportPath); ok {
 		return archive, nil
 	}
 
-	var fileInfo os.FileInfo
-	gopherjsBinary, err := os.Executable()
-	if err == nil {
-		fileInfo, err = os.Stat(gopherjsBinary)
-		if err == nil && fileInfo.ModTime().After(pkg.SrcModTime) {
-			pkg.SrcModTime = fileInfo.ModTime()
-		}
-	}
-	if err != nil {
-		os.Stderr.WriteString("Could not get GopherJS binary's modification timestamp. Please report issue.\n")
-		pkg.SrcModTime = time.Now()
-	}
-
-	for _, importedPkgPath := range pkg.Imports {
-		if importedPkgPath == "unsafe" {
-			continue
-		}
-		importedPkg, _, err := s.buildImportPathWithSrcDir(importedPkgPath, pkg.Dir)
-		if err != nil {
-			return nil, err
-		}
-
-		impModTime := importedPkg.SrcModTime
-		if impModTime.After(pkg.SrcModTime) {
-			pkg.SrcModTime = impModTime
-		}
-	}
-
-	if pkg.FileModTime().After(pkg.SrcModTime) {
-		pkg.SrcModTime = pkg.FileModTime()
-	}
-
-	if !s.options.NoCache {
-		archive := s.buildCache.LoadArchive(pkg.ImportPath)
-		if archive != nil && !pkg.SrcModTime.After(archive.BuildTime) {
-			if err := archive.RegisterTypes(s.Types); err != nil {
-				panic(fmt.Errorf("Failed to load type information from %v: %w", archive, err))
-			}
-			s.UpToDateArchives[pkg.ImportPath] = archive
-			// Existing archive is up to date, no need to build it from scratch.
-			return archive, nil
-		}
-	}
-
-	// Existing archive is out of date or doesn't exist, let's build the package.
-	fileSet := token.NewFileSet()
-	files, overlayJsFiles, err := parseAndAugment(s.xctx, pkg, pkg.IsTest, fileSet)
+	nodes, err := s.loadImportGraph(pkg)
 	if err != nil {
 		return nil, err
 	}
-
-	importContext := &compiler.ImportContext{
-		Packages: s.Types,
-		Import:   s.ImportResolverFor(pkg),
-	}
-	archive, err := compiler.Compile(pkg.ImportPath, files, fileSet, importContext, s.options.Minify)
-	if err != nil {
+	if err := s.buildGraph(nodes); err != nil {
 		return nil, err
 	}
-
-	for _, jsFile := range append(pkg.JSFiles, overlayJsFiles...) {
-		archive.IncJSCode = append(archive.IncJSCode, []byte("\t(function() {\n")...)
-		archive.IncJSCode = append(archive.IncJSCode, jsFile.Content...)
-		archive.IncJSCode = append(archive.IncJSCode, []byte("\n\t}).call($global);\n")...)
-	}
-
-	if s.options.Verbose {
-		fmt.Println(pkg.ImportPath)
-	}
-
-	s.buildCache.StoreArchive(archive)
-	s.UpToDateArchives[pkg.ImportPath] = archive
-
+	archive, _ := s.upToDateArchive(pkg.ImportPath)
 	return archive, nil
 }
 
@@ -706,7 +734,7 @@ func (s *Session) BuildPackage(pkg *PackageData) (*compiler.Archive, error) {
 // given an import path.
 func (s *Session) ImportResolverFor(pkg *PackageData) func(string) (*compiler.Archive, error) {
 	return func(path string) (*compiler.Archive, error) {
-		if archive, ok := s.UpToDateArchives[path]; ok {
+		if archive, ok := s.upToDateArchive(path); ok {
 			return archive, nil
 		}
 		_, archive, err := s.buildImportPathWithSrcDir(path, pkg.Dir)
@@ -749,7 +777,7 @@ func (s *Session) WriteCommandPackage(archive *compiler.Archive, pkgObj string)
 	}
 
 	deps, err := compiler.ImportDependencies(archive, func(path string) (*compiler.Archive, error) {
-		if archive, ok := s.UpToDateArchives[path]; ok {
+		if archive, ok := s.upToDateArchive(path); ok {
 			return archive, nil
 		}
 		_, archive, err := s.buildImportPathWithSrcDir(path, "")
@@ -758,7 +786,38 @@ func (s *Session) WriteCommandPackage(archive *compiler.Archive, pkgObj string)
 	if err != nil {
 		return err
 	}
-	return compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease())
+	if err := compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease(), s.options.Format, s.options.BuildMode); err != nil {
+		return err
+	}
+	if s.options.CreateDeclarationFile && (s.options.BuildMode == compiler.BuildModeLibrary || len(compiler.ProgramExports(deps, s.options.BuildMode)) > 0) {
+		return writeDeclarationFile(deps, declarationFileName(pkgObj), s.options)
+	}
+	return nil
+}
+
+// declarationFileName returns the name of the TypeScript declaration file for
+// the JavaScript output file pkgObj.
+func declarationFileName(pkgObj string) string {
+	for _, ext := range []string{"js", "mjs", "cjs"} {
+		if strings.HasSuffix(pkgObj, "."+ext) {
+			return strings.TrimSuffix(pkgObj, "."+ext) + ".d." + strings.Replace(ext, "js", "ts", 1)
+		}
+	}
+	return pkgObj + ".d.ts"
+}
+
+// writeDeclarationFile writes TypeScript declarations for the exports of the
+// program built out of pkgs.
+func writeDeclarationFile(pkgs []*compiler.Archive, name string, options *Options) error {
+	dtsFile, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer dtsFile.Close()
+	if err := compiler.WriteTypeScriptDeclarations(pkgs, dtsFile, options.Format, options.BuildMode); err != nil {
+		return err
+	}
+	return dtsFile.Close()
 }
 
 // NewMappingCallback creates a new callback for source map generation.
@@ -799,35 +858,46 @@ func hasGopathPrefix(file, gopath string) (hasGopathPrefix bool, prefixLen int)
 	return false, 0
 }
 
-// WaitForChange watches file system events and returns if either when one of
-// the source files is modified.
+// WaitForChange watches file system events and returns when some of the source
+// files are modified, after invalidating the packages the build of which they
+// affect. The session can then be used for the next build, which rebuilds only
+// the invalidated packages.
 func (s *Session) WaitForChange() {
-	// Will need to re-validate up-to-dateness of all archives, so flush them from
-	// memory.
-	s.UpToDateArchives = map[string]*compiler.Archive{}
-	s.Types = map[string]*types.Package{}
-
 	s.options.PrintSuccess("watching for changes...\n")
-	for {
+	var files []string
+	for len(files) == 0 {
 		select {
 		case ev := <-s.Watcher.Events:
-			if ev.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 || filepath.Base(ev.Name)[0] == '.' {
-				continue
-			}
-			if !strings.HasSuffix(ev.Name, ".go") && !strings.HasSuffix(ev.Name, ".inc.js") {
+			if !s.isWatchedChange(ev) {
 				continue
 			}
 			s.options.PrintSuccess("change detected: %s\n", ev.Name)
+			files = append(files, ev.Name)
 		case err := <-s.Watcher.Errors:
 			s.options.PrintError("watcher error: %s\n", err.Error())
+			s.flush()
+			return
 		}
-		break
 	}
 
-	go func() {
-		for range s.Watcher.Events {
-			// consume, else Close() may deadlock
+	// Collect the rest of the files changed at once, e.g. by "save all" in an
+	// editor, to rebuild them together.
+	timer := time.NewTimer(watchDebounce)
+	defer timer.Stop()
+	for collecting := true; collecting; {
+		select {
+		case ev := <-s.Watcher.Events:
+			if s.isWatchedChange(ev) {
+				files = append(files, ev.Name)
+			}
+		case err := <-s.Watcher.Errors:
+			s.options.PrintError("watcher error: %s\n", err.Error())
+			s.flush()
+			return
+		case <-timer.C:
+			collecting = false
 		}
-	}()
-	s.Watcher.Close()
+	}
+
+	s.Invalidate(files)
 }
@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RemoteURLEnv is the environment variable that configures a remote build
+// cache, see ConfiguredBackend.
+const RemoteURLEnv = "GOPHERJS_CACHE_URL"
+
+// Backend stores cached artifacts under opaque keys, which consist of
+// lowercase hexadecimal digits.
+//
+// Backends don't need to verify integrity of the stored data, BuildCache does
+// that itself. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the data stored under the key, or an error satisfying
+	// errors.Is(err, fs.ErrNotExist) if there is none.
+	Get(key string) ([]byte, error)
+	// Put stores the data under the key, replacing any previous data.
+	Put(key string, data []byte) error
+}
+
+// Remover is implemented by backends that are able to remove the data stored
+// under a key, which BuildCache uses to get rid of corrupted artifacts.
+type Remover interface {
+	Remove(key string) error
+}
+
+// ConfiguredBackend returns the backend for the build cache: the local cache
+// directory, layered over the HTTP cache at the URL in the GOPHERJS_CACHE_URL
+// environment variable, if it's set.
+func ConfiguredBackend() (Backend, error) {
+	remote := os.Getenv(RemoteURLEnv)
+	if remote == "" {
+		return DiskBackend{}, nil
+	}
+	u, err := url.Parse(remote)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q: must be an http or https URL", RemoteURLEnv, remote)
+	}
+	return Layered{Local: DiskBackend{}, Remote: &HTTPBackend{URL: remote}}, nil
+}
+
+// DiskBackend stores cached artifacts in the local cache directory, where they
+// can be managed by Clear(), Trim() and RemoveOlderThan(). The cache is trimmed
+// to the size returned by MaxSize() as the artifacts are stored.
+//
+// Getting an artifact updates its modification time, which serves as the last
+// access time, since access times maintained by the file system are often
+// disabled or imprecise.
+type DiskBackend struct{}
+
+// path returns the location of the artifact with the given key.
+func (DiskBackend) path(key string) string {
+	return filepath.Join(cacheRoot, key[0:2], key)
+}
+
+// Get implements Backend.
+func (d DiskBackend) Get(key string) ([]byte, error) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	markUsed(path)
+	return data, nil
+}
+
+// Put implements Backend.
+func (d DiskBackend) Put(key string, data []byte) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create build cache directory: %w", err)
+	}
+	// Write the data in a temporary file first to avoid concurrency errors.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary build cache file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		// Make sure we don't leave a half-written file behind.
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	// Rename fully written file into its permanent name.
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to rename build cache file to %q: %w", path, err)
+	}
+	trimAfterWrite(int64(len(data)))
+	return nil
+}
+
+// Remove implements Remover.
+func (d DiskBackend) Remove(key string) error {
+	return os.Remove(d.path(key))
+}
+
+// HTTPBackend stores cached artifacts on an HTTP server, which can be shared by
+// several machines.
+//
+// The server is expected to respond to GET <URL>/<key> requests with the data
+// previously uploaded by PUT <URL>/<key> requests, or with the 404 status if
+// there is none, which most object storages and generic HTTP caches can be
+// configured to do. Credentials for basic authentication can be a part of the
+// URL.
+type HTTPBackend struct {
+	URL string
+	// Client to make requests with. If nil, a client with a default timeout is
+	// used.
+	Client *http.Client
+}
+
+// defaultHTTPClient makes requests for HTTPBackend by default. The timeout
+// prevents an unresponsive cache server from stalling builds.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (h *HTTPBackend) client() *http.Client {
+	if h.Client == nil {
+		return defaultHTTPClient
+	}
+	return h.Client
+}
+
+func (h *HTTPBackend) url(key string) string {
+	return strings.TrimSuffix(h.URL, "/") + "/" + key
+}
+
+// Get implements Backend.
+func (h *HTTPBackend) Get(key string) ([]byte, error) {
+	resp, err := h.client().Get(h.url(key))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fs.ErrNotExist
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("remote build cache responded with %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put implements Backend.
+func (h *HTTPBackend) Put(key string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, h.url(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := h.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("remote build cache responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Layered is a backend that keeps a local copy of a remote backend's
+// artifacts. Artifacts are stored in both backends, and fetched from the remote
+// one only if they are missing in the local one.
+//
+// Storing artifacts in the remote backend is best-effort: it's done in the
+// background, and failures are logged rather than reported, so that a slow or
+// unavailable remote cache doesn't slow down or break builds. Call
+// WaitForUploads before exiting to let the uploads finish.
+type Layered struct {
+	Local  Backend
+	Remote Backend
+}
+
+// Get implements Backend.
+func (l Layered) Get(key string) ([]byte, error) {
+	data, err := l.Local.Get(key)
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		log.Warningf("Failed to get %q from the local build cache: %v", key, err)
+	}
+	data, err = l.Remote.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.Local.Put(key, data); err != nil {
+		log.Warningf("Failed to store %q in the local build cache: %v", key, err)
+	}
+	return data, nil
+}
+
+// Put implements Backend.
+func (l Layered) Put(key string, data []byte) error {
+	uploads.Add(1)
+	go func() {
+		defer uploads.Done()
+		uploadSlots <- struct{}{}
+		defer func() { <-uploadSlots }()
+		if err := l.Remote.Put(key, data); err != nil {
+			log.Warningf("Failed to store %q in the remote build cache: %v", key, err)
+		}
+	}()
+	return l.Local.Put(key, data)
+}
+
+var (
+	// uploads tracks the artifacts being stored in remote backends by Layered.
+	uploads sync.WaitGroup
+	// uploadSlots limits the number of concurrent uploads.
+	uploadSlots = make(chan struct{}, 4)
+)
+
+// WaitForUploads blocks until the artifacts being stored in the remote build
+// cache in the background are uploaded, or fail to be.
+func WaitForUploads() {
+	uploads.Wait()
+}
+
+// Remove implements Remover by removing the local copy of the artifact. The
+// remote copy is replaced when the artifact is stored again.
+func (l Layered) Remove(key string) error {
+	if r, ok := l.Local.(Remover); ok {
+		return r.Remove(key)
+	}
+	return nil
+}
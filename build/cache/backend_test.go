@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gopherjs/gopherjs/compiler"
+)
+
+// fakeCacheServer is a stand-in for a remote HTTP cache server.
+type fakeCacheServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func (s *fakeCacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/cache/")
+	switch r.Method {
+	case http.MethodGet:
+		s.gets++
+		data, ok := s.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = data
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRemoteBackend(t *testing.T) {
+	server := &fakeCacheServer{objects: map[string][]byte{}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	remote := &HTTPBackend{URL: ts.URL + "/cache/"}
+
+	want := &compiler.Archive{
+		ImportPath: "fake/package",
+		Imports:    []string{"fake/dep"},
+	}
+
+	// One machine builds the package...
+	cacheForTest(t)
+	bc := BuildCache{Backend: Layered{Local: DiskBackend{}, Remote: remote}}
+	bc.StoreArchive(want, "hash")
+	WaitForUploads()
+	if len(server.objects) != 1 {
+		t.Fatalf("Got: %d objects stored on the server. Want: 1.", len(server.objects))
+	}
+
+	// ...and another one with an empty local cache reuses it.
+	cacheForTest(t)
+	got := bc.LoadArchive(want.ImportPath, "hash")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Archive loaded from the remote cache is different from stored (-want,+got):\n%s", diff)
+	}
+	// The archive is now available locally.
+	gets := server.gets
+	if got := bc.LoadArchive(want.ImportPath, "hash"); got == nil {
+		t.Errorf("Got: %s was not found in the cache. Want: archive is loaded.", want.ImportPath)
+	}
+	if server.gets != gets {
+		t.Errorf("Got: %d requests to the server for a locally cached archive. Want: 0.", server.gets-gets)
+	}
+
+	if got := bc.LoadArchive("fake/other", "hash"); got != nil {
+		t.Errorf("Got: fake/other was found in cache: %#v. Want: nil for packages that weren't cached.", got)
+	}
+
+	// Corrupted data on the server is a cache miss.
+	for key, data := range server.objects {
+		server.objects[key] = append(data, '!')
+	}
+	cacheForTest(t)
+	if got := bc.LoadArchive(want.ImportPath, "hash"); got != nil {
+		t.Errorf("Got: %s was loaded from corrupted remote data. Want: cache miss.", got.ImportPath)
+	}
+}
+
+func TestRemoteBackendErrors(t *testing.T) {
+	cacheForTest(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	bc := BuildCache{Backend: &HTTPBackend{URL: ts.URL}}
+	a := &compiler.Archive{ImportPath: "fake/package"}
+	bc.StoreArchive(a, "hash")
+	if got := bc.LoadArchive(a.ImportPath, "hash"); got != nil {
+		t.Errorf("Got: %s was loaded from an unavailable server. Want: cache miss.", got.ImportPath)
+	}
+
+	// Failing uploads don't prevent storing the artifacts locally.
+	layered := Layered{Local: DiskBackend{}, Remote: &HTTPBackend{URL: ts.URL}}
+	if err := layered.Put("aa01", []byte("data")); err != nil {
+		t.Errorf("Layered.Put() returned error: %s. Want: remote failures are not reported.", err)
+	}
+	WaitForUploads()
+	if data, err := layered.Get("aa01"); err != nil || string(data) != "data" {
+		t.Errorf("Layered.Get() returned %q, %v. Want: the data stored locally.", data, err)
+	}
+}
+
+func TestBackendNotInKey(t *testing.T) {
+	cacheForTest(t)
+
+	a := &compiler.Archive{ImportPath: "fake/package"}
+	(&BuildCache{}).StoreArchive(a, "hash")
+	bc := BuildCache{Backend: Layered{Local: DiskBackend{}, Remote: &HTTPBackend{URL: "http://127.0.0.1:0"}}}
+	if got := bc.LoadArchive(a.ImportPath, "hash"); got == nil {
+		t.Errorf("Got: %s was not found with a different backend. Want: the backend is not a part of the cache key.", a.ImportPath)
+	}
+}
@@ -3,12 +3,21 @@
 package cache
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"go/build"
+	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gopherjs/gopherjs/compiler"
 	log "github.com/sirupsen/logrus"
@@ -30,16 +39,15 @@ var cacheRoot = func() string {
 	return filepath.Join(build.Default.GOPATH, "pkg", "gopherjs_build_cache")
 }()
 
-// cachedPath returns a location inside the build cache for a given set of key
-// strings. The set of keys must uniquely identify cacheable object. Prefer
-// using more specific functions to ensure key consistency.
-func cachedPath(keys ...string) string {
+// cacheKey returns a backend key for a given set of key strings. The set of
+// keys must uniquely identify cacheable object. Prefer using more specific
+// functions to ensure key consistency.
+func cacheKey(keys ...string) string {
 	key := path.Join(keys...)
 	if key == "" {
-		panic("CachedPath() must not be used with an empty string")
+		panic("cacheKey() must not be used with an empty string")
 	}
-	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
-	return filepath.Join(cacheRoot, sum[0:2], sum)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
 }
 
 // Clear the cache. This will remove *all* cached artifacts from *all* build
@@ -56,25 +64,32 @@ func Clear() error {
 //
 // BuildCache struct fields represent build parameters which change invalidates
 // the cache. For example, any artifacts that were cached for a minified build
-// must not be reused for a non-minified build. GopherJS version change also
-// invalidates the cache. It is callers responsibility to ensure that artifacts
-// passed the the StoreArchive function were generated with the same build
-// parameters as the cache is configured.
+// must not be reused for a non-minified build. GopherJS version change, as well
+// as any change to the gopherjs executable itself, also invalidates the cache.
+// It is callers responsibility to ensure that artifacts passed the the
+// StoreArchive function were generated with the same build parameters as the
+// cache is configured. Locations of GOROOT and GOPATH are deliberately not a
+// part of the configuration, so that the cache can be shared between machines.
 //
-// There is no upper limit for the total cache size. It can be cleared
-// programmatically via the Clear() function, or the user can just delete the
-// directory if it grows too big.
+// Archives are keyed by a hash of the package's build inputs, computed by the
+// caller from the contents of the sources and hashes of the dependencies,
+// similar to the go command's action IDs. A change in any of the inputs
+// produces a different key, so a stale archive is never returned, and file
+// modification times don't matter. Archives of the previous versions of the
+// sources are kept until the cache is trimmed, see Trim.
 //
-// TODO(nevkontakte): changes in the input sources or dependencies doesn't
-// currently invalidate the cache. This is handled at the higher level by
-// checking cached archive timestamp against loaded package modification time.
+// Each cached archive is stored with a checksum of its contents, which is
+// verified when the archive is loaded. Corrupted archives are deleted and
+// treated as cache misses.
 //
-// TODO(nevkontakte): this cache could benefit from checksum integrity checks.
+// Artifacts are stored by a Backend, which is the local cache directory by
+// default, see DiskBackend. A remote HTTP cache can be shared between machines,
+// see HTTPBackend and Layered.
 type BuildCache struct {
-	GOOS      string
-	GOARCH    string
-	GOROOT    string
-	GOPATH    string
+	GOOS   string
+	GOARCH string
+	// Release of the Go distribution the standard library comes from.
+	GoVersion string
 	BuildTags []string
 	Minify    bool
 	// When building for tests, import path of the package being tested. The
@@ -82,65 +97,84 @@ type BuildCache struct {
 	// may be imported by other packages in the binary we can't reuse the "normal"
 	// cache.
 	TestedPackage string
+	// Coverage analysis mode the package being tested is instrumented with, if
+	// any.
+	CoverMode string
+	// Whether the code counting heap allocations is emitted.
+	TrackAllocations bool
+	// Whether the library API of the packages is generated, see
+	// compiler.CompileOptions.Library.
+	Library bool
+	// Whether the TypeScript types of the exports are collected, see
+	// compiler.CompileOptions.Declarations.
+	Declarations bool
+	// Storage of the cached artifacts, DiskBackend if nil.
+	Backend Backend
 }
 
 func (bc BuildCache) String() string {
 	return fmt.Sprintf("%#v", bc)
 }
 
-// StoreArchive compiled archive in the cache. Any error inside this method
-// will cause the cache not to be persisted.
-func (bc *BuildCache) StoreArchive(a *compiler.Archive) {
+// backend returns the storage of the cached artifacts.
+func (bc *BuildCache) backend() Backend {
+	if bc.Backend == nil {
+		return DiskBackend{}
+	}
+	return bc.Backend
+}
+
+// StoreArchive compiled archive in the cache under the hash of its build
+// inputs. Any error inside this method will cause the cache not to be
+// persisted.
+func (bc *BuildCache) StoreArchive(a *compiler.Archive, srcHash string) {
 	if bc == nil {
 		return // Caching is disabled.
 	}
-	path := cachedPath(bc.archiveKey(a.ImportPath))
-	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
-		log.Warningf("Failed to create build cache directory: %v", err)
-		return
-	}
-	// Write the archive in a temporary file first to avoid concurrency errors.
-	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
-	if err != nil {
-		log.Warningf("Failed to temporary build cache file: %v", err)
-		return
-	}
-	defer f.Close()
-	if err := compiler.WriteArchive(a, f); err != nil {
+	var buf bytes.Buffer
+	if err := compiler.WriteArchive(a, &buf); err != nil {
 		log.Warningf("Failed to write build cache archive %q: %v", a, err)
-		// Make sure we don't leave a half-written archive behind.
-		os.Remove(f.Name())
 		return
 	}
-	f.Close()
-	// Rename fully written file into its permanent name.
-	if err := os.Rename(f.Name(), path); err != nil {
-		log.Warningf("Failed to rename build cache archive to %q: %v", path, err)
+	var data bytes.Buffer
+	writeChecked(&data, buf.Bytes())
+	key := cacheKey(bc.archiveKey(a.ImportPath, srcHash))
+	if err := bc.backend().Put(key, data.Bytes()); err != nil {
+		log.Warningf("Failed to store build cache archive %q: %v", a, err)
+		return
 	}
-	log.Infof("Successfully stored build archive %q as %q.", a, path)
+	log.Infof("Successfully stored build archive %q as %q.", a, key)
 }
 
-// LoadArchive returns a previously cached archive of the given package or nil
-// if it wasn't previously stored.
+// LoadArchive returns a previously cached archive of the given package built
+// out of the inputs with the given hash, or nil if it wasn't previously stored.
 //
 // The returned archive would have been built with the same configuration as
 // the build cache was.
-func (bc *BuildCache) LoadArchive(importPath string) *compiler.Archive {
+func (bc *BuildCache) LoadArchive(importPath, srcHash string) *compiler.Archive {
 	if bc == nil {
 		return nil // Caching is disabled.
 	}
-	path := cachedPath(bc.archiveKey(importPath))
-	f, err := os.Open(path)
+	key := cacheKey(bc.archiveKey(importPath, srcHash))
+	stored, err := bc.backend().Get(key)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			log.Infof("No cached package archive for %q.", importPath)
 		} else {
 			log.Warningf("Failed to open cached package archive for %q: %v", importPath, err)
 		}
 		return nil // Cache miss.
 	}
-	defer f.Close()
-	a, err := compiler.ReadArchive(importPath, f)
+	data, err := readChecked(bytes.NewReader(stored))
+	if err != nil {
+		log.Warningf("Failed to read cached package archive for %q: %v", importPath, err)
+		// Remove the corrupted archive, so that it's replaced by a good one.
+		if r, ok := bc.backend().(Remover); ok {
+			r.Remove(key)
+		}
+		return nil // Cache miss.
+	}
+	a, err := compiler.ReadArchive(importPath, bytes.NewReader(data))
 	if err != nil {
 		log.Warningf("Failed to read cached package archive for %q: %v", importPath, err)
 		return nil // Invalid/corrupted archive, cache miss.
@@ -152,10 +186,68 @@ func (bc *BuildCache) LoadArchive(importPath string) *compiler.Archive {
 // commonKey returns a part of the cache key common for all artifacts generated
 // under a given BuildCache configuration.
 func (bc *BuildCache) commonKey() string {
-	return fmt.Sprintf("%#v + %v", *bc, compiler.Version)
+	params := *bc
+	params.Backend = nil // Where artifacts are stored doesn't affect them.
+	return fmt.Sprintf("%#v + %v + %v", params, compiler.Version, compilerID())
+}
+
+// compilerID returns a hash of the running gopherjs executable, so that
+// archives built by a different build of the compiler, such as one with
+// changes to the prelude made under the same version, are not reused.
+func compilerID() string {
+	compilerHash.once.Do(func() {
+		exe, err := os.Executable()
+		if err == nil {
+			var data []byte
+			if data, err = os.ReadFile(exe); err == nil {
+				compilerHash.id = fmt.Sprintf("%x", sha256.Sum256(data))
+				return
+			}
+		}
+		log.Warningf("Failed to identify the gopherjs executable, build cache will not be reused: %v", err)
+		compilerHash.id = fmt.Sprintf("unknown-%d", time.Now().UnixNano())
+	})
+	return compilerHash.id
+}
+
+var compilerHash struct {
+	once sync.Once
+	id   string
 }
 
 // archiveKey returns a full cache key for a package's compiled archive.
-func (bc *BuildCache) archiveKey(importPath string) string {
-	return path.Join("archive", bc.commonKey(), importPath)
+func (bc *BuildCache) archiveKey(importPath, srcHash string) string {
+	return path.Join("archive", bc.commonKey(), importPath, srcHash)
+}
+
+// checksumPrefix starts the header line of the cached files, which is followed
+// by a hex-encoded SHA-256 sum of the rest of the file.
+const checksumPrefix = "gopherjs-cache sha256:"
+
+// writeChecked writes data to buf prefixed by its checksum header.
+func writeChecked(buf *bytes.Buffer, data []byte) {
+	fmt.Fprintf(buf, "%s%x\n", checksumPrefix, sha256.Sum256(data))
+	buf.Write(data)
+}
+
+// readChecked reads data written by writeChecked, verifying its checksum.
+func readChecked(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum header: %w", err)
+	}
+	wantHex := strings.TrimPrefix(strings.TrimSuffix(header, "\n"), checksumPrefix)
+	want, err := hex.DecodeString(wantHex)
+	if err != nil || len(want) != sha256.Size || len(wantHex)+len(checksumPrefix)+1 != len(header) {
+		return nil, fmt.Errorf("malformed checksum header %q", header)
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256.Sum256(data); !bytes.Equal(got[:], want) {
+		return nil, fmt.Errorf("checksum mismatch: got %x, want %x", got, want)
+	}
+	return data, nil
 }
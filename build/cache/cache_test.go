@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/gopherjs/gopherjs/compiler"
@@ -16,11 +19,11 @@ func TestStore(t *testing.T) {
 	}
 
 	bc := BuildCache{}
-	if got := bc.LoadArchive(want.ImportPath); got != nil {
+	if got := bc.LoadArchive(want.ImportPath, "hash"); got != nil {
 		t.Errorf("Got: %s was found in the cache. Want: empty cache.", got.ImportPath)
 	}
-	bc.StoreArchive(want)
-	got := bc.LoadArchive(want.ImportPath)
+	bc.StoreArchive(want, "hash")
+	got := bc.LoadArchive(want.ImportPath, "hash")
 	if got == nil {
 		t.Errorf("Got: %s wan not found in the cache. Want: archive is can be loaded after store.", want.ImportPath)
 	}
@@ -29,9 +32,24 @@ func TestStore(t *testing.T) {
 	}
 
 	// Make sure the package names are a part of the cache key.
-	if got := bc.LoadArchive("fake/other"); got != nil {
+	if got := bc.LoadArchive("fake/other", "hash"); got != nil {
 		t.Errorf("Got: fake/other was found in cache: %#v. Want: nil for packages that weren't cached.", got)
 	}
+
+	// Make sure the source hashes are a part of the cache key.
+	if got := bc.LoadArchive(want.ImportPath, "other hash"); got != nil {
+		t.Errorf("Got: %s was found in cache with a different source hash. Want: nil for changed sources.", got.ImportPath)
+	}
+
+	// Make sure an archive of the changed sources doesn't replace the old one,
+	// so that switching back to the old sources is a cache hit.
+	bc.StoreArchive(want, "other hash")
+	if got := bc.LoadArchive(want.ImportPath, "other hash"); got == nil {
+		t.Errorf("Got: %s was not found in the cache after store with a new source hash. Want: archive is loaded.", want.ImportPath)
+	}
+	if got := bc.LoadArchive(want.ImportPath, "hash"); got == nil {
+		t.Errorf("Got: %s was not found in the cache with the old source hash. Want: the old archive is kept.", want.ImportPath)
+	}
 }
 
 func TestInvalidation(t *testing.T) {
@@ -51,28 +69,185 @@ func TestInvalidation(t *testing.T) {
 			cache1: BuildCache{GOARCH: "m68k"},
 			cache2: BuildCache{GOARCH: "mos6502"},
 		}, {
-			cache1: BuildCache{GOROOT: "here"},
-			cache2: BuildCache{GOROOT: "there"},
+			cache1: BuildCache{GoVersion: "go1.18"},
+			cache2: BuildCache{GoVersion: "go1.19"},
 		}, {
-			cache1: BuildCache{GOPATH: "home"},
-			cache2: BuildCache{GOPATH: "away"},
+			cache1: BuildCache{Declarations: false},
+			cache2: BuildCache{Declarations: true},
 		},
 	}
 
 	for _, test := range tests {
 		a := &compiler.Archive{ImportPath: "package/fake"}
-		test.cache1.StoreArchive(a)
+		test.cache1.StoreArchive(a, "hash")
 
-		if got := test.cache2.LoadArchive(a.ImportPath); got != nil {
+		if got := test.cache2.LoadArchive(a.ImportPath, "hash"); got != nil {
 			t.Logf("-cache1,+cache2:\n%s", cmp.Diff(test.cache1, test.cache2))
 			t.Errorf("Got: %v loaded from cache. Want: build parameter change invalidates cache.", got)
 		}
 	}
 }
 
+func TestBuildModes(t *testing.T) {
+	cacheForTest(t)
+
+	exe := BuildCache{}
+	lib := BuildCache{Library: true}
+	exe.StoreArchive(&compiler.Archive{ImportPath: "fake/package"}, "hash")
+	if got := lib.LoadArchive("fake/package", "hash"); got != nil {
+		t.Errorf("Got: archive of the exe build mode loaded in the library build mode. Want: cache miss.")
+	}
+
+	lib.StoreArchive(&compiler.Archive{ImportPath: "fake/package", Library: &compiler.Library{}}, "hash")
+	if got := lib.LoadArchive("fake/package", "hash"); got == nil || got.Library == nil {
+		t.Errorf("Got: %v loaded in the library build mode. Want: archive with the library API.", got)
+	}
+	if got := exe.LoadArchive("fake/package", "hash"); got == nil || got.Library != nil {
+		t.Errorf("Got: %v loaded in the exe build mode. Want: archive without the library API.", got)
+	}
+}
+
+func TestCorruptedArchive(t *testing.T) {
+	cacheForTest(t)
+
+	a := &compiler.Archive{ImportPath: "fake/package"}
+	bc := BuildCache{}
+	bc.StoreArchive(a, "hash")
+	path := DiskBackend{}.path(cacheKey(bc.archiveKey(a.ImportPath, "hash")))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the cached archive: %s", err)
+	}
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to corrupt the cached archive: %s", err)
+	}
+
+	if got := bc.LoadArchive(a.ImportPath, "hash"); got != nil {
+		t.Errorf("Got: %s was loaded from a corrupted cache file. Want: cache miss.", got.ImportPath)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Got: corrupted cache file still exists (err: %v). Want: the file is removed.", err)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	cacheForTest(t)
+
+	bc := BuildCache{}
+	now := time.Now()
+	var paths []string
+	for i, importPath := range []string{"fake/a", "fake/b", "fake/c"} {
+		bc.StoreArchive(&compiler.Archive{ImportPath: importPath}, "hash")
+		path := DiskBackend{}.path(cacheKey(bc.archiveKey(importPath, "hash")))
+		accessed := now.Add(time.Duration(i-4) * time.Hour)
+		if err := os.Chtimes(path, accessed, accessed); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	// Loading the oldest archive makes it the most recently used.
+	if bc.LoadArchive("fake/a", "hash") == nil {
+		t.Fatalf("Got: fake/a not found in the cache. Want: archive is loaded.")
+	}
+
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Trim(2 * info.Size()); err != nil {
+		t.Fatalf("Trim() returned error: %s", err)
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	if !exists(paths[0]) || exists(paths[1]) || !exists(paths[2]) {
+		t.Errorf("Got: fake/a, fake/b, fake/c exist after Trim(): %t, %t, %t. Want: only the least recently used fake/b is evicted.",
+			exists(paths[0]), exists(paths[1]), exists(paths[2]))
+	}
+
+	if err := RemoveOlderThan(90 * time.Minute); err != nil {
+		t.Fatalf("RemoveOlderThan() returned error: %s", err)
+	}
+	if !exists(paths[0]) || exists(paths[2]) {
+		t.Errorf("Got: fake/a, fake/c exist after RemoveOlderThan(): %t, %t. Want: only fake/a, used just now, is kept.",
+			exists(paths[0]), exists(paths[2]))
+	}
+	dirs, err := os.ReadDir(cacheRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if entries, err := os.ReadDir(filepath.Join(cacheRoot, dir.Name())); err == nil && len(entries) == 0 {
+			t.Errorf("Got: emptied cache directory %q is left behind. Want: the directory is removed.", dir.Name())
+		}
+	}
+}
+
+func TestTrimAfterWrite(t *testing.T) {
+	cacheForTest(t)
+
+	if got, err := MaxSize(); err != nil || got != DefaultMaxSize {
+		t.Errorf("MaxSize() returned %d, %v. Want: %d, nil by default.", got, err, DefaultMaxSize)
+	}
+
+	data := make([]byte, 1000)
+	t.Setenv(MaxSizeEnv, "2000")
+	put := func(key string, data []byte) int {
+		t.Helper()
+		if err := (DiskBackend{}).Put(key, data); err != nil {
+			t.Fatalf("Put(%q) returned error: %s", key, err)
+		}
+		files, err := listFiles()
+		if err != nil {
+			t.Fatalf("listFiles() returned error: %s", err)
+		}
+		return len(files)
+	}
+	put("aa01", data)
+	put("aa02", data)
+	if n := put("aa03", data); n != 2 {
+		t.Errorf("Got: %d files in the cache after writes exceeding the limit. Want: 2 files.", n)
+	}
+	// Writes smaller than a tenth of the limit don't trigger trimming until they
+	// add up.
+	if n := put("aa04", data[:100]); n != 3 {
+		t.Errorf("Got: %d files in the cache after a small write. Want: 3 files, the cache isn't trimmed.", n)
+	}
+	if n := put("aa05", data[:100]); n != 3 {
+		t.Errorf("Got: %d files in the cache after small writes adding up to a tenth of the limit. Want: 3 files.", n)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{size: "512", want: 512},
+		{size: "100K", want: 100 << 10},
+		{size: "200MB", want: 200 << 20},
+		{size: "2GiB", want: 2 << 30},
+		{size: "1t", want: 1 << 40},
+	}
+	for _, test := range tests {
+		got, err := ParseSize(test.size)
+		if err != nil || got != test.want {
+			t.Errorf("ParseSize(%q) returned %d, %v. Want: %d, nil.", test.size, got, err, test.want)
+		}
+	}
+	for _, size := range []string{"", "MB", "-1", "1.5G", "10X", "9999999999T"} {
+		if got, err := ParseSize(size); err == nil {
+			t.Errorf("ParseSize(%q) returned %d. Want: error.", size, got)
+		}
+	}
+}
+
 func cacheForTest(t *testing.T) {
 	t.Helper()
 	originalRoot := cacheRoot
 	t.Cleanup(func() { cacheRoot = originalRoot })
 	cacheRoot = t.TempDir()
+	lastTrim.done, lastTrim.written = false, 0
 }
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// testLogHeader starts the log of the files and environment variables read by
+// a test binary, which the testing package writes to the file given by the
+// -test.testlogfile flag.
+const testLogHeader = "# test log"
+
+// TestKey returns the key of the result of running the test binary with the
+// given contents in the directory with the arguments.
+//
+// Only arguments that don't affect the result beyond the test output, such as
+// -test.run or -test.v, may be used for runs whose results are cached, the same
+// way "go test" only caches runs with a restricted set of flags.
+func TestKey(binary []byte, args []string, dir string) string {
+	return cacheKey("test", fmt.Sprintf("%x", sha256.Sum256(binary)), fmt.Sprintf("%q", args), dir)
+}
+
+// testInput is a file or an environment variable read by a test binary.
+type testInput struct {
+	Op   string // "getenv", "open" or "stat", as in the test log.
+	Name string // Variable name or absolute path.
+}
+
+// testResult is a cached test result.
+type testResult struct {
+	Inputs     []testInput
+	InputsHash string
+	Output     []byte
+}
+
+// LoadTestResult returns the output of a previous passing test run with the key,
+// provided that the files and environment variables the test read are still
+// the same.
+//
+// Unlike build artifacts, test results are always stored in the local cache
+// directory, since they depend on the local files.
+func LoadTestResult(key string) ([]byte, bool) {
+	stored, err := DiskBackend{}.Get(key)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warningf("Failed to open cached test result: %v", err)
+		}
+		return nil, false // Cache miss.
+	}
+	data, err := readChecked(bytes.NewReader(stored))
+	if err != nil {
+		log.Warningf("Failed to read cached test result: %v", err)
+		DiskBackend{}.Remove(key)
+		return nil, false
+	}
+	var r testResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		log.Warningf("Failed to decode cached test result: %v", err)
+		return nil, false
+	}
+	if hashTestInputs(r.Inputs) != r.InputsHash {
+		log.Infof("Inputs of the cached test result %q have changed.", key)
+		return nil, false
+	}
+	return r.Output, true
+}
+
+// StoreTestResult stores the output of a passing test run with the key, along
+// with the inputs listed in the test log written by the test binary, which was
+// run in the directory dir. Any error inside this function will cause the
+// result not to be persisted.
+func StoreTestResult(key string, dir string, output []byte, testLog []byte) {
+	inputs, err := parseTestLog(testLog, dir)
+	if err != nil {
+		log.Warningf("Failed to parse the test log: %v", err)
+		return
+	}
+	data, err := json.Marshal(testResult{
+		Inputs:     inputs,
+		InputsHash: hashTestInputs(inputs),
+		Output:     output,
+	})
+	if err != nil {
+		log.Warningf("Failed to encode the test result: %v", err)
+		return
+	}
+	var buf bytes.Buffer
+	writeChecked(&buf, data)
+	if err := (DiskBackend{}).Put(key, buf.Bytes()); err != nil {
+		log.Warningf("Failed to store the test result: %v", err)
+		return
+	}
+	log.Infof("Successfully stored test result as %q.", key)
+}
+
+// parseTestLog returns the inputs listed in a test log, with paths relative to
+// the directory dir the test started in resolved.
+func parseTestLog(testLog []byte, dir string) ([]testInput, error) {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		dir = wd
+	}
+	sc := bufio.NewScanner(bytes.NewReader(testLog))
+	if !sc.Scan() || sc.Text() != testLogHeader {
+		return nil, fmt.Errorf("missing %q header", testLogHeader)
+	}
+	var inputs []testInput
+	seen := map[testInput]bool{}
+	for sc.Scan() {
+		op, name, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", sc.Text())
+		}
+		switch op {
+		case "getenv":
+		case "open", "stat", "chdir":
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(dir, name)
+			}
+			if op == "chdir" {
+				dir = name
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("unknown operation in line %q", sc.Text())
+		}
+		in := testInput{Op: op, Name: name}
+		if !seen[in] {
+			seen[in] = true
+			inputs = append(inputs, in)
+		}
+	}
+	return inputs, sc.Err()
+}
+
+// hashTestInputs returns a hash of the current state of the inputs: the values
+// of the environment variables, the contents of the opened files and the
+// metadata of the others.
+func hashTestInputs(inputs []testInput) string {
+	h := sha256.New()
+	for _, in := range inputs {
+		fmt.Fprintf(h, "%s %s\n", in.Op, in.Name)
+		switch in.Op {
+		case "getenv":
+			v, ok := os.LookupEnv(in.Name)
+			fmt.Fprintf(h, "%t %q\n", ok, v)
+		case "stat":
+			fi, err := os.Stat(in.Name)
+			if err != nil {
+				fmt.Fprintf(h, "error\n")
+				continue
+			}
+			fmt.Fprintf(h, "%v %d %d\n", fi.Mode(), fi.Size(), fi.ModTime().UnixNano())
+		case "open":
+			hashOpened(h, in.Name)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// hashOpened writes the contents of the opened file to h, or the list of
+// entries if it's a directory.
+func hashOpened(h hash.Hash, name string) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		fmt.Fprintf(h, "error\n")
+		return
+	}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			fmt.Fprintf(h, "error\n")
+			return
+		}
+		for _, e := range entries {
+			fmt.Fprintf(h, "%s %v\n", e.Name(), e.Type())
+		}
+		return
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		fmt.Fprintf(h, "error\n")
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(h, "%d\n", fi.Size())
+	if _, err := io.Copy(h, f); err != nil {
+		fmt.Fprintf(h, "error\n")
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTestResult(t *testing.T) {
+	cacheForTest(t)
+	dir := t.TempDir()
+	data := filepath.Join(dir, "testdata", "input.txt")
+	if err := os.MkdirAll(filepath.Dir(data), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(data, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPHERJS_TEST_INPUT", "one")
+
+	key := TestKey([]byte("binary"), []string{"-test.run", "TestA"}, dir)
+	if _, ok := LoadTestResult(key); ok {
+		t.Fatalf("LoadTestResult() found a result in an empty cache")
+	}
+	testLog := "# test log\n" +
+		"getenv GOPHERJS_TEST_INPUT\n" +
+		"chdir testdata\n" +
+		"open input.txt\n" +
+		"stat " + filepath.Join(dir, "missing") + "\n"
+	StoreTestResult(key, dir, []byte("PASS\n"), []byte(testLog))
+
+	got, ok := LoadTestResult(key)
+	if !ok {
+		t.Fatalf("LoadTestResult() didn't find the stored result")
+	}
+	if diff := cmp.Diff("PASS\n", string(got)); diff != "" {
+		t.Errorf("LoadTestResult() returned diff in output (-want,+got):\n%s", diff)
+	}
+
+	// Other binaries and flags have their own results.
+	if other := TestKey([]byte("binary"), []string{"-test.run", "TestB"}, dir); other == key {
+		t.Errorf("TestKey() returned the same key for different arguments")
+	}
+	if other := TestKey([]byte("other binary"), []string{"-test.run", "TestA"}, dir); other == key {
+		t.Errorf("TestKey() returned the same key for different binaries")
+	}
+
+	// Changes in the inputs invalidate the result.
+	changes := []struct {
+		name   string
+		change func()
+		revert func()
+	}{{
+		name:   "environment variable",
+		change: func() { os.Setenv("GOPHERJS_TEST_INPUT", "two") },
+		revert: func() { os.Setenv("GOPHERJS_TEST_INPUT", "one") },
+	}, {
+		name:   "opened file",
+		change: func() { os.WriteFile(data, []byte("two"), 0o644) },
+		revert: func() { os.WriteFile(data, []byte("one"), 0o644) },
+	}, {
+		name:   "stat file",
+		change: func() { os.WriteFile(filepath.Join(dir, "missing"), nil, 0o644) },
+		revert: func() { os.Remove(filepath.Join(dir, "missing")) },
+	}}
+	for _, c := range changes {
+		c.change()
+		if _, ok := LoadTestResult(key); ok {
+			t.Errorf("LoadTestResult() found a result after a change of the %s", c.name)
+		}
+		c.revert()
+		if _, ok := LoadTestResult(key); !ok {
+			t.Errorf("LoadTestResult() didn't find the result after the %s was reverted", c.name)
+		}
+	}
+}
+
+func TestTestResultInvalidLog(t *testing.T) {
+	cacheForTest(t)
+	key := TestKey([]byte("binary"), nil, "")
+	StoreTestResult(key, "", []byte("PASS\n"), []byte("getenv HOME\n"))
+	if _, ok := LoadTestResult(key); ok {
+		t.Errorf("LoadTestResult() found a result stored with a test log missing its header")
+	}
+}
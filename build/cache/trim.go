@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxSizeEnv is the environment variable that limits the total size of the
+// build cache, see MaxSize.
+const MaxSizeEnv = "GOPHERJS_CACHE_MAX_SIZE"
+
+// DefaultMaxSize is the build cache size limit used unless MaxSizeEnv is set.
+const DefaultMaxSize = 1 << 30
+
+// lastTrim tracks the amount of data stored in the cache since it was last
+// trimmed by the process.
+var lastTrim struct {
+	sync.Mutex
+	done    bool
+	written int64
+}
+
+// trimAfterWrite evicts the least recently used artifacts if the cache exceeds
+// the size returned by MaxSize, after size bytes were stored in it. To bound
+// the cost of listing the cache files, it's done on the first write of the
+// process and then every time a tenth of the limit is written, so the cache
+// may exceed the limit by that much.
+func trimAfterWrite(size int64) {
+	maxSize, err := MaxSize()
+	if err != nil || maxSize == 0 {
+		return
+	}
+	lastTrim.Lock()
+	defer lastTrim.Unlock()
+	lastTrim.written += size
+	if lastTrim.done && lastTrim.written < maxSize/10 {
+		return
+	}
+	lastTrim.done = true
+	lastTrim.written = 0
+	if err := Trim(maxSize); err != nil {
+		log.Warningf("Failed to trim the build cache: %v", err)
+	}
+}
+
+// markUsed updates the last access time of the cached file.
+func markUsed(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		log.Warningf("Failed to update access time of %q: %v", path, err)
+	}
+}
+
+// cachedFile is a file in the cache directory.
+type cachedFile struct {
+	path     string
+	size     int64
+	accessed time.Time
+}
+
+// listFiles returns all files in the cache, least recently used first.
+func listFiles() ([]cachedFile, error) {
+	var files []cachedFile
+	err := filepath.WalkDir(cacheRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // Files may be removed by a concurrent build.
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), accessed: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build cache files: %w", err)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].accessed.Before(files[j].accessed) })
+	return files, nil
+}
+
+// removeFiles deletes the given cached files, as well as the cache
+// subdirectories left empty.
+func removeFiles(files []cachedFile) error {
+	dirs := map[string]bool{}
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove build cache file: %w", err)
+		}
+		dirs[filepath.Dir(f.path)] = true
+	}
+	for dir := range dirs {
+		os.Remove(dir) // Fails if the directory is not empty, which is fine.
+	}
+	return nil
+}
+
+// Trim evicts the least recently used artifacts from the cache until its total
+// size doesn't exceed maxSize bytes.
+func Trim(maxSize int64) error {
+	files, err := listFiles()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	n := 0
+	for ; n < len(files) && total > maxSize; n++ {
+		total -= files[n].size
+	}
+	if n > 0 {
+		log.Infof("Evicting %d least recently used files from the build cache.", n)
+	}
+	return removeFiles(files[:n])
+}
+
+// RemoveOlderThan removes the cached artifacts that haven't been used for at
+// least the given duration.
+func RemoveOlderThan(d time.Duration) error {
+	files, err := listFiles()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-d)
+	n := sort.Search(len(files), func(i int) bool { return !files[i].accessed.Before(cutoff) })
+	return removeFiles(files[:n])
+}
+
+// MaxSize returns the maximum build cache size in bytes configured by the
+// GOPHERJS_CACHE_MAX_SIZE environment variable, DefaultMaxSize if it's not set,
+// or 0 if it's set to 0 and the cache size is not limited.
+func MaxSize() (int64, error) {
+	v := os.Getenv(MaxSizeEnv)
+	if v == "" {
+		return DefaultMaxSize, nil
+	}
+	size, err := ParseSize(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", MaxSizeEnv, err)
+	}
+	return size, nil
+}
+
+// ParseSize parses a size in bytes with an optional binary unit suffix, for
+// example "512", "100K", "200MB" or "2GiB". Units are powers of 1024.
+func ParseSize(s string) (int64, error) {
+	num := strings.TrimSpace(s)
+	num = strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(num), "B"), "I")
+	multiplier := int64(1)
+	if n := len(num); n > 0 {
+		if i := strings.IndexByte("KMGT", num[n-1]); i >= 0 {
+			multiplier = 1 << (10 * (i + 1))
+			num = num[:n-1]
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || size < 0 || size > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("malformed size %q", s)
+	}
+	return size * multiplier, nil
+}
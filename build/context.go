@@ -251,7 +251,9 @@ func (sc simpleCtx) applyPostloadTweaks(pkg *build.Package) *build.Package {
 	case "runtime":
 		pkg.GoFiles = []string{} // Package sources are completely replaced in natives.
 	case "runtime/pprof":
-		pkg.GoFiles = nil
+		// Only the protocol buffer encoder is reused by the natives, which write
+		// the profiles themselves.
+		pkg.GoFiles = []string{"protobuf.go"}
 	case "sync":
 		// GopherJS completely replaces sync.Pool implementation with a simpler one,
 		// since it always executes in a single-threaded environment.
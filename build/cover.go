@@ -0,0 +1,373 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CoverModes are the supported coverage analysis modes, see Options.CoverMode.
+// Since JavaScript is single-threaded, "atomic" is the same as "count".
+var CoverModes = []string{"set", "count", "atomic"}
+
+// CoverVar is the variable holding the coverage counters of a source file of
+// the package under test, which is declared in the package when it's
+// instrumented for coverage analysis.
+type CoverVar struct {
+	File string // Import path of the package joined with the file name, as in coverage profiles.
+	Var  string
+}
+
+// CoverVars returns the coverage counter variables of the package's non-test
+// sources.
+func (p *PackageData) CoverVars() []CoverVar {
+	var vars []CoverVar
+	for i, name := range coverFiles(p) {
+		vars = append(vars, CoverVar{File: path.Join(p.ImportPath, name), Var: coverVar(i)})
+	}
+	return vars
+}
+
+// coverFiles returns the names of the package's sources instrumented for
+// coverage analysis.
+func coverFiles(p *PackageData) []string {
+	var names []string
+	for _, name := range p.GoFiles {
+		if !strings.HasSuffix(name, "_test.go") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// coverVar returns the name of the coverage counters variable of the i-th
+// instrumented source of a package.
+func coverVar(i int) string {
+	return "GoCover_" + strconv.Itoa(i)
+}
+
+// coverPackage returns whether the package is instrumented for coverage
+// analysis, which is the case for the package under test.
+func (s *Session) coverPackage(pkg *PackageData) bool {
+	return s.options.CoverMode != "" && pkg.IsTest && pkg.ImportPath == s.options.TestedPackage
+}
+
+// instrumentCoverage adds coverage counters to the package's non-test sources
+// the same way "go tool cover" does, and returns the files along with a file
+// declaring the counter variables.
+func instrumentCoverage(pkg *PackageData, files []*ast.File, fileSet *token.FileSet, mode string) ([]*ast.File, error) {
+	vars := map[string]string{} // Source file path to the counters variable.
+	for i, name := range coverFiles(pkg) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(pkg.Dir, name)
+		}
+		vars[name] = coverVar(i)
+	}
+
+	decls := &bytes.Buffer{}
+	fmt.Fprintf(decls, "package %s\n", files[0].Name.Name)
+	for _, file := range files {
+		v, ok := vars[fileSet.File(file.Pos()).Name()]
+		if !ok {
+			continue
+		}
+		c := &coverer{mode: mode, counters: v}
+		ast.Walk(c, file)
+
+		n := len(c.blocks)
+		var pos, numStmt []string
+		for _, b := range c.blocks {
+			start, end := fileSet.Position(b.start), fileSet.Position(b.end)
+			pos = append(pos, fmt.Sprintf("%d, %d, %#x", start.Line, end.Line, (end.Column&0xFFFF)<<16|(start.Column&0xFFFF)))
+			numStmt = append(numStmt, strconv.Itoa(b.numStmt))
+		}
+		fmt.Fprintf(decls, "\nvar %s = struct {\n\tCount   [%d]uint32\n\tPos     [%d]uint32\n\tNumStmt [%d]uint16\n}{\n\tPos: [%d]uint32{%s},\n\tNumStmt: [%d]uint16{%s},\n}\n",
+			v, n, 3*n, n, 3*n, strings.Join(pos, ", "), n, strings.Join(numStmt, ", "))
+	}
+	declFile, err := parser.ParseFile(fileSet, filepath.Join(pkg.Dir, "_gopherjs_cover.go"), decls, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare coverage counters of %s: %w", pkg.ImportPath, err)
+	}
+	return append(files, declFile), nil
+}
+
+// coverBlock is a basic block of the source code with a coverage counter.
+type coverBlock struct {
+	start, end token.Pos
+	numStmt    int
+}
+
+// coverer adds coverage counters to the basic blocks of a source file. It's
+// a port of the AST walk of cmd/cover, which edits the AST instead of the
+// source text.
+type coverer struct {
+	mode     string
+	counters string // Name of the counters variable.
+	blocks   []coverBlock
+}
+
+func (c *coverer) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.BlockStmt:
+		// If it's a switch or select, the body is a list of case clauses; don't
+		// tag the block itself.
+		if len(n.List) > 0 {
+			switch n.List[0].(type) {
+			case *ast.CaseClause:
+				for _, s := range n.List {
+					clause := s.(*ast.CaseClause)
+					clause.Body = c.addCounters(clause.Colon+1, clause.End(), clause.Body, false)
+				}
+				return c
+			case *ast.CommClause:
+				for _, s := range n.List {
+					clause := s.(*ast.CommClause)
+					clause.Body = c.addCounters(clause.Colon+1, clause.End(), clause.Body, false)
+				}
+				return c
+			}
+		}
+		n.List = c.addCounters(n.Lbrace, n.Rbrace+1, n.List, true) // +1 to step past closing brace.
+	case *ast.IfStmt:
+		if n.Init != nil {
+			ast.Walk(c, n.Init)
+		}
+		ast.Walk(c, n.Cond)
+		ast.Walk(c, n.Body)
+		if n.Else == nil {
+			return nil
+		}
+		// The elses are special, because if we have
+		//	if x {
+		//	} else if y {
+		//	}
+		// we want to cover the "if y". To do this, we need a place to drop the
+		// counter, so we add a hidden block.
+		switch s := n.Else.(type) {
+		case *ast.IfStmt:
+			n.Else = &ast.BlockStmt{
+				Lbrace: n.Body.End(), // Start at the end of the "if" block so the elses cover it.
+				List:   []ast.Stmt{s},
+				Rbrace: s.End(),
+			}
+		case *ast.BlockStmt:
+			s.Lbrace = n.Body.End() // Start at the end of the "if" block so the elses cover it.
+		}
+		ast.Walk(c, n.Else)
+		return nil
+	case *ast.SelectStmt:
+		// Don't annotate an empty select.
+		if n.Body == nil || len(n.Body.List) == 0 {
+			return nil
+		}
+	case *ast.SwitchStmt:
+		// Don't annotate an empty switch, but still walk its header.
+		if n.Body == nil || len(n.Body.List) == 0 {
+			if n.Init != nil {
+				ast.Walk(c, n.Init)
+			}
+			if n.Tag != nil {
+				ast.Walk(c, n.Tag)
+			}
+			return nil
+		}
+	case *ast.TypeSwitchStmt:
+		// Don't annotate an empty type switch, but still walk its header.
+		if n.Body == nil || len(n.Body.List) == 0 {
+			if n.Init != nil {
+				ast.Walk(c, n.Init)
+			}
+			ast.Walk(c, n.Assign)
+			return nil
+		}
+	case *ast.FuncDecl:
+		// Don't annotate functions with blank names, they can't be executed.
+		if n.Name.Name == "_" {
+			return nil
+		}
+	}
+	return c
+}
+
+// addCounters splits the statement list into basic blocks and returns the list
+// with a counter at the start of each block. The blocks cover the source from
+// pos to blockEnd.
+func (c *coverer) addCounters(pos, blockEnd token.Pos, list []ast.Stmt, extendToClosingBrace bool) []ast.Stmt {
+	// Make sure an empty block gets a counter too.
+	if len(list) == 0 {
+		return []ast.Stmt{c.newCounter(pos, blockEnd, 0)}
+	}
+	// Copy the list, since it may be mutated.
+	list = append([]ast.Stmt(nil), list...)
+	var result []ast.Stmt
+	for {
+		// Find the first statement that affects the flow of control, which is
+		// the last statement of the basic block.
+		var last int
+		end := blockEnd
+		for last = 0; last < len(list); last++ {
+			stmt := list[last]
+			end = c.statementBoundary(stmt)
+			if c.endsBasicSourceBlock(stmt) {
+				// A labeled statement may be the target of a goto, which starts
+				// a basic block. Unless the statement is a control statement, put
+				// the counter between the label and the statement:
+				//	foo: COUNTER[n]++; stmt
+				if label, ok := stmt.(*ast.LabeledStmt); ok && !isControl(label.Stmt) {
+					newLabel := *label
+					newLabel.Stmt = &ast.EmptyStmt{Semicolon: label.Stmt.Pos(), Implicit: true}
+					end = label.Pos() // The previous block ends before the label.
+					list[last] = &newLabel
+					list = append(list, nil)
+					copy(list[last+1:], list[last:])
+					list[last+1] = label.Stmt
+				}
+				last++
+				extendToClosingBrace = false // The block is broken up now.
+				break
+			}
+		}
+		if extendToClosingBrace {
+			end = blockEnd
+		}
+		if pos != end { // There may be no source to cover, e.g. if blocks abut.
+			result = append(result, c.newCounter(pos, end, last))
+		}
+		result = append(result, list[:last]...)
+		list = list[last:]
+		if len(list) == 0 {
+			break
+		}
+		pos = list[0].Pos()
+	}
+	return result
+}
+
+// newCounter records the block and returns the statement incrementing its
+// counter.
+func (c *coverer) newCounter(start, end token.Pos, numStmt int) ast.Stmt {
+	counter := &ast.IndexExpr{
+		X:     &ast.SelectorExpr{X: ast.NewIdent(c.counters), Sel: ast.NewIdent("Count")},
+		Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(c.blocks))},
+	}
+	c.blocks = append(c.blocks, coverBlock{start: start, end: end, numStmt: numStmt})
+	if c.mode == "set" {
+		return &ast.AssignStmt{Lhs: []ast.Expr{counter}, Tok: token.ASSIGN, Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "1"}}}
+	}
+	return &ast.IncDecStmt{X: counter, Tok: token.INC}
+}
+
+// statementBoundary finds the location in s that terminates the current basic
+// block in the source.
+func (c *coverer) statementBoundary(s ast.Stmt) token.Pos {
+	// Control flow statements are easy.
+	switch s := s.(type) {
+	case *ast.BlockStmt:
+		// Treat blocks like basic blocks to avoid overlapping counters.
+		return s.Lbrace
+	case *ast.IfStmt:
+		if pos, ok := funcLitPos(s.Init); ok {
+			return pos
+		}
+		if pos, ok := funcLitPos(s.Cond); ok {
+			return pos
+		}
+		return s.Body.Lbrace
+	case *ast.ForStmt:
+		if pos, ok := funcLitPos(s.Init); ok {
+			return pos
+		}
+		if pos, ok := funcLitPos(s.Cond); ok {
+			return pos
+		}
+		if pos, ok := funcLitPos(s.Post); ok {
+			return pos
+		}
+		return s.Body.Lbrace
+	case *ast.LabeledStmt:
+		return c.statementBoundary(s.Stmt)
+	case *ast.RangeStmt:
+		if pos, ok := funcLitPos(s.X); ok {
+			return pos
+		}
+		return s.Body.Lbrace
+	case *ast.SwitchStmt:
+		if pos, ok := funcLitPos(s.Init); ok {
+			return pos
+		}
+		if pos, ok := funcLitPos(s.Tag); ok {
+			return pos
+		}
+		return s.Body.Lbrace
+	case *ast.SelectStmt:
+		return s.Body.Lbrace
+	case *ast.TypeSwitchStmt:
+		if pos, ok := funcLitPos(s.Init); ok {
+			return pos
+		}
+		return s.Body.Lbrace
+	}
+	// If not a control flow statement, it is a declaration, expression, call,
+	// etc. and it may have a function literal. If it does, that's the boundary.
+	if pos, ok := funcLitPos(s); ok {
+		return pos
+	}
+	return s.End()
+}
+
+// endsBasicSourceBlock reports whether s changes the flow of control: break,
+// if, etc., or if there's a function literal in it.
+func (c *coverer) endsBasicSourceBlock(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.BlockStmt, *ast.BranchStmt, *ast.ForStmt, *ast.IfStmt, *ast.RangeStmt,
+		*ast.SwitchStmt, *ast.SelectStmt, *ast.TypeSwitchStmt:
+		return true
+	case *ast.LabeledStmt:
+		return true // A goto may branch here, starting a new basic block.
+	case *ast.ExprStmt:
+		// Calls to panic change the flow.
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "panic" && len(call.Args) == 1 {
+				return true
+			}
+		}
+	}
+	_, ok := funcLitPos(s)
+	return ok
+}
+
+// isControl reports whether s is a control statement that, if labeled, cannot
+// be separated from its label.
+func isControl(s ast.Stmt) bool {
+	switch s.(type) {
+	case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.SelectStmt, *ast.TypeSwitchStmt:
+		return true
+	}
+	return false
+}
+
+// funcLitPos returns the position of the body of the first function literal in
+// the node.
+func funcLitPos(n ast.Node) (token.Pos, bool) {
+	if n == nil {
+		return token.NoPos, false
+	}
+	var pos token.Pos
+	ast.Inspect(n, func(n ast.Node) bool {
+		if pos.IsValid() {
+			return false
+		}
+		if lit, ok := n.(*ast.FuncLit); ok {
+			pos = lit.Body.Lbrace
+			return false
+		}
+		return true
+	})
+	return pos, pos.IsValid()
+}
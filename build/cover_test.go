@@ -0,0 +1,131 @@
+package build
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gopherjs/gopherjs/internal/srctesting"
+)
+
+func TestCoverage(t *testing.T) {
+	src := `package a
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	} else if x == 0 {
+		return 0
+	}
+	return x
+}
+
+func Loop(n int) (sum int) {
+	for i := 0; i < n; i++ {
+		switch {
+		case i%2 == 0:
+			sum += i
+		default:
+		}
+	}
+	return sum
+}
+`
+	pkgs := map[string]map[string]string{
+		"a": {
+			"a.go":      src,
+			"a_test.go": `package a; var _ = Abs(1)`,
+		},
+	}
+	s := newTestSession(t, pkgs, 1)
+	s.options.CoverMode = "count"
+	s.options.TestedPackage = "a"
+
+	pkg, err := s.xctx.Import("a", "", 0)
+	if err != nil {
+		t.Fatalf("Import() returned error: %s", err)
+	}
+	if diff := cmp.Diff([]CoverVar{{File: "a/a.go", Var: "GoCover_0"}}, pkg.CoverVars()); diff != "" {
+		t.Errorf("CoverVars() returned diff (-want,+got):\n%s", diff)
+	}
+
+	archive, err := s.BuildPackage(pkg.TestPackage())
+	if err != nil {
+		t.Fatalf("BuildPackage() returned error: %s", err)
+	}
+	var code strings.Builder
+	for _, d := range archive.Declarations {
+		code.Write(d.DeclCode)
+		code.Write(d.InitCode)
+	}
+	if !strings.Contains(code.String(), "GoCover_0") {
+		t.Errorf("Package under test doesn't use its coverage counters:\n%s", code.String())
+	}
+
+	// Other packages aren't instrumented.
+	s.options.TestedPackage = "b"
+	if s.coverPackage(pkg.TestPackage()) {
+		t.Errorf("coverPackage() returned true for a package which isn't tested")
+	}
+}
+
+func TestCoverageBlocks(t *testing.T) {
+	pkgs := map[string]map[string]string{
+		"a": {"a.go": `package a
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	} else if x == 0 {
+		return 0
+	}
+	return x
+}
+
+func Loop(n int) (sum int) {
+L:
+	for i := 0; i < n; i++ {
+		switch {
+		case i%2 == 0:
+			sum += i
+			continue L
+		default:
+		}
+		panic("odd")
+	}
+	return sum
+}
+`},
+	}
+	s := newTestSession(t, pkgs, 1)
+	pkg, err := s.xctx.Import("a", "", 0)
+	if err != nil {
+		t.Fatalf("Import() returned error: %s", err)
+	}
+	fileSet := token.NewFileSet()
+	files, _, err := parseAndAugment(s.xctx, pkg, false, fileSet)
+	if err != nil {
+		t.Fatalf("parseAndAugment() returned error: %s", err)
+	}
+	files, err = instrumentCoverage(pkg, files, fileSet, "set")
+	if err != nil {
+		t.Fatalf("instrumentCoverage() returned error: %s", err)
+	}
+
+	got := srctesting.Format(t, fileSet, files[len(files)-1])
+	want := `package a
+
+var GoCover_0 = struct {
+	Count   [11]uint32
+	Pos     [33]uint32
+	NumStmt [11]uint16
+}{
+	Pos:     [33]uint32{3, 4, 0xb0015, 9, 9, 0xa0002, 4, 6, 0x3000b, 6, 6, 0x130003, 6, 8, 0x30013, 12, 14, 0x19001c, 23, 23, 0xc0002, 14, 15, 0xa0019, 21, 21, 0xf0003, 16, 18, 0xe0011, 19, 19, 0xb000b},
+	NumStmt: [11]uint16{1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 0},
+}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("instrumentCoverage() declared counters with diff (-want,+got):\n%s", diff)
+	}
+}
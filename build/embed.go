@@ -0,0 +1,313 @@
+package build
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/compiler"
+	"golang.org/x/tools/go/buildutil"
+)
+
+// embedFile is a file matched by a //go:embed pattern.
+type embedFile struct {
+	Name string // Slash-separated path relative to the package directory.
+	Path string // Full file path for the build context the file came from.
+}
+
+// resolveEmbeds finds files that match the package's //go:embed patterns.
+//
+// The patterns are interpreted the same way the go tool does: a pattern
+// matching a directory embeds all files in it recursively, except for files
+// whose names begin with '.' or '_', unless the pattern has the "all:" prefix.
+// Files are enumerated using the build context the package came from.
+func resolveEmbeds(pkg *PackageData) ([]compiler.EmbedPattern, []embedFile, error) {
+	if len(pkg.EmbedPatterns) == 0 {
+		return nil, nil, nil
+	}
+
+	var patterns []compiler.EmbedPattern
+	patternFiles := map[string][]string{}
+	files := map[string]embedFile{}
+	for _, pattern := range pkg.EmbedPatterns {
+		if _, seen := patternFiles[pattern]; seen {
+			continue
+		}
+		matched, err := resolveEmbedPattern(pkg, pattern)
+		if err != nil {
+			err = fmt.Errorf("pattern %s: %w", pattern, err)
+			if pos := pkg.EmbedPatternPos[pattern]; len(pos) > 0 {
+				err = fmt.Errorf("%s: %w", pos[0], err)
+			}
+			return nil, nil, err
+		}
+		names := make([]string, len(matched))
+		for i, f := range matched {
+			names[i] = f.Name
+			files[f.Name] = f
+		}
+		sort.Strings(names)
+		patternFiles[pattern] = names
+		patterns = append(patterns, compiler.EmbedPattern{Pattern: pattern, Files: names})
+	}
+	if err := checkSingleFileEmbeds(pkg, patternFiles); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].Pattern < patterns[j].Pattern })
+
+	list := make([]embedFile, 0, len(files))
+	for _, f := range files {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return patterns, list, nil
+}
+
+// checkSingleFileEmbeds reports an error if the //go:embed patterns of a string
+// or []byte variable match more than one file, given the files each pattern
+// matches. The patterns of a variable are found by the positions go/build
+// recorded for them on the lines of the variable's directives. Variables of
+// named string or byte slice types aren't recognized without type checking,
+// and are only checked at run time.
+func checkSingleFileEmbeds(pkg *PackageData, patternFiles map[string][]string) error {
+	type line struct {
+		file string
+		line int
+	}
+	patternsOnLine := map[line][]string{}
+	for pattern, positions := range pkg.EmbedPatternPos {
+		for _, pos := range positions {
+			l := line{file: pos.Filename, line: pos.Line}
+			patternsOnLine[l] = append(patternsOnLine[l], pattern)
+		}
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		filename := buildutil.JoinPath(pkg.bctx, pkg.Dir, name)
+		r, err := buildutil.OpenFile(pkg.bctx, filename)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(fset, filename, r, parser.ParseComments)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if !isStringOrBytes(spec.Type) {
+					continue
+				}
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if doc == nil {
+					continue
+				}
+				matched := map[string]bool{}
+				for _, c := range doc.List {
+					if !strings.HasPrefix(c.Text, "//go:embed") {
+						continue
+					}
+					pos := fset.Position(c.Pos())
+					for _, pattern := range patternsOnLine[line{file: pos.Filename, line: pos.Line}] {
+						for _, f := range patternFiles[pattern] {
+							matched[f] = true
+						}
+					}
+				}
+				if len(matched) > 1 {
+					return fmt.Errorf("%s: invalid go:embed: multiple files for type %s", fset.Position(spec.Pos()), types.ExprString(spec.Type))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isStringOrBytes reports whether the type expression is string or []byte.
+func isStringOrBytes(typ ast.Expr) bool {
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		return typ.Name == "string"
+	case *ast.ArrayType:
+		elt, ok := typ.Elt.(*ast.Ident)
+		return typ.Len == nil && ok && (elt.Name == "byte" || elt.Name == "uint8")
+	}
+	return false
+}
+
+// resolveEmbedPattern returns files matching a single //go:embed pattern.
+func resolveEmbedPattern(pkg *PackageData, pattern string) ([]embedFile, error) {
+	glob, all := pattern, false
+	if strings.HasPrefix(pattern, "all:") {
+		glob, all = pattern[len("all:"):], true
+	}
+	if _, err := path.Match(glob, ""); err != nil || glob == "." || !fs.ValidPath(glob) {
+		return nil, fmt.Errorf("invalid pattern syntax")
+	}
+
+	matches, err := globCtx(pkg, glob)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []embedFile
+	for _, m := range matches {
+		rel, info := m.rel, m.info
+		what := "file"
+		if info.IsDir() {
+			what = "directory"
+		}
+		// Directories along the path must not begin a new module.
+		for dir := rel; dir != "."; dir = path.Dir(dir) {
+			if dir != rel || info.IsDir() {
+				if buildutil.FileExists(pkg.bctx, buildutil.JoinPath(pkg.bctx, pkg.Dir, dir, "go.mod")) {
+					return nil, fmt.Errorf("cannot embed %s %s: in different module", what, rel)
+				}
+			}
+			if isBadEmbedName(path.Base(dir)) {
+				return nil, fmt.Errorf("cannot embed %s %s: invalid name %s", what, rel, path.Base(dir))
+			}
+		}
+
+		switch {
+		case info.Mode().IsRegular():
+			result = append(result, embedFile{Name: rel, Path: buildutil.JoinPath(pkg.bctx, pkg.Dir, rel)})
+		case info.IsDir():
+			found, err := walkEmbedDir(pkg, rel, all)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("cannot embed directory %s: contains no embeddable files", rel)
+			}
+			result = append(result, found...)
+		default:
+			return nil, fmt.Errorf("cannot embed irregular file %s", rel)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no matching files found")
+	}
+	return result, nil
+}
+
+// walkEmbedDir returns all embeddable files in the directory dir, recursively.
+func walkEmbedDir(pkg *PackageData, dir string, all bool) ([]embedFile, error) {
+	entries, err := buildutil.ReadDir(pkg.bctx, buildutil.JoinPath(pkg.bctx, pkg.Dir, dir))
+	if err != nil {
+		return nil, err
+	}
+	var result []embedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		rel := path.Join(dir, name)
+		if isBadEmbedName(name) || ((name[0] == '.' || name[0] == '_') && !all) {
+			// Avoid hidden files that user may not know about.
+			continue
+		}
+		switch {
+		case entry.IsDir():
+			if buildutil.FileExists(pkg.bctx, buildutil.JoinPath(pkg.bctx, pkg.Dir, rel, "go.mod")) {
+				continue // Nested module.
+			}
+			found, err := walkEmbedDir(pkg, rel, all)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, found...)
+		case entry.Mode().IsRegular():
+			result = append(result, embedFile{Name: rel, Path: buildutil.JoinPath(pkg.bctx, pkg.Dir, rel)})
+		}
+	}
+	return result, nil
+}
+
+type globMatch struct {
+	rel  string // Slash-separated path relative to the package directory.
+	info os.FileInfo
+}
+
+// globCtx returns entries in the package directory matching the slash-separated
+// glob pattern, similar to filepath.Glob, but using the package's build context
+// to access the file system.
+func globCtx(pkg *PackageData, glob string) ([]globMatch, error) {
+	matches := []globMatch{{rel: "."}}
+	for _, elem := range strings.Split(glob, "/") {
+		var next []globMatch
+		for _, m := range matches {
+			if m.info != nil && !m.info.IsDir() {
+				continue
+			}
+			entries, err := buildutil.ReadDir(pkg.bctx, buildutil.JoinPath(pkg.bctx, pkg.Dir, m.rel))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if ok, _ := path.Match(elem, entry.Name()); ok {
+					next = append(next, globMatch{rel: path.Join(m.rel, entry.Name()), info: entry})
+				}
+			}
+		}
+		matches = next
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].rel < matches[j].rel })
+	return matches, nil
+}
+
+// isBadEmbedName reports whether name is the base name of a file that can't or
+// won't be included in modules and therefore shouldn't be treated as existing
+// for embedding.
+func isBadEmbedName(name string) bool {
+	switch name {
+	case ".bzr", ".hg", ".git", ".svn":
+		return true
+	}
+	return false
+}
+
+// mergeEmbedPatternPos combines positions of //go:embed patterns from
+// different sets of source files of the same package.
+func mergeEmbedPatternPos(a, b map[string][]token.Position) map[string][]token.Position {
+	result := map[string][]token.Position{}
+	for _, m := range []map[string][]token.Position{a, b} {
+		for pattern, pos := range m {
+			result[pattern] = append(result[pattern], pos...)
+		}
+	}
+	return result
+}
+
+// readEmbeds reads contents of the embedded files.
+func readEmbeds(pkg *PackageData, files []embedFile) ([]compiler.EmbedFile, error) {
+	var result []compiler.EmbedFile
+	for _, f := range files {
+		r, err := buildutil.OpenFile(pkg.bctx, f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded file %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded file %s: %w", f.Name, err)
+		}
+		result = append(result, compiler.EmbedFile{Name: f.Name, Data: data})
+	}
+	return result, nil
+}
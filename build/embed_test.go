@@ -0,0 +1,156 @@
+package build
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/compiler"
+)
+
+func TestResolveEmbeds(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.txt",
+		"b.txt",
+		"dir/c.txt",
+		"dir/.hidden",
+		"dir/_underscore",
+		"dir/sub/d.txt",
+		"dir/nested/go.mod",
+		"dir/nested/e.txt",
+		"empty/.hidden",
+		"module/go.mod",
+		"module/f.txt",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bctx := build.Default
+	resolve := func(patterns ...string) ([]compiler.EmbedPattern, []string, error) {
+		pkg := &PackageData{
+			Package: &build.Package{Dir: dir, EmbedPatterns: patterns},
+			bctx:    &bctx,
+		}
+		resolved, files, err := resolveEmbeds(pkg)
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name)
+		}
+		return resolved, names, err
+	}
+
+	tests := []struct {
+		patterns []string
+		want     []compiler.EmbedPattern
+		files    []string
+	}{{
+		patterns: []string{"a.txt"},
+		want:     []compiler.EmbedPattern{{Pattern: "a.txt", Files: []string{"a.txt"}}},
+		files:    []string{"a.txt"},
+	}, {
+		patterns: []string{"*.txt", "a.txt"},
+		want: []compiler.EmbedPattern{
+			{Pattern: "*.txt", Files: []string{"a.txt", "b.txt"}},
+			{Pattern: "a.txt", Files: []string{"a.txt"}},
+		},
+		files: []string{"a.txt", "b.txt"},
+	}, {
+		patterns: []string{"dir"},
+		want:     []compiler.EmbedPattern{{Pattern: "dir", Files: []string{"dir/c.txt", "dir/sub/d.txt"}}},
+		files:    []string{"dir/c.txt", "dir/sub/d.txt"},
+	}, {
+		patterns: []string{"all:dir"},
+		want:     []compiler.EmbedPattern{{Pattern: "all:dir", Files: []string{"dir/.hidden", "dir/_underscore", "dir/c.txt", "dir/sub/d.txt"}}},
+		files:    []string{"dir/.hidden", "dir/_underscore", "dir/c.txt", "dir/sub/d.txt"},
+	}, {
+		patterns: []string{"dir/.hidden"},
+		want:     []compiler.EmbedPattern{{Pattern: "dir/.hidden", Files: []string{"dir/.hidden"}}},
+		files:    []string{"dir/.hidden"},
+	}}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.patterns, " "), func(t *testing.T) {
+			got, files, err := resolve(test.patterns...)
+			if err != nil {
+				t.Fatalf("resolveEmbeds() returned error: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("resolveEmbeds() returned patterns %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(files, test.files) {
+				t.Errorf("resolveEmbeds() returned files %v, want %v", files, test.files)
+			}
+		})
+	}
+
+	errors := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "missing.txt", want: "no matching files found"},
+		{pattern: "empty", want: "contains no embeddable files"},
+		{pattern: "module/f.txt", want: "in different module"},
+		{pattern: "../a.txt", want: "invalid pattern syntax"},
+		{pattern: "[", want: "invalid pattern syntax"},
+	}
+
+	for _, test := range errors {
+		t.Run(test.pattern, func(t *testing.T) {
+			_, _, err := resolve(test.pattern)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("resolveEmbeds(%q) returned error %v, want %q", test.pattern, err, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveEmbedsSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		decl string
+		want string // Expected error, if any.
+	}{
+		{name: "string", decl: "//go:embed a.txt\nvar s string", want: ""},
+		{name: "string of several patterns", decl: "//go:embed a.txt\n//go:embed a.txt\nvar s string", want: ""},
+		{name: "string of several files", decl: "//go:embed a.txt b.txt\nvar s string", want: "x.go:5:5: invalid go:embed: multiple files for type string"},
+		{name: "bytes of several files", decl: "//go:embed *.txt\nvar b []byte", want: "x.go:5:5: invalid go:embed: multiple files for type []byte"},
+		{name: "FS of several files", decl: "//go:embed *.txt\nvar f embed.FS", want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := "package x\n\nimport \"embed\"\n" + test.decl + "\n\nvar _ embed.FS\n"
+			if err := os.WriteFile(filepath.Join(dir, "x.go"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			bctx := build.Default
+			bpkg, err := bctx.ImportDir(dir, 0)
+			if err != nil {
+				t.Fatalf("ImportDir() returned error: %s", err)
+			}
+			_, _, err = resolveEmbeds(&PackageData{Package: bpkg, bctx: &bctx})
+			if test.want == "" && err != nil {
+				t.Errorf("resolveEmbeds() returned error: %s", err)
+			}
+			if test.want != "" && (err == nil || !strings.HasSuffix(err.Error(), test.want)) {
+				t.Errorf("resolveEmbeds() returned error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package build
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/compiler"
+)
+
+// buildNode is a package in the import graph of the package being built, which
+// isn't up to date in the current session.
+type buildNode struct {
+	pkg           *PackageData
+	deps          []*buildNode
+	embedPatterns []compiler.EmbedPattern
+	embedFiles    []embedFile
+	srcHash       string // See Session.srcHash.
+	loading       bool   // Set while the node's imports are being loaded.
+}
+
+// buildTask tracks a package being built, such that concurrent builds that
+// depend on the same package wait for each other instead of building it twice.
+type buildTask struct {
+	done    chan struct{} // Closed when the build is finished.
+	archive *compiler.Archive
+	err     error
+}
+
+// loadImportGraph loads the packages imported by pkg, directly or indirectly,
+// which are not up to date in the session, and returns them in the order of
+// depth-first post-order traversal, which ends with pkg itself.
+//
+// Source hashes of the packages are computed along the way, see srcHash.
+func (s *Session) loadImportGraph(pkg *PackageData) ([]*buildNode, error) {
+	var order []*buildNode
+	nodes := map[string]*buildNode{}
+	var visit func(pkg *PackageData, stack []string) (*buildNode, error)
+	visit = func(pkg *PackageData, stack []string) (*buildNode, error) {
+		node := &buildNode{pkg: pkg, loading: true}
+		nodes[pkg.ImportPath] = node
+		stack = append(stack, pkg.ImportPath)
+
+		depHashes := map[string]string{}
+		for _, importedPkgPath := range pkg.Imports {
+			if importedPkgPath == "unsafe" {
+				continue
+			}
+			importedPkg, err := s.xctx.Import(importedPkgPath, pkg.Dir, 0)
+			if s.Watcher != nil && importedPkg != nil { // add watch even on error
+				s.Watcher.Add(importedPkg.Dir)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if dep, ok := nodes[importedPkg.ImportPath]; ok {
+				if dep.loading {
+					return nil, fmt.Errorf("import cycle not allowed: %s -> %s", strings.Join(stack, " -> "), importedPkg.ImportPath)
+				}
+				node.deps = append(node.deps, dep)
+				depHashes[dep.pkg.ImportPath] = dep.srcHash
+			} else if hash, ok := s.upToDateHash(importedPkg.ImportPath); ok {
+				depHashes[importedPkg.ImportPath] = hash
+			} else {
+				dep, err := visit(importedPkg, stack)
+				if err != nil {
+					return nil, err
+				}
+				node.deps = append(node.deps, dep)
+				depHashes[dep.pkg.ImportPath] = dep.srcHash
+			}
+		}
+
+		setSrcModTime(node)
+
+		var err error
+		node.embedPatterns, node.embedFiles, err = resolveEmbeds(pkg)
+		if err != nil {
+			return nil, err
+		}
+		s.watchPackage(pkg, node.embedFiles)
+
+		node.srcHash, err = s.srcHash(node, depHashes)
+		if err != nil {
+			return nil, err
+		}
+
+		node.loading = false
+		order = append(order, node)
+		return node, nil
+	}
+
+	if _, err := visit(pkg, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// setSrcModTime populates the deprecated PackageData.SrcModTime of the node's
+// package, which the build doesn't use, the way it was computed before the
+// build cache was keyed by source hashes. Imports which are up to date in the
+// session aren't accounted for.
+func setSrcModTime(node *buildNode) {
+	pkg := node.pkg
+	gopherjsBinary, err := os.Executable()
+	if err == nil {
+		var fileInfo os.FileInfo
+		fileInfo, err = os.Stat(gopherjsBinary)
+		if err == nil && fileInfo.ModTime().After(pkg.SrcModTime) {
+			pkg.SrcModTime = fileInfo.ModTime()
+		}
+	}
+	if err != nil {
+		pkg.SrcModTime = time.Now()
+	}
+	for _, dep := range node.deps {
+		if dep.pkg.SrcModTime.After(pkg.SrcModTime) {
+			pkg.SrcModTime = dep.pkg.SrcModTime
+		}
+	}
+	if fileModTime := pkg.FileModTime(); fileModTime.After(pkg.SrcModTime) {
+		pkg.SrcModTime = fileModTime
+	}
+}
+
+// buildGraph builds the packages loaded by loadImportGraph.
+//
+// Packages that don't depend on each other are built concurrently, using up to
+// Options.Parallelism workers. Packages are reported in verbose mode in the
+// graph order regardless of the order in which they are built, and if several
+// packages fail to build, the error of the first one in the graph order is
+// returned, so that the output doesn't depend on scheduling.
+func (s *Session) buildGraph(nodes []*buildNode) error {
+	index := map[*buildNode]int{}
+	tasks := make([]*buildTask, len(nodes))
+	owned := make([]bool, len(nodes))
+	s.mu.Lock()
+	for i, node := range nodes {
+		index[node] = i
+		if task, ok := s.tasks[node.pkg.ImportPath]; ok {
+			// Already being built by a concurrent build in this session.
+			tasks[i] = task
+			continue
+		}
+		if archive, ok := s.UpToDateArchives[node.pkg.ImportPath]; ok {
+			// Built by a concurrent build since the graph was loaded.
+			tasks[i] = &buildTask{done: make(chan struct{}), archive: archive}
+			close(tasks[i].done)
+			continue
+		}
+		if s.tasks == nil {
+			s.tasks = map[string]*buildTask{}
+		}
+		tasks[i] = &buildTask{done: make(chan struct{})}
+		s.tasks[node.pkg.ImportPath] = tasks[i]
+		owned[i] = true
+	}
+	s.mu.Unlock()
+
+	report := s.orderedReporter(nodes)
+	workers := make(chan struct{}, s.parallelism())
+	var wg sync.WaitGroup
+	wg.Add(len(nodes))
+	for i, node := range nodes {
+		if !owned[i] {
+			go func(i int) {
+				defer wg.Done()
+				<-tasks[i].done
+				report(i, false)
+			}(i)
+			continue
+		}
+
+		go func(i int, node *buildNode) {
+			defer wg.Done()
+			task := tasks[i]
+			compiled := false
+			defer func() {
+				s.mu.Lock()
+				delete(s.tasks, node.pkg.ImportPath)
+				s.mu.Unlock()
+				close(task.done)
+				report(i, compiled)
+			}()
+
+			for _, dep := range node.deps {
+				depTask := tasks[index[dep]]
+				<-depTask.done
+				if depTask.err != nil {
+					task.err = depTask.err
+					return
+				}
+			}
+
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			task.archive, compiled, task.err = s.buildNode(node)
+		}(i, node)
+	}
+
+	wg.Wait()
+	for _, task := range tasks {
+		if task.err != nil {
+			return task.err
+		}
+	}
+	return nil
+}
+
+// orderedReporter returns a function to call when a package of the graph is
+// built, which prints the names of the compiled packages in verbose mode in
+// the graph order.
+func (s *Session) orderedReporter(nodes []*buildNode) func(i int, compiled bool) {
+	finished := make([]bool, len(nodes))
+	compiled := make([]bool, len(nodes))
+	next := 0
+	return func(i int, c bool) {
+		s.reportMu.Lock()
+		defer s.reportMu.Unlock()
+		finished[i], compiled[i] = true, c
+		for ; next < len(nodes) && finished[next]; next++ {
+			if compiled[next] && s.options.Verbose {
+				fmt.Println(nodes[next].pkg.ImportPath)
+			}
+		}
+	}
+}
+
+// buildNode builds a single package, whose imports have already been built,
+// or loads it from the build cache if it's up to date. Returns whether the
+// package was compiled.
+func (s *Session) buildNode(node *buildNode) (*compiler.Archive, bool, error) {
+	pkg := node.pkg
+	if archive, ok := s.reuseStale(node); ok {
+		// Only imports of the package changed in watch mode, but not their API.
+		return archive, false, nil
+	}
+	if !s.options.NoCache {
+		if archive := s.buildCache.LoadArchive(pkg.ImportPath, node.srcHash); archive != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := archive.RegisterTypes(s.Types); err != nil {
+				panic(fmt.Errorf("Failed to load type information from %v: %w", archive, err))
+			}
+			if typesPkg := s.keptTypes(archive); typesPkg != nil {
+				s.Types[pkg.ImportPath] = typesPkg
+			}
+			s.UpToDateArchives[pkg.ImportPath] = archive
+			s.srcHashes[pkg.ImportPath] = node.srcHash
+			// Existing archive is up to date, no need to build it from scratch.
+			return archive, false, nil
+		}
+	}
+
+	// Existing archive is out of date or doesn't exist, let's build the package.
+	fileSet := token.NewFileSet()
+	files, overlayJsFiles, err := parseAndAugment(s.xctx, pkg, pkg.IsTest, fileSet)
+	if err != nil {
+		return nil, false, s.truncateErrors(err)
+	}
+	if s.coverPackage(pkg) {
+		files, err = instrumentCoverage(pkg, files, fileSet, s.options.CoverMode)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// The compiler gets its own copy of the type information, which it can use
+	// without synchronization with the concurrent builds.
+	packages := s.typesSnapshot()
+	resolve := s.ImportResolverFor(pkg)
+	importContext := &compiler.ImportContext{
+		Packages: packages,
+		Import: func(path string) (*compiler.Archive, error) {
+			archive, err := resolve(path)
+			if err == nil && packages[path] == nil {
+				// The package wasn't built yet when the snapshot was taken.
+				for path, typesPkg := range s.typesSnapshot() {
+					if packages[path] == nil {
+						packages[path] = typesPkg
+					}
+				}
+			}
+			return archive, err
+		},
+	}
+	archive, err := compiler.Compile(pkg.ImportPath, files, fileSet, importContext, s.compileOptions())
+	if err != nil {
+		return nil, false, s.truncateErrors(err)
+	}
+
+	for _, jsFile := range append(pkg.JSFiles, overlayJsFiles...) {
+		archive.IncJSCode = append(archive.IncJSCode, []byte("\t(function() {\n")...)
+		archive.IncJSCode = append(archive.IncJSCode, jsFile.Content...)
+		archive.IncJSCode = append(archive.IncJSCode, []byte("\n\t}).call($global);\n")...)
+	}
+
+	archive.EmbedPatterns = node.embedPatterns
+	archive.EmbedFiles, err = readEmbeds(pkg, node.embedFiles)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.buildCache.StoreArchive(archive, node.srcHash)
+	s.mu.Lock()
+	s.Types[pkg.ImportPath] = packages[pkg.ImportPath]
+	if typesPkg := s.keptTypes(archive); typesPkg != nil {
+		s.Types[pkg.ImportPath] = typesPkg
+	}
+	s.UpToDateArchives[pkg.ImportPath] = archive
+	s.srcHashes[pkg.ImportPath] = node.srcHash
+	s.mu.Unlock()
+
+	return archive, true, nil
+}
+
+// typesSnapshot returns a copy of the session's map of type information.
+func (s *Session) typesSnapshot() map[string]*types.Package {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	packages := make(map[string]*types.Package, len(s.Types))
+	for path, pkg := range s.Types {
+		packages[path] = pkg
+	}
+	return packages
+}
+
+// upToDateArchive returns the archive of the package with the given import
+// path, if it was built during the session and is up to date.
+func (s *Session) upToDateArchive(importPath string) (*compiler.Archive, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archive, ok := s.UpToDateArchives[importPath]
+	return archive, ok
+}
+
+// upToDateHash returns the source hash of the package with the given import
+// path, if it was built during the session and is up to date.
+func (s *Session) upToDateHash(importPath string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.UpToDateArchives[importPath]; !ok {
+		return "", false
+	}
+	hash, ok := s.srcHashes[importPath]
+	return hash, ok
+}
+
+// parallelism returns the maximum number of packages compiled concurrently.
+func (s *Session) parallelism() int {
+	if s.options.Parallelism > 0 {
+		return s.options.Parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// compileOptions returns the options the packages of the session are compiled
+// with. The library API is only generated in the library build mode, in which
+// any of the packages may be the one being built.
+func (s *Session) compileOptions() compiler.CompileOptions {
+	return compiler.CompileOptions{
+		Minify:       s.options.Minify,
+		Library:      s.options.BuildMode == compiler.BuildModeLibrary,
+		Declarations: s.options.CreateDeclarationFile,
+
+		TrackAllocations: s.options.TrackAllocations,
+	}
+}
+
+// truncateErrors limits the number of errors reported for a package, unless
+// all errors were requested.
+func (s *Session) truncateErrors(err error) error {
+	if list, ok := err.(compiler.ErrorList); ok && !s.options.AllErrors {
+		return list.Truncate(compiler.DefaultMaxErrors)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package build
+
+import (
+	"go/types"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/compiler"
+	"golang.org/x/tools/go/buildutil"
+)
+
+func newTestSession(t *testing.T, pkgs map[string]map[string]string, parallelism int) *Session {
+	t.Helper()
+	return &Session{
+		options:          &Options{NoCache: true, Parallelism: parallelism},
+		xctx:             simpleCtx{bctx: *buildutil.FakeContext(pkgs), noPostTweaks: true},
+		UpToDateArchives: map[string]*compiler.Archive{},
+		Types:            map[string]*types.Package{},
+		srcHashes:        map[string]string{},
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	// Packages b and c can be built concurrently, but they must share type
+	// information about d for a to type check.
+	pkgs := map[string]map[string]string{
+		"a": {"a.go": `package a; import ("b"; "c"); var Same = b.F() == c.G()`},
+		"b": {"b.go": `package b; import "d"; func F() d.T { return d.T{} }`},
+		"c": {"c.go": `package c; import "d"; func G() d.T { return d.T{} }`},
+		"d": {"d.go": `package d; type T struct{ X int }`},
+	}
+
+	for _, parallelism := range []int{1, 4} {
+		s := newTestSession(t, pkgs, parallelism)
+		pkg, err := s.xctx.Import("a", "", 0)
+		if err != nil {
+			t.Fatalf("Import() returned error: %s", err)
+		}
+
+		nodes, err := s.loadImportGraph(pkg)
+		if err != nil {
+			t.Fatalf("loadImportGraph() returned error: %s", err)
+		}
+		var order []string
+		for _, n := range nodes {
+			order = append(order, n.pkg.ImportPath)
+		}
+		if got, want := strings.Join(order, " "), "d b c a"; got != want {
+			t.Errorf("loadImportGraph() returned packages in order %q, want %q", got, want)
+		}
+
+		if err := s.buildGraph(nodes); err != nil {
+			t.Fatalf("buildGraph() with parallelism %d returned error: %s", parallelism, err)
+		}
+		for _, path := range order {
+			if s.UpToDateArchives[path] == nil || s.Types[path] == nil {
+				t.Errorf("Package %q is not up to date after buildGraph() with parallelism %d", path, parallelism)
+			}
+		}
+		if len(s.tasks) != 0 {
+			t.Errorf("Session has %d unfinished build tasks after buildGraph()", len(s.tasks))
+		}
+	}
+}
+
+func TestBuildGraphErrors(t *testing.T) {
+	t.Run("cycle", func(t *testing.T) {
+		s := newTestSession(t, map[string]map[string]string{
+			"a": {"a.go": `package a; import "b"`},
+			"b": {"b.go": `package b; import "a"`},
+		}, 2)
+		pkg, err := s.xctx.Import("a", "", 0)
+		if err != nil {
+			t.Fatalf("Import() returned error: %s", err)
+		}
+		_, err = s.BuildPackage(pkg)
+		if want := "import cycle not allowed: a -> b -> a"; err == nil || err.Error() != want {
+			t.Errorf("BuildPackage() returned error %v, want %q", err, want)
+		}
+	})
+
+	t.Run("first in graph order", func(t *testing.T) {
+		s := newTestSession(t, map[string]map[string]string{
+			"a": {"a.go": `package a; import ("b"; "c")`},
+			"b": {"b.go": `package b; var x int = "b"`},
+			"c": {"c.go": `package c; var x int = "c"`},
+		}, 2)
+		pkg, err := s.xctx.Import("a", "", 0)
+		if err != nil {
+			t.Fatalf("Import() returned error: %s", err)
+		}
+		_, err = s.BuildPackage(pkg)
+		if err == nil || !strings.Contains(err.Error(), `"b"`) {
+			t.Errorf("BuildPackage() returned error %v, want the error of package b", err)
+		}
+		if s.UpToDateArchives["a"] != nil {
+			t.Errorf("Package a is up to date despite errors in its imports")
+		}
+	})
+}
+
+func TestConcurrentBuilds(t *testing.T) {
+	// Programs built concurrently in the same session share the packages they
+	// both import.
+	s := newTestSession(t, map[string]map[string]string{
+		"a": {"a.go": `package a; import ("c"; "d"); var X = c.F() == d.G()`},
+		"b": {"b.go": `package b; import ("c"; "d"); var Y = d.G() == c.F()`},
+		"c": {"c.go": `package c; import "d"; func F() d.T { return d.T{} }`},
+		"d": {"d.go": `package d; type T struct{ X int }; func G() T { return T{} }`},
+	}, 4)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pkg, err := s.xctx.Import([]string{"a", "b"}[i%2], "", 0)
+			if err == nil {
+				_, err = s.BuildPackage(pkg)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("BuildPackage() returned error: %s", err)
+		}
+	}
+	if got, want := len(s.UpToDateArchives), 4; got != want {
+		t.Errorf("Got %d up to date packages, want %d", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"hash"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+// srcHash computes a hash of everything the package's compiled archive depends
+// on: contents of the package's .go and .inc.js files, standard library
+// overlays, embedded files and hashes of the imported packages. The build cache
+// stores it with the archive, such that the cached archive is reused if and
+// only if it would be built out of the same inputs, regardless of file
+// modification times.
+//
+// Files are identified by their names relative to the package directory, such
+// that the hash doesn't depend on where the sources are located. Build
+// parameters, such as build tags or minification, and versions of GopherJS and
+// Go are accounted for by the build cache itself.
+func (s *Session) srcHash(node *buildNode, depHashes map[string]string) (string, error) {
+	pkg := node.pkg
+	h := sha256.New()
+	fmt.Fprintf(h, "package %s test=%t\n", pkg.ImportPath, pkg.IsTest)
+
+	for _, name := range pkg.GoFiles {
+		if err := hashFile(h, "go", pkg.bctx, pkg.Dir, name); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range pkg.JSFiles {
+		fmt.Fprintf(h, "js %q %x\n", relName(pkg.Dir, f.Path), sha256.Sum256(f.Content))
+	}
+
+	nativesContext := overlayCtx(s.xctx.Env())
+	if nativesPkg, err := nativesContext.Import(strings.TrimSuffix(pkg.ImportPath, "_test"), "", 0); err == nil {
+		var names []string
+		names = append(names, nativesPkg.GoFiles...)
+		names = append(names, nativesPkg.TestGoFiles...)
+		names = append(names, nativesPkg.XTestGoFiles...)
+		for _, name := range names {
+			if err := hashFile(h, "native", &nativesContext.bctx, nativesPkg.Dir, name); err != nil {
+				return "", err
+			}
+		}
+		for _, f := range nativesPkg.JSFiles {
+			fmt.Fprintf(h, "native %q %x\n", relName(nativesPkg.Dir, f.Path), sha256.Sum256(f.Content))
+		}
+	}
+
+	for _, p := range node.embedPatterns {
+		fmt.Fprintf(h, "embed pattern %q %q\n", p.Pattern, p.Files)
+	}
+	for _, f := range node.embedFiles {
+		if err := hashFile(h, "embed", pkg.bctx, pkg.Dir, f.Path); err != nil {
+			return "", err
+		}
+	}
+
+	deps := make([]string, 0, len(depHashes))
+	for path := range depHashes {
+		deps = append(deps, path)
+	}
+	sort.Strings(deps)
+	for _, path := range deps {
+		fmt.Fprintf(h, "import %s %s\n", path, depHashes[path])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hashFile adds the file's name relative to dir and a hash of its contents to
+// h. The name may be either absolute or relative to dir.
+func hashFile(h hash.Hash, kind string, bctx *build.Context, dir, name string) error {
+	if !filepath.IsAbs(name) && !path.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	r, err := buildutil.OpenFile(bctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer r.Close()
+	fh := sha256.New()
+	if _, err := io.Copy(fh, r); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	fmt.Fprintf(h, "%s %q %x\n", kind, relName(dir, name), fh.Sum(nil))
+	return nil
+}
+
+// relName returns the file name relative to dir, or the name itself if it's
+// not inside dir.
+func relName(dir, name string) string {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,277 @@
+package build
+
+import (
+	"bytes"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gopherjs/gopherjs/compiler"
+)
+
+// watchDebounce is how long WaitForChange waits for more changes after the
+// first one, so that several files saved at once trigger a single rebuild.
+const watchDebounce = 100 * time.Millisecond
+
+// staleBuild is a package built before the last change in watch mode, which
+// may be reused if the package's sources didn't change and the type
+// information of its imports stayed the same.
+type staleBuild struct {
+	archive    *compiler.Archive
+	types      *types.Package
+	srcChanged bool
+}
+
+// watchPackage records the package's directory and embedded files, so that
+// WaitForChange can find the package affected by a changed file.
+func (s *Session) watchPackage(pkg *PackageData, embedFiles []embedFile) {
+	s.mu.Lock()
+	if s.pkgDirs == nil {
+		s.pkgDirs = map[string]string{}
+	}
+	s.pkgDirs[pkg.ImportPath] = pkg.Dir
+	for _, f := range embedFiles {
+		if s.watchedEmbeds == nil {
+			s.watchedEmbeds = map[string]map[string]bool{}
+		}
+		if s.watchedEmbeds[f.Path] == nil {
+			s.watchedEmbeds[f.Path] = map[string]bool{}
+		}
+		s.watchedEmbeds[f.Path][pkg.ImportPath] = true
+	}
+	s.mu.Unlock()
+
+	if s.Watcher != nil {
+		for _, f := range embedFiles {
+			s.Watcher.Add(filepath.Dir(f.Path))
+		}
+	}
+}
+
+// SourceDirs returns the directories containing the sources of the packages
+// loaded during the session and the files they embed, which need to be watched
+// to detect changes to the sources.
+func (s *Session) SourceDirs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := map[string]bool{}
+	for _, dir := range s.pkgDirs {
+		set[dir] = true
+	}
+	for file := range s.watchedEmbeds {
+		set[filepath.Dir(file)] = true
+	}
+	dirs := make([]string, 0, len(set))
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// isWatchedChange returns whether the file system event may affect the build.
+func (s *Session) isWatchedChange(ev fsnotify.Event) bool {
+	if ev.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 || filepath.Base(ev.Name)[0] == '.' {
+		return false
+	}
+	return s.isSource(ev.Name)
+}
+
+// isSource returns whether the file is a source file or embedded by one of the
+// packages loaded during the session.
+func (s *Session) isSource(file string) bool {
+	if strings.HasSuffix(file, ".go") || strings.HasSuffix(file, ".inc.js") {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watchedEmbeds[file]) > 0
+}
+
+// Invalidate marks the packages affected by changes to the given files as out
+// of date, such that the next build rebuilds them. Only the changed packages
+// and the packages importing them are rebuilt, and the latter only if the API
+// of the former changed. Files which are not package sources are ignored.
+//
+// Invalidate must not be called concurrently with builds.
+func (s *Session) Invalidate(files []string) {
+	var sources []string
+	for _, file := range files {
+		if s.isSource(file) {
+			sources = append(sources, file)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+	// If a file can't be attributed to a package, play it safe.
+	if changed, ok := s.changedPackages(sources); ok {
+		s.invalidate(changed)
+	} else {
+		s.flush()
+	}
+}
+
+// changedPackages returns import paths of the packages the changed files
+// belong to. Returns false if some of the files can't be attributed to a known
+// package.
+func (s *Session) changedPackages(files []string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var changed []string
+	for _, file := range files {
+		owners := s.watchedEmbeds[file]
+		for path := range owners {
+			changed = append(changed, path)
+		}
+		if !strings.HasSuffix(file, ".go") && !strings.HasSuffix(file, ".inc.js") {
+			continue
+		}
+		found := len(owners) > 0
+		for path, dir := range s.pkgDirs {
+			if dir == filepath.Dir(file) {
+				changed = append(changed, path)
+				found = true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return changed, true
+}
+
+// invalidate removes the changed packages and the packages that import them,
+// directly or indirectly, from the up to date packages. The removed packages
+// are kept as stale builds, which the next build reuses if possible, see
+// reuseStale.
+func (s *Session) invalidate(changed []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	importers := map[string][]string{}
+	for path, archive := range s.UpToDateArchives {
+		for _, imp := range archive.Imports {
+			importers[imp] = append(importers[imp], path)
+		}
+	}
+
+	s.stale = map[string]*staleBuild{}
+	s.affected = map[string]bool{}
+	s.typesKept = map[string]bool{}
+	srcChanged := map[string]bool{}
+	queue := append([]string{}, changed...)
+	for _, path := range changed {
+		srcChanged[path] = true
+	}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if s.affected[path] {
+			continue
+		}
+		s.affected[path] = true
+		queue = append(queue, importers[path]...)
+
+		if archive, ok := s.UpToDateArchives[path]; ok {
+			s.stale[path] = &staleBuild{archive: archive, types: s.Types[path], srcChanged: srcChanged[path]}
+		}
+		delete(s.UpToDateArchives, path)
+		delete(s.Types, path)
+		delete(s.srcHashes, path)
+	}
+}
+
+// flush forgets all packages built during the session, such that they are
+// validated against the build cache again.
+func (s *Session) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UpToDateArchives = map[string]*compiler.Archive{}
+	s.Types = map[string]*types.Package{}
+	s.srcHashes = map[string]string{}
+	s.stale = nil
+	s.affected = nil
+	s.typesKept = nil
+}
+
+// importsKeptTypes returns whether none of the imports have new type
+// information since the last change. Must be called with s.mu held.
+func (s *Session) importsKeptTypes(imports []string) bool {
+	for _, imp := range imports {
+		if s.affected[imp] && !s.typesKept[imp] {
+			return false
+		}
+	}
+	return true
+}
+
+// reuseStale marks the package up to date without building it if it was
+// invalidated only because it imports a changed package, and the type
+// information of all its imports stayed the same, in which case the package
+// would compile to the same code.
+func (s *Session) reuseStale(node *buildNode) (*compiler.Archive, bool) {
+	path := node.pkg.ImportPath
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stale := s.stale[path]
+	if stale == nil || stale.srcChanged || !s.importsKeptTypes(stale.archive.Imports) {
+		return nil, false
+	}
+	delete(s.stale, path)
+	s.Types[path] = stale.types
+	s.UpToDateArchives[path] = stale.archive
+	s.srcHashes[path] = node.srcHash
+	s.typesKept[path] = true
+	return stale.archive, true
+}
+
+// keptTypes returns the type information the package had before the last
+// change if its API didn't change, or nil if the type information of the new
+// archive must be used instead. The API includes whether the exported functions
+// are blocking, which affects the code generated for calls to them. Keeping the
+// type information allows packages importing this one to be reused, see
+// reuseStale. Must be called with s.mu held.
+func (s *Session) keptTypes(archive *compiler.Archive) *types.Package {
+	stale := s.stale[archive.ImportPath]
+	if stale == nil {
+		return nil
+	}
+	delete(s.stale, archive.ImportPath)
+	if !bytes.Equal(stale.archive.ExportData, archive.ExportData) ||
+		!sameBlocking(stale.archive, archive) ||
+		!s.importsKeptTypes(archive.Imports) {
+		return nil
+	}
+	s.typesKept[archive.ImportPath] = true
+	return stale.types
+}
+
+// sameBlocking returns whether the same exported functions and methods of the
+// package are blocking in both archives.
+func sameBlocking(old, new *compiler.Archive) bool {
+	blocking := func(archive *compiler.Archive) map[string]bool {
+		m := map[string]bool{}
+		for _, d := range archive.Declarations {
+			name := d.FullName[strings.LastIndexByte(d.FullName, '.')+1:]
+			if d.Blocking && token.IsExported(name) {
+				m[d.FullName] = true
+			}
+		}
+		return m
+	}
+	oldBlocking, newBlocking := blocking(old), blocking(new)
+	if len(oldBlocking) != len(newBlocking) {
+		return false
+	}
+	for name := range newBlocking {
+		if !oldBlocking[name] {
+			return false
+		}
+	}
+	return true
+}
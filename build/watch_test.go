@@ -0,0 +1,80 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gopherjs/gopherjs/compiler"
+)
+
+func TestIncrementalRebuild(t *testing.T) {
+	pkgs := map[string]map[string]string{
+		"a": {"a.go": `package a; import ("b"; "c"); var Same = b.F() == c.G()`},
+		"b": {"b.go": `package b; import "c"; func F() c.T { return c.G() }`},
+		"c": {"c.go": `package c; type T struct{ X int }; func G() T { return T{} }`},
+		"d": {"d.go": `package d; import "c"; var V = c.T{}`},
+	}
+	s := newTestSession(t, pkgs, 2)
+
+	build := func() map[string]*compiler.Archive {
+		t.Helper()
+		for _, path := range []string{"a", "d"} {
+			pkg, err := s.xctx.Import(path, "", 0)
+			if err != nil {
+				t.Fatalf("Import(%q) returned error: %s", path, err)
+			}
+			if _, err := s.BuildPackage(pkg); err != nil {
+				t.Fatalf("BuildPackage(%q) returned error: %s", path, err)
+			}
+		}
+		archives := map[string]*compiler.Archive{}
+		for path, archive := range s.UpToDateArchives {
+			archives[path] = archive
+		}
+		return archives
+	}
+	// rebuilt returns whether the packages were rebuilt, rather than reused.
+	rebuilt := func(before, after map[string]*compiler.Archive) map[string]bool {
+		result := map[string]bool{}
+		for path, archive := range after {
+			result[path] = before[path] != archive
+		}
+		return result
+	}
+
+	first := build()
+
+	// Changing the implementation of c doesn't affect the packages importing it.
+	pkgs["c"]["c.go"] = `package c; type T struct{ X int }; func G() T { return T{X: 1} }`
+	s.invalidate([]string{"c"})
+	second := build()
+	if diff := cmp.Diff(map[string]bool{"a": false, "b": false, "c": true, "d": false}, rebuilt(first, second)); diff != "" {
+		t.Errorf("After an implementation change, got unexpected rebuilt packages (-want,+got):\n%s", diff)
+	}
+
+	// Changing the API of c requires rebuilding the packages importing it,
+	// directly or indirectly.
+	pkgs["c"]["c.go"] = `package c; type T struct{ X, Y int }; func G() T { return T{X: 1} }`
+	s.invalidate([]string{"c"})
+	third := build()
+	if diff := cmp.Diff(map[string]bool{"a": true, "b": true, "c": true, "d": true}, rebuilt(second, third)); diff != "" {
+		t.Errorf("After an API change, got unexpected rebuilt packages (-want,+got):\n%s", diff)
+	}
+
+	// Changing the implementation of b doesn't affect its importers.
+	pkgs["b"]["b.go"] = `package b; import "c"; func F() c.T { t := c.G(); return t }`
+	s.invalidate([]string{"b"})
+	fourth := build()
+	if diff := cmp.Diff(map[string]bool{"a": false, "b": true, "c": false, "d": false}, rebuilt(third, fourth)); diff != "" {
+		t.Errorf("After an implementation change in b, got unexpected rebuilt packages (-want,+got):\n%s", diff)
+	}
+
+	// A function becoming blocking changes the code of its callers, even though
+	// the types stay the same, so the packages importing c are rebuilt.
+	pkgs["c"]["c.go"] = `package c; type T struct{ X, Y int }; var ch = make(chan T, 1); func G() T { ch <- T{X: 1}; return <-ch }`
+	s.invalidate([]string{"c"})
+	fifth := build()
+	if diff := cmp.Diff(map[string]bool{"a": true, "b": true, "c": true, "d": true}, rebuilt(fourth, fifth)); diff != "" {
+		t.Errorf("After c.G became blocking, got unexpected rebuilt packages (-want,+got):\n%s", diff)
+	}
+}
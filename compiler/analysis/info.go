@@ -316,7 +316,17 @@ func (fi *FuncInfo) Visit(node ast.Node) ast.Visitor {
 }
 
 func (fi *FuncInfo) visitCallExpr(n *ast.CallExpr) ast.Visitor {
-	switch f := astutil.RemoveParens(n.Fun).(type) {
+	fun := astutil.RemoveParens(n.Fun)
+	// Explicit instantiation of a generic function, e.g. Map[int, string](...).
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		if _, ok := fi.pkgInfo.TypeOf(f.X).(*types.Signature); ok {
+			fun = astutil.RemoveParens(f.X)
+		}
+	case *ast.IndexListExpr:
+		fun = astutil.RemoveParens(f.X)
+	}
+	switch f := fun.(type) {
 	case *ast.Ident:
 		fi.callToNamedFunc(fi.pkgInfo.Uses[f])
 	case *ast.SelectorExpr:
@@ -354,6 +364,7 @@ func (fi *FuncInfo) visitCallExpr(n *ast.CallExpr) ast.Visitor {
 func (fi *FuncInfo) callToNamedFunc(callee types.Object) {
 	switch o := callee.(type) {
 	case *types.Func:
+		o = typesutil.FuncOrigin(o)
 		if recv := o.Type().(*types.Signature).Recv(); recv != nil {
 			if _, ok := recv.Type().Underlying().(*types.Interface); ok {
 				// Conservatively assume that an interface implementation may be blocking.
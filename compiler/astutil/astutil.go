@@ -45,6 +45,12 @@ func IsTypeExpr(expr ast.Expr, info *types.Info) bool {
 		return ok
 	case *ast.ParenExpr:
 		return IsTypeExpr(e.X, info)
+	case *ast.IndexExpr:
+		// Instantiation of a generic type, e.g. List[int].
+		return IsTypeExpr(e.X, info)
+	case *ast.IndexListExpr:
+		// Instantiation of a generic type with several type arguments, e.g. Map[string, int].
+		return IsTypeExpr(e.X, info)
 	default:
 		return false
 	}
@@ -59,6 +65,16 @@ func ImportsUnsafe(file *ast.File) bool {
 	return false
 }
 
+// ImportsEmbed returns true if the file imports the "embed" package.
+func ImportsEmbed(file *ast.File) bool {
+	for _, imp := range file.Imports {
+		if imp.Path.Value == `"embed"` {
+			return true
+		}
+	}
+	return false
+}
+
 // FuncKey returns a string, which uniquely identifies a top-level function or
 // method in a package.
 func FuncKey(d *ast.FuncDecl) string {
@@ -69,6 +85,13 @@ func FuncKey(d *ast.FuncDecl) string {
 	if star, ok := recv.(*ast.StarExpr); ok {
 		recv = star.X
 	}
+	// Receivers of generic types' methods list type parameters, e.g. List[T].
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
 	return recv.(*ast.Ident).Name + "." + d.Name.Name
 }
 
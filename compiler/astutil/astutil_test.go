@@ -70,6 +70,14 @@ func TestFuncKey(t *testing.T) {
 			desc: "method",
 			src:  `package testpackage; func (_ myType) bar() {}`,
 			want: "myType.bar",
+		}, {
+			desc: "method of a generic type",
+			src:  `package testpackage; func (_ *myType[T]) bar() {}`,
+			want: "myType.bar",
+		}, {
+			desc: "method of a generic type with several type parameters",
+			src:  `package testpackage; func (_ myType[K, V]) bar() {}`,
+			want: "myType.bar",
 		},
 	}
 	for _, test := range tests {
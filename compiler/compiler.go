@@ -79,8 +79,25 @@ type Archive struct {
 	FileSet []byte
 	// Whether or not the package was compiled with minification enabled.
 	Minified bool
+	// Whether or not the package was compiled with allocation tracking enabled,
+	// see CompileOptions.TrackAllocations.
+	TrackedAllocations bool
 	// A list of go:linkname directives encountered in the package.
 	GoLinknames []GoLinkname
+	// Patterns of go:embed directives in the package along with the files they
+	// match. Populated by the build system, which resolves the patterns.
+	EmbedPatterns []EmbedPattern
+	// Contents of the files embedded into the package by go:embed directives.
+	EmbedFiles []EmbedFile
+	// Properties the package sets on the js.Module's exports object, which
+	// become named exports in the ECMAScript module output.
+	ModuleExports []ModuleExport
+	// TypeScript interface declarations referenced by the types of the
+	// package's exports, keyed by the interface name.
+	TypeDeclarations map[string]string
+	// JavaScript API of the package in the library build mode. Nil for main
+	// packages, and unless requested by CompileOptions.Library.
+	Library *Library
 	// Time when this archive was built.
 	BuildTime time.Time
 }
@@ -189,10 +206,21 @@ type dceInfo struct {
 	methodFilter string
 }
 
-func WriteProgramCode(pkgs []*Archive, w *SourceMapFilter, goVersion string) error {
+func WriteProgramCode(pkgs []*Archive, w *SourceMapFilter, goVersion string, format OutputFormat, mode BuildMode) error {
 	mainPkg := pkgs[len(pkgs)-1]
 	minify := mainPkg.Minified
 
+	moduleExports := ProgramExports(pkgs, mode)
+	if mode == BuildModeLibrary {
+		if mainPkg.Library == nil {
+			return fmt.Errorf("library build mode requires a non-main package compiled with its library API, but %s is not", mainPkg.ImportPath)
+		}
+		// Include the library API declaration into the program.
+		lib := *mainPkg
+		lib.Declarations = append(append([]*Decl{}, mainPkg.Declarations...), mainPkg.Library.Decl)
+		pkgs = append(append([]*Archive{}, pkgs[:len(pkgs)-1]...), &lib)
+	}
+
 	// Aggregate all go:linkname directives in the program together.
 	gls := goLinknameSet{}
 	for _, pkg := range pkgs {
@@ -255,7 +283,12 @@ func WriteProgramCode(pkgs []*Archive, w *SourceMapFilter, goVersion string) err
 		}
 	}
 
-	if _, err := w.Write([]byte("\"use strict\";\n(function() {\n\n")); err != nil {
+	header := "\"use strict\";\n(function() {\n\n"
+	if format == FormatESM {
+		// Module code is always strict and has its own scope.
+		header = esmNodeRequire
+	}
+	if _, err := w.Write([]byte(header)); err != nil {
 		return err
 	}
 	if _, err := w.Write([]byte(fmt.Sprintf("var $goVersion = %q;\n", goVersion))); err != nil {
@@ -272,6 +305,17 @@ func WriteProgramCode(pkgs []*Archive, w *SourceMapFilter, goVersion string) err
 	if _, err := w.Write([]byte("\n")); err != nil {
 		return err
 	}
+	if mainPkg.TrackedAllocations {
+		if _, err := w.Write([]byte("$trackAllocations = true;\n")); err != nil {
+			return err
+		}
+	}
+
+	if format == FormatESM {
+		if _, err := w.Write([]byte("$module = { exports: {} };\n")); err != nil {
+			return err
+		}
+	}
 
 	// write packages
 	for _, pkg := range pkgs {
@@ -280,7 +324,14 @@ func WriteProgramCode(pkgs []*Archive, w *SourceMapFilter, goVersion string) err
 		}
 	}
 
-	if _, err := w.Write([]byte("$synthesizeMethods();\n$initAllLinknames();\nvar $mainPkg = $packages[\"" + string(mainPkg.ImportPath) + "\"];\n$packages[\"runtime\"].$init();\n$go($mainPkg.$init, []);\n$flushConsole();\n\n}).call(this);\n")); err != nil {
+	if _, err := w.Write([]byte("$synthesizeMethods();\n$initAllLinknames();\nvar $mainPkg = $packages[\"" + string(mainPkg.ImportPath) + "\"];\n$packages[\"runtime\"].$init();\n")); err != nil {
+		return err
+	}
+	footer := "$go($mainPkg.$init, []);\n$flushConsole();\n\n}).call(this);\n"
+	if format == FormatESM {
+		footer = "await $goMain($mainPkg.$init);\n$flushConsole();\n" + writeModuleExports(moduleExports)
+	}
+	if _, err := w.Write([]byte(footer)); err != nil {
 		return err
 	}
 	return nil
@@ -310,6 +361,9 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls goLinknameS
 	if _, err := w.Write(removeWhitespace([]byte(fmt.Sprintf("\tvar %s;\n", strings.Join(vars, ", "))), minify)); err != nil {
 		return err
 	}
+	if _, err := w.Write(writeEmbeds(pkg, minify)); err != nil {
+		return err
+	}
 	for _, d := range filteredDecls {
 		if _, err := w.Write(d.DeclCode); err != nil {
 			return err
@@ -47,6 +47,48 @@ func Bfunc() int {
 	compare(t, "foo", files, true)
 }
 
+func TestOrderGenerics(t *testing.T) {
+	fileA := `
+package foo
+
+type List[T any] struct {
+	head *node[T]
+}
+
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+func (l *List[T]) Push(v T) {
+	l.head = &node[T]{v, l.head}
+}
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	var result []U
+	for _, x := range xs {
+		result = append(result, f(x))
+	}
+	return result
+}
+`
+
+	fileB := `
+package foo
+
+var Ints List[int]
+var Strings List[string]
+
+func Double(xs []int) []int {
+	return Map(xs, func(x int) int { return x * 2 })
+}
+`
+	files := []source{{"fileA.go", []byte(fileA)}, {"fileB.go", []byte(fileB)}}
+
+	compare(t, "foo", files, false)
+	compare(t, "foo", files, true)
+}
+
 func compare(t *testing.T, path string, sourceFiles []source, minify bool) {
 	outputNormal, err := compile(path, sourceFiles, minify)
 	if err != nil {
@@ -74,6 +116,18 @@ type source struct {
 }
 
 func compile(path string, sourceFiles []source, minify bool) ([]byte, error) {
+	a, err := compileArchive(path, sourceFiles, CompileOptions{Minify: minify})
+	if err != nil {
+		return nil, err
+	}
+	b, err := renderPackage(a)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func compileArchive(path string, sourceFiles []source, options CompileOptions) (*Archive, error) {
 	conf := loader.Config{}
 	conf.Fset = token.NewFileSet()
 	conf.ParserMode = parser.ParseComments
@@ -110,7 +164,7 @@ func compile(path string, sourceFiles []source, minify bool) ([]byte, error) {
 			importContext.Packages[path] = pi.Pkg
 
 			// compile package
-			a, err := Compile(path, pi.Files, prog.Fset, importContext, minify)
+			a, err := Compile(path, pi.Files, prog.Fset, importContext, options)
 			if err != nil {
 				return nil, err
 			}
@@ -119,15 +173,7 @@ func compile(path string, sourceFiles []source, minify bool) ([]byte, error) {
 		},
 	}
 
-	a, err := importContext.Import(path)
-	if err != nil {
-		return nil, err
-	}
-	b, err := renderPackage(a)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+	return importContext.Import(path)
 }
 
 func renderPackage(archive *Archive) ([]byte, error) {
@@ -0,0 +1,120 @@
+package compiler
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Phase is the stage of the compilation which found an error.
+type Phase string
+
+const (
+	PhaseParse     Phase = "parse"
+	PhaseTypeCheck Phase = "typecheck"
+	PhaseLinkname  Phase = "linkname"
+	PhaseCompile   Phase = "compile"
+)
+
+// Severity tells whether a diagnostic prevents the package from building.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// DefaultMaxErrors is the number of errors reported for a package unless all
+// errors are requested, see ErrorList.Truncate.
+const DefaultMaxErrors = 10
+
+// Diagnostic is a problem found in the sources of a package.
+type Diagnostic struct {
+	Package  string // Import path of the package the problem was found in.
+	Phase    Phase
+	Severity Severity
+	Pos      token.Position // Start of the offending code, if known.
+	End      token.Position // End of the offending code, if known.
+	Msg      string
+	Err      error // The underlying error, if any.
+
+	pos token.Pos // Used to find the end of the offending code.
+}
+
+func (d *Diagnostic) Error() string {
+	if !d.Pos.IsValid() {
+		return d.Msg
+	}
+	return d.Pos.String() + ": " + d.Msg
+}
+
+func (d *Diagnostic) Unwrap() error { return d.Err }
+
+// diagnose converts the errors found by the phase into diagnostics of the
+// package, locating the end of the offending code in the files.
+func diagnose(errs ErrorList, importPath string, phase Phase, fset *token.FileSet, files []*ast.File) ErrorList {
+	var result ErrorList
+	for _, err := range errs {
+		switch e := err.(type) {
+		case types.Error:
+			err = &Diagnostic{Severity: SeverityError, Pos: fset.Position(e.Pos), Msg: e.Msg, Err: e, pos: e.Pos}
+		case ErrorList:
+			result = append(result, diagnose(e, importPath, phase, fset, files)...)
+			continue
+		}
+		if d, ok := err.(*Diagnostic); ok {
+			if d.Package == "" {
+				d.Package = importPath
+			}
+			if d.Phase == "" {
+				d.Phase = phase
+			}
+			if !d.End.IsValid() && d.pos.IsValid() {
+				if end := endOf(files, d.pos); end.IsValid() {
+					d.End = fset.Position(end)
+				}
+			}
+		}
+		result = append(result, err)
+	}
+	return result
+}
+
+// endOf returns the end of the innermost syntax node or comment starting at
+// pos, or token.NoPos if there is none.
+func endOf(files []*ast.File, pos token.Pos) token.Pos {
+	end := token.NoPos
+	for _, f := range files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if c.Pos() == pos {
+					return c.End()
+				}
+			}
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil || pos < n.Pos() || pos >= n.End() {
+				return false
+			}
+			if n.Pos() == pos {
+				end = n.End() // Children are visited later, so the innermost node wins.
+			}
+			return true
+		})
+	}
+	return end
+}
+
+// Truncate returns the first max errors followed by a "too many errors" error,
+// or all the errors if there are no more than max of them or max is not
+// positive.
+func (err ErrorList) Truncate(max int) ErrorList {
+	if max <= 0 || len(err) <= max {
+		return err
+	}
+	tooMany := &Diagnostic{Severity: SeverityError, Msg: "too many errors"}
+	if last, ok := err[max-1].(*Diagnostic); ok {
+		tooMany.Package, tooMany.Phase, tooMany.Pos = last.Package, last.Phase, last.Pos
+	}
+	return append(err[:max:max], tooMany)
+}
@@ -0,0 +1,85 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCompileDiagnostics(t *testing.T) {
+	file, fset := parseSource(t, `package testcase
+
+//go:linkname a b.c
+func a()
+
+var x int = "string"
+`)
+	importContext := &ImportContext{
+		Packages: map[string]*types.Package{},
+		Import: func(path string) (*Archive, error) {
+			return nil, fmt.Errorf("unexpected import of %q", path)
+		},
+	}
+	_, err := Compile("testcase", []*ast.File{file}, fset, importContext, CompileOptions{})
+	list, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("Compile() returned error %v, want an ErrorList", err)
+	}
+
+	position := func(line, column int) token.Position {
+		return fset.Position(fset.File(file.Pos()).LineStart(line) + token.Pos(column-1))
+	}
+	want := ErrorList{&Diagnostic{
+		Package:  "testcase",
+		Phase:    PhaseLinkname,
+		Severity: SeverityError,
+		Pos:      position(3, 1),
+		End:      position(3, 20),
+		Msg:      `//go:linkname is only allowed in Go files that import "unsafe"`,
+	}, &Diagnostic{
+		Package:  "testcase",
+		Phase:    PhaseTypeCheck,
+		Severity: SeverityError,
+		Pos:      position(6, 13),
+		End:      position(6, 21),
+	}}
+	// Type checker messages vary between Go versions.
+	if len(list) == len(want) {
+		if d, ok := list[1].(*Diagnostic); ok && strings.Contains(d.Msg, `"string"`) {
+			want[1].(*Diagnostic).Msg = d.Msg
+		}
+	}
+	opts := cmp.Options{
+		cmpopts.IgnoreFields(Diagnostic{}, "Err"),
+		cmpopts.IgnoreUnexported(Diagnostic{}),
+	}
+	if diff := cmp.Diff(want, list, opts); diff != "" {
+		t.Errorf("Compile() returned diff in diagnostics (-want,+got):\n%s", diff)
+	}
+}
+
+func TestTruncateErrors(t *testing.T) {
+	var list ErrorList
+	for i := 1; i <= 12; i++ {
+		list = append(list, &Diagnostic{Package: "p", Phase: PhaseParse, Severity: SeverityError, Pos: token.Position{Filename: "p.go", Line: i, Column: 1}, Msg: "bad"})
+	}
+
+	if got := list.Truncate(12); len(got) != 12 {
+		t.Errorf("Truncate(12) returned %d errors, want all 12", len(got))
+	}
+	if got := list.Truncate(0); len(got) != 12 {
+		t.Errorf("Truncate(0) returned %d errors, want all 12", len(got))
+	}
+
+	got := list.Truncate(DefaultMaxErrors)
+	want := append(list[:DefaultMaxErrors:DefaultMaxErrors], &Diagnostic{Package: "p", Phase: PhaseParse, Severity: SeverityError, Pos: list[DefaultMaxErrors-1].(*Diagnostic).Pos, Msg: "too many errors"})
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(Diagnostic{})); diff != "" {
+		t.Errorf("Truncate(%d) returned diff (-want,+got):\n%s", DefaultMaxErrors, diff)
+	}
+}
@@ -0,0 +1,487 @@
+package compiler
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+)
+
+// ModuleExport is a value exported by the generated JavaScript program.
+type ModuleExport struct {
+	Name string
+	// TypeScript type of the exported value.
+	Type string
+}
+
+// tsTypes maps Go types to TypeScript types of their JavaScript
+// representations, as produced by $externalize and consumed by $internalize.
+//
+// Named struct types become TypeScript interfaces, the declarations of which
+// are collected in decls, keyed by the interface name. Exported types of pkg
+// keep their names, other types are prefixed with the name of their package.
+type tsTypes struct {
+	pkg   *types.Package
+	decls map[string]string
+}
+
+func newTSTypes(pkg *types.Package) *tsTypes {
+	return &tsTypes{pkg: pkg, decls: map[string]string{}}
+}
+
+// declarations returns the interface declarations referenced by the types, or
+// nil if the types aren't collected.
+func (ts *tsTypes) declarations() map[string]string {
+	if ts == nil {
+		return nil
+	}
+	return ts.decls
+}
+
+// typeOf returns the TypeScript type of the externalized values of type t.
+// With wrap set, struct values are wrapped with js.MakeFullWrapper instead of
+// being converted into plain objects.
+func (ts *tsTypes) typeOf(t types.Type, wrap bool) string {
+	if typesutil.IsJsObject(t) {
+		return "any"
+	}
+	switch t := t.(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "boolean"
+		case t.Info()&types.IsComplex != 0:
+			return "never" // Can't be externalized.
+		case t.Info()&types.IsNumeric != 0:
+			return "number"
+		case t.Info()&types.IsString != 0:
+			return "string"
+		default:
+			return "never" // unsafe.Pointer can't be externalized.
+		}
+	case *types.Array:
+		return ts.arrayOf(t.Elem(), wrap)
+	case *types.Slice:
+		return ts.arrayOf(t.Elem(), wrap)
+	case *types.Map:
+		return fmt.Sprintf("{ [key: string]: %s }", ts.typeOf(t.Elem(), wrap))
+	case *types.Pointer:
+		elem := ts.typeOf(t.Elem(), wrap)
+		if elem == "any" || strings.HasSuffix(elem, " | null") {
+			return elem
+		}
+		return tsParens(elem) + " | null"
+	case *types.Signature:
+		return ts.funcType(t, wrap)
+	case *types.Interface:
+		return "any"
+	case *types.Chan:
+		return "never" // Can't be externalized.
+	case *types.Struct:
+		return ts.structType(nil, t, wrap)
+	case *types.Named:
+		if o := t.Obj(); o.Pkg() != nil && o.Pkg().Path() == "time" && o.Name() == "Time" {
+			return "Date"
+		}
+		if st, ok := t.Underlying().(*types.Struct); ok {
+			return ts.structType(t, st, wrap)
+		}
+		return ts.typeOf(t.Underlying(), wrap)
+	default:
+		return ts.typeOf(t.Underlying(), wrap)
+	}
+}
+
+// arrayOf returns the TypeScript type of externalized arrays and slices.
+// Elements that don't need externalization are stored in typed arrays, which
+// are passed to JavaScript as is.
+func (ts *tsTypes) arrayOf(elem types.Type, wrap bool) string {
+	if b, ok := elem.Underlying().(*types.Basic); ok {
+		switch b.Kind() {
+		case types.Int, types.Int32:
+			return "Int32Array"
+		case types.Int8:
+			return "Int8Array"
+		case types.Int16:
+			return "Int16Array"
+		case types.Uint, types.Uint32, types.Uintptr:
+			return "Uint32Array"
+		case types.Uint8:
+			return "Uint8Array"
+		case types.Uint16:
+			return "Uint16Array"
+		case types.Float32:
+			return "Float32Array"
+		case types.Float64:
+			return "Float64Array"
+		}
+	}
+	return tsParens(ts.typeOf(elem, wrap)) + "[]"
+}
+
+// funcType returns the TypeScript type of an externalized function.
+func (ts *tsTypes) funcType(sig *types.Signature, wrap bool) string {
+	return fmt.Sprintf("(%s) => %s", ts.params(sig, wrap), ts.results(sig.Results(), wrap))
+}
+
+// params returns the TypeScript parameter list of an externalized function.
+func (ts *tsTypes) params(sig *types.Signature, wrap bool) string {
+	params := make([]string, sig.Params().Len())
+	for i := range params {
+		p := sig.Params().At(i)
+		name := p.Name()
+		if name == "" || name == "_" || reservedKeywords[name] || !jsIdentifier.MatchString(name) {
+			name = fmt.Sprintf("p%d", i)
+		}
+		if sig.Variadic() && i == len(params)-1 {
+			params[i] = fmt.Sprintf("...%s: %s[]", name, tsParens(ts.typeOf(p.Type().(*types.Slice).Elem(), wrap)))
+			continue
+		}
+		params[i] = fmt.Sprintf("%s: %s", name, ts.typeOf(p.Type(), wrap))
+	}
+	return strings.Join(params, ", ")
+}
+
+// results returns the TypeScript type of the value returned by an externalized
+// function: nothing, a single value or an array of several values.
+func (ts *tsTypes) results(results *types.Tuple, wrap bool) string {
+	switch results.Len() {
+	case 0:
+		return "void"
+	case 1:
+		return ts.typeOf(results.At(0).Type(), wrap)
+	}
+	list := make([]string, results.Len())
+	for i := range list {
+		list[i] = ts.typeOf(results.At(i).Type(), wrap)
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+// structType returns the TypeScript type of externalized struct values.
+//
+// Structs embedding *js.Object are externalized as the underlying JavaScript
+// object, with fields tagged `js:"..."` as its properties. Other structs are
+// either wrapped, or converted into plain objects with the exported fields.
+// Named structs are declared as interfaces to allow recursive types.
+func (ts *tsTypes) structType(named *types.Named, st *types.Struct, wrap bool) string {
+	switch {
+	case isJsObjectStruct(st):
+		return ts.declare(named, "", func() []string {
+			var members []string
+			for i := 0; i < st.NumFields(); i++ {
+				if tag := reflect.StructTag(st.Tag(i)).Get("js"); tag != "" {
+					members = append(members, tsProperty(tag)+": "+ts.typeOf(st.Field(i).Type(), false))
+				}
+			}
+			return members
+		})
+	case wrap:
+		var recv types.Type
+		if named != nil {
+			recv = types.NewPointer(named)
+		}
+		return ts.wrapperType(named, st, true, recv)
+	default:
+		return ts.declare(named, "$Value", func() []string {
+			var members []string
+			for i := 0; i < st.NumFields(); i++ {
+				if f := st.Field(i); f.Exported() {
+					members = append(members, tsProperty(f.Name())+": "+ts.typeOf(f.Type(), false))
+				}
+			}
+			return members
+		})
+	}
+}
+
+// wrapperOf returns the TypeScript type of js.MakeWrapper or, if full is set,
+// js.MakeFullWrapper applied to a value of type t.
+func (ts *tsTypes) wrapperOf(t types.Type, full bool) string {
+	recv := t
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return "any"
+	}
+	st, isStruct := named.Underlying().(*types.Struct)
+	if isStruct && isJsObjectStruct(st) {
+		return "any"
+	}
+	if isStruct {
+		// Struct values are represented by pointers.
+		recv = types.NewPointer(named)
+	} else if recv != named {
+		named = nil // Nothing to name the interface after.
+	}
+	return ts.wrapperType(named, st, full, recv)
+}
+
+// wrapperType returns the TypeScript type of a js.MakeWrapper or
+// js.MakeFullWrapper object of the named type, which has wrapped exported
+// methods of recv and, in case of the full wrapper of a struct, exported fields.
+func (ts *tsTypes) wrapperType(named *types.Named, st *types.Struct, full bool, recv types.Type) string {
+	suffix := "$Methods"
+	if full {
+		suffix = ""
+	}
+	return ts.declare(named, suffix, func() []string {
+		var members []string
+		if full {
+			members = append(members, "readonly $type: string")
+			for i := 0; st != nil && i < st.NumFields(); i++ {
+				if f := st.Field(i); f.Exported() {
+					members = append(members, tsProperty(fieldName(st, i))+": "+ts.typeOf(f.Type(), true))
+				}
+			}
+		}
+		if recv == nil {
+			return members
+		}
+		mset := types.NewMethodSet(recv)
+		for i := 0; i < mset.Len(); i++ {
+			m := mset.At(i).Obj()
+			if !m.Exported() {
+				continue
+			}
+			sig := mset.At(i).Type().(*types.Signature)
+			members = append(members, fmt.Sprintf("%s(%s): %s", tsProperty(m.Name()), ts.params(sig, full), ts.results(sig.Results(), full)))
+		}
+		return members
+	})
+}
+
+// declare returns the name of the interface declared for the named type with
+// the given suffix, adding the declaration if necessary. Anonymous types are
+// returned as object type literals.
+func (ts *tsTypes) declare(named *types.Named, suffix string, members func() []string) string {
+	if named == nil {
+		list := members()
+		if len(list) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(list, "; ") + " }"
+	}
+
+	name, exported := ts.interfaceName(named)
+	name += suffix
+	if _, ok := ts.decls[name]; ok {
+		return name
+	}
+	ts.decls[name] = "" // Placeholder for recursive types.
+
+	var buf strings.Builder
+	if exported {
+		buf.WriteString("export ")
+	}
+	fmt.Fprintf(&buf, "interface %s {\n", name)
+	for _, m := range members() {
+		fmt.Fprintf(&buf, "  %s;\n", m)
+	}
+	buf.WriteString("}\n")
+	ts.decls[name] = buf.String()
+	return name
+}
+
+// interfaceName returns the base name of interfaces declared for the named
+// type and whether they are exported.
+func (ts *tsTypes) interfaceName(named *types.Named) (string, bool) {
+	obj := named.Obj()
+	name := obj.Name()
+	if args := named.TypeArgs(); args != nil {
+		for i := 0; i < args.Len(); i++ {
+			arg := types.TypeString(args.At(i), func(p *types.Package) string { return p.Name() })
+			name += "$" + tsIdentReplacer.Replace(arg)
+		}
+	}
+	if obj.Pkg() == ts.pkg && obj.Exported() && obj.Parent() == obj.Pkg().Scope() {
+		return name, true
+	}
+	if obj.Pkg() != nil {
+		name = obj.Pkg().Name() + "$" + name
+	}
+	return name, false
+}
+
+var tsIdentifier = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+var tsIdentReplacer = strings.NewReplacer("*", "$ptr", "[", "$", "]", "", ".", "$", " ", "", ",", "$", "(", "$", ")", "", "{", "$", "}", "")
+
+// libraryFuncType returns the TypeScript type of a function exported by
+// $exportFunction: the last error result is thrown instead of being returned,
+// and functions that may block return a Promise.
+func (ts *tsTypes) libraryFuncType(sig *types.Signature, blocking bool) string {
+	results := sig.Results()
+	if n := results.Len(); n > 0 && types.Identical(results.At(n-1).Type(), types.Universe.Lookup("error").Type()) {
+		vars := make([]*types.Var, n-1)
+		for i := range vars {
+			vars[i] = results.At(i)
+		}
+		results = types.NewTuple(vars...)
+	}
+	result := ts.results(results, true)
+	if blocking {
+		result = "Promise<" + result + ">"
+	}
+	return fmt.Sprintf("(%s) => %s", ts.params(sig, true), result)
+}
+
+// libraryStructType returns the TypeScript type of a constructor exported by
+// $exportStructType.
+func (ts *tsTypes) libraryStructType(named *types.Named) string {
+	st := named.Underlying().(*types.Struct)
+	var fields []string
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Exported() {
+			fields = append(fields, tsProperty(f.Name())+"?: "+ts.typeOf(f.Type(), true))
+		}
+	}
+	init := "{}"
+	if len(fields) > 0 {
+		init = "{ " + strings.Join(fields, "; ") + " }"
+	}
+	return fmt.Sprintf("{ new (init?: %s): %s }", init, ts.typeOf(named, true))
+}
+
+// isJsObjectStruct returns true if the struct values are externalized as the
+// *js.Object they embed, which is the case when the first field is (or leads
+// to) a *js.Object.
+func isJsObjectStruct(st *types.Struct) bool {
+	for st.NumFields() > 0 {
+		t := st.Field(0).Type()
+		if typesutil.IsJsObject(t) {
+			return true
+		}
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		next, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return false
+		}
+		st = next
+	}
+	return false
+}
+
+// tsProperty returns a TypeScript property name, quoted if necessary.
+func tsProperty(name string) string {
+	if jsIdentifier.MatchString(name) {
+		return name
+	}
+	return encodeString(name)
+}
+
+// tsParens wraps function and union types in parentheses, so that they can be
+// used as operands of other type operators.
+func tsParens(t string) string {
+	if strings.Contains(t, "=>") || strings.Contains(t, " | ") {
+		return "(" + t + ")"
+	}
+	return t
+}
+
+// ProgramExports returns values exported by the program built out of pkgs in
+// the given build mode, sorted by name. The "default" export is excluded, since
+// it's the exports object itself.
+func ProgramExports(pkgs []*Archive, mode BuildMode) []ModuleExport {
+	var lists [][]ModuleExport
+	if mainPkg := pkgs[len(pkgs)-1]; mode == BuildModeLibrary && mainPkg.Library != nil {
+		lists = append(lists, mainPkg.Library.Exports)
+	}
+	for _, pkg := range pkgs {
+		lists = append(lists, pkg.ModuleExports)
+	}
+
+	seen := map[string]bool{"default": true}
+	var exports []ModuleExport
+	for _, list := range lists {
+		for _, e := range list {
+			if !seen[e.Name] {
+				seen[e.Name] = true
+				exports = append(exports, e)
+			}
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Name < exports[j].Name })
+	return exports
+}
+
+// WriteTypeScriptDeclarations writes a TypeScript declaration file (.d.ts) for
+// the exports of the program built out of pkgs, see WriteProgramCode.
+func WriteTypeScriptDeclarations(pkgs []*Archive, w io.Writer, format OutputFormat, mode BuildMode) error {
+	mainPkg := pkgs[len(pkgs)-1]
+	if mode == BuildModeLibrary && mainPkg.Library == nil {
+		return fmt.Errorf("library build mode requires a non-main package compiled with its library API, but %s is not", mainPkg.ImportPath)
+	}
+	exports := ProgramExports(pkgs, mode)
+
+	available := map[string]string{}
+	for _, pkg := range pkgs {
+		for name, decl := range pkg.TypeDeclarations {
+			if _, ok := available[name]; !ok || pkg == mainPkg {
+				available[name] = decl
+			}
+		}
+	}
+	// Only include declarations referenced by the exports, directly or not.
+	decls := map[string]string{}
+	var reference func(t string)
+	reference = func(t string) {
+		for _, name := range tsIdentifier.FindAllString(t, -1) {
+			if decl, ok := available[name]; ok && decls[name] == "" {
+				decls[name] = decl
+				reference(decl)
+			}
+		}
+	}
+	for _, e := range exports {
+		reference(e.Type)
+	}
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "// TypeScript declarations for %s, generated by GopherJS.\n\n", mainPkg.ImportPath)
+	for _, name := range names {
+		buf.WriteString(decls[name])
+		buf.WriteString("\n")
+	}
+
+	var specs []string
+	for i, e := range exports {
+		if jsIdentifier.MatchString(e.Name) && !reservedKeywords[e.Name] {
+			fmt.Fprintf(&buf, "export declare const %s: %s;\n", e.Name, e.Type)
+			continue
+		}
+		local := fmt.Sprintf("$moduleExport%d", i)
+		fmt.Fprintf(&buf, "declare const %s: %s;\n", local, e.Type)
+		specs = append(specs, local+" as "+encodeString(e.Name))
+	}
+	if len(specs) > 0 {
+		fmt.Fprintf(&buf, "export { %s };\n", strings.Join(specs, ", "))
+	}
+
+	if format == FormatESM {
+		members := make([]string, len(exports))
+		for i, e := range exports {
+			members[i] = fmt.Sprintf("  %s: %s;\n", tsProperty(e.Name), e.Type)
+		}
+		fmt.Fprintf(&buf, "declare const $moduleExports: {\n%s};\nexport default $moduleExports;\n", strings.Join(members, ""))
+	} else if len(exports) == 0 {
+		buf.WriteString("export {};\n")
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
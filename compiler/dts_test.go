@@ -0,0 +1,128 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteTypeScriptDeclarations(t *testing.T) {
+	src := []byte(`package lib
+
+import "github.com/gopherjs/gopherjs/js"
+
+type Point struct {
+	X, Y   int64
+	Labels []string
+	Next   *Point
+	hidden bool
+}
+
+func (p *Point) Scale(k float64) *Point { return p }
+
+func (p Point) Coords() (int64, int64) { return p.X, p.Y }
+
+type Element struct {
+	*js.Object
+	ID       string ` + "`" + `js:"id"` + "`" + `
+	Children []Element ` + "`" + `js:"child-nodes"` + "`" + `
+}
+
+type options struct {
+	Bytes []byte
+	Index map[string]bool
+}
+
+func Parse(s string) (*Point, error) { return nil, nil }
+
+func Sum(xs ...float64) float64 { return 0 }
+
+func Find(e Element, f func(Element) bool) []Element { return nil }
+
+func Configure(o options) {}
+
+func init() {
+	js.Module.Get("exports").Set("defaults", options{})
+	js.Module.Get("exports").Set("wrapped", js.MakeWrapper(&Point{}))
+}
+`)
+	archive, err := compileArchive("example.com/lib", []source{{"lib.go", src}}, CompileOptions{Library: true, Declarations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := WriteTypeScriptDeclarations([]*Archive{archive}, &buf, FormatESM, BuildModeLibrary); err != nil {
+		t.Fatalf("WriteTypeScriptDeclarations() returned error: %s", err)
+	}
+	want := `// TypeScript declarations for example.com/lib, generated by GopherJS.
+
+export interface Element {
+  id: string;
+  "child-nodes": Element[];
+}
+
+export interface Point {
+  readonly $type: string;
+  X: number;
+  Y: number;
+  Labels: string[];
+  Next: Point | null;
+  Coords(): [number, number];
+  Scale(k: number): Point | null;
+}
+
+export interface Point$Methods {
+  Coords(): [number, number];
+  Scale(k: number): Point$Value | null;
+}
+
+export interface Point$Value {
+  X: number;
+  Y: number;
+  Labels: string[];
+  Next: Point$Value | null;
+}
+
+interface lib$options {
+  readonly $type: string;
+  Bytes: Uint8Array;
+  Index: { [key: string]: boolean };
+}
+
+interface lib$options$Value {
+  Bytes: Uint8Array;
+  Index: { [key: string]: boolean };
+}
+
+export declare const Configure: (o: lib$options) => void;
+export declare const Find: (e: Element, f: (p0: Element) => boolean) => Element[];
+export declare const Parse: (s: string) => Point | null;
+export declare const Point: { new (init?: { X?: number; Y?: number; Labels?: string[]; Next?: Point | null }): Point };
+export declare const Sum: (...xs: number[]) => number;
+export declare const defaults: lib$options$Value;
+export declare const wrapped: Point$Methods;
+declare const $moduleExports: {
+  Configure: (o: lib$options) => void;
+  Find: (e: Element, f: (p0: Element) => boolean) => Element[];
+  Parse: (s: string) => Point | null;
+  Point: { new (init?: { X?: number; Y?: number; Labels?: string[]; Next?: Point | null }): Point };
+  Sum: (...xs: number[]) => number;
+  defaults: lib$options$Value;
+  wrapped: Point$Methods;
+};
+export default $moduleExports;
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WriteTypeScriptDeclarations() returned diff (-want,+got):\n%s", diff)
+	}
+
+	buf.Reset()
+	if err := WriteTypeScriptDeclarations([]*Archive{archive}, &buf, FormatIIFE, BuildModeExe); err != nil {
+		t.Fatalf("WriteTypeScriptDeclarations() returned error: %s", err)
+	}
+	if got := buf.String(); strings.Contains(got, "Parse") || !strings.Contains(got, "export declare const defaults: lib$options$Value;\n") || strings.Contains(got, "export default") {
+		t.Errorf("WriteTypeScriptDeclarations() in the exe mode returned:\n%s\nwant only js.Module exports without the default export", got)
+	}
+}
@@ -0,0 +1,258 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gopherjs/gopherjs/compiler/astutil"
+)
+
+// EmbedFile is a file embedded into a package with a //go:embed directive.
+type EmbedFile struct {
+	Name string // Slash-separated path relative to the package directory.
+	Data []byte
+}
+
+// EmbedPattern is a //go:embed pattern along with the names of the files it
+// matches.
+type EmbedPattern struct {
+	Pattern string
+	Files   []string // Slash-separated paths relative to the package directory.
+}
+
+// goEmbed describes a package-level variable initialized by a //go:embed
+// compiler directive.
+type goEmbed struct {
+	Patterns []string
+	Kind     embedKind
+}
+
+type embedKind int
+
+const (
+	embedString embedKind = iota
+	embedBytes
+	embedFS
+)
+
+// parseGoEmbeds finds //go:embed compiler directives in the package source and
+// returns the patterns for each of the variables they apply to.
+//
+// The directives must satisfy the same constraints as the upstream Go compiler
+// enforces: the file must import "embed", and the directive must precede
+// a single package-level variable of type string, []byte or embed.FS without
+// an initializer.
+//
+// Patterns are resolved into files by the build system, which stores them in
+// the package's Archive.
+func parseGoEmbeds(fset *token.FileSet, files []*ast.File, info *types.Info) (map[*types.Var]*goEmbed, error) {
+	var errs ErrorList
+	embeds := map[*types.Var]*goEmbed{}
+
+	for _, file := range files {
+		importsEmbed := astutil.ImportsEmbed(file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				directives := embedDirectives(doc)
+				if len(directives) == 0 {
+					continue
+				}
+				pos := directives[0].Pos()
+
+				if !importsEmbed {
+					errs = append(errs, ErrorAt(fmt.Errorf(`go:embed only allowed in Go files that import "embed"`), fset, pos))
+					continue
+				}
+				if len(spec.Names) > 1 {
+					errs = append(errs, ErrorAt(fmt.Errorf("go:embed cannot apply to multiple vars"), fset, pos))
+					continue
+				}
+				if len(spec.Values) > 0 {
+					errs = append(errs, ErrorAt(fmt.Errorf("go:embed cannot apply to var with initializer"), fset, pos))
+					continue
+				}
+				if spec.Type == nil {
+					errs = append(errs, ErrorAt(fmt.Errorf("go:embed cannot apply to var without type"), fset, pos))
+					continue
+				}
+
+				o, ok := info.Defs[spec.Names[0]].(*types.Var)
+				if !ok {
+					continue // Blank identifier, nothing to initialize.
+				}
+				kind, ok := embedKindOf(o.Type())
+				if !ok {
+					errs = append(errs, ErrorAt(fmt.Errorf("go:embed cannot apply to var of type %v", o.Type()), fset, pos))
+					continue
+				}
+
+				embed := &goEmbed{Kind: kind}
+				for _, c := range directives {
+					patterns, err := parseEmbedPatterns(strings.TrimPrefix(c.Text, "//go:embed"))
+					if err != nil {
+						errs = append(errs, ErrorAt(err, fset, c.Pos()))
+						continue
+					}
+					embed.Patterns = append(embed.Patterns, patterns...)
+				}
+				embeds[o] = embed
+			}
+		}
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return embeds, nil
+}
+
+// embedDirectives returns //go:embed comments from the comment group.
+func embedDirectives(doc *ast.CommentGroup) []*ast.Comment {
+	if doc == nil {
+		return nil
+	}
+	var directives []*ast.Comment
+	for _, c := range doc.List {
+		if c.Text == "//go:embed" || strings.HasPrefix(c.Text, "//go:embed ") || strings.HasPrefix(c.Text, "//go:embed\t") {
+			directives = append(directives, c)
+		}
+	}
+	return directives
+}
+
+// embedKindOf returns which kind of embedding a variable of the given type
+// supports.
+func embedKindOf(t types.Type) (embedKind, bool) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "embed" && named.Obj().Name() == "FS" {
+		return embedFS, true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() == types.String {
+			return embedString, true
+		}
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return embedBytes, true
+		}
+	}
+	return 0, false
+}
+
+// parseEmbedPatterns parses arguments of a //go:embed directive. Patterns are
+// separated by spaces and may be quoted using Go string syntax.
+//
+// This mirrors the parsing done by go/build, which collects the patterns for
+// the build system to resolve.
+func parseEmbedPatterns(args string) ([]string, error) {
+	var patterns []string
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		var pattern string
+		switch args[0] {
+		default:
+			i := len(args)
+			for j, c := range args {
+				if unicode.IsSpace(c) {
+					i = j
+					break
+				}
+			}
+			pattern = args[:i]
+			args = args[i:]
+
+		case '`':
+			i := strings.Index(args[1:], "`")
+			if i < 0 {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+			pattern = args[1 : 1+i]
+			args = args[1+i+1:]
+
+		case '"':
+			i := 1
+			for ; i < len(args) && args[i] != '"'; i++ {
+				if args[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(args) {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+			q, err := strconv.Unquote(args[:i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args[:i+1])
+			}
+			pattern = q
+			args = args[i+1:]
+		}
+		if args != "" {
+			if r, _ := utf8.DecodeRuneInString(args); !unicode.IsSpace(r) {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("usage: //go:embed pattern...")
+	}
+	return patterns, nil
+}
+
+// translateEmbed returns a JavaScript expression which evaluates to the value
+// of a variable initialized by a //go:embed directive.
+func (fc *funcContext) translateEmbed(o *types.Var, embed *goEmbed) string {
+	patterns := make([]string, len(embed.Patterns))
+	for i, p := range embed.Patterns {
+		patterns[i] = encodeString(p)
+	}
+	list := "[" + strings.Join(patterns, ", ") + "]"
+
+	switch embed.Kind {
+	case embedString:
+		return fmt.Sprintf("$embedString($pkg, %s)", list)
+	case embedBytes:
+		return fmt.Sprintf("new %s($stringToBytes($embedString($pkg, %s)))", fc.typeName(o.Type()), list)
+	case embedFS:
+		return fmt.Sprintf("$embedFS(%s, $pkg, %s)", fc.typeName(o.Type()), list)
+	default:
+		panic(fmt.Errorf("unexpected embed kind %v for %v", embed.Kind, o))
+	}
+}
+
+// writeEmbeds returns code which makes files embedded into the package
+// available to the variable initializers.
+func writeEmbeds(pkg *Archive, minify bool) []byte {
+	if len(pkg.EmbedPatterns) == 0 {
+		return nil
+	}
+	patterns := make([]string, len(pkg.EmbedPatterns))
+	for i, p := range pkg.EmbedPatterns {
+		names := make([]string, len(p.Files))
+		for j, name := range p.Files {
+			names[j] = encodeString(name)
+		}
+		patterns[i] = fmt.Sprintf("%s: [%s]", encodeString(p.Pattern), strings.Join(names, ", "))
+	}
+	files := make([]string, len(pkg.EmbedFiles))
+	for i, f := range pkg.EmbedFiles {
+		files[i] = fmt.Sprintf("%s: %s", encodeString(f.Name), encodeString(string(f.Data)))
+	}
+	const sep = ",\n\t\t\t"
+	code := fmt.Sprintf("\t$pkg.$embeds = {\n\t\tpatterns: {\n\t\t\t%s\n\t\t},\n\t\tfiles: {\n\t\t\t%s\n\t\t}\n\t};\n", strings.Join(patterns, sep), strings.Join(files, sep))
+	return removeWhitespace([]byte(code), minify)
+}
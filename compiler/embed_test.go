@@ -0,0 +1,131 @@
+package compiler
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEmbedPatterns(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    []string
+		wantErr string
+	}{
+		{args: " a.txt", want: []string{"a.txt"}},
+		{args: " a.txt  b/*.txt\tc", want: []string{"a.txt", "b/*.txt", "c"}},
+		{args: ` "with space.txt" a.txt`, want: []string{"with space.txt", "a.txt"}},
+		{args: " \"escaped\\\".txt\"", want: []string{`escaped".txt`}},
+		{args: " `raw string.txt`", want: []string{"raw string.txt"}},
+		{args: "", wantErr: "usage: //go:embed pattern..."},
+		{args: ` "unterminated.txt`, wantErr: "invalid quoted string"},
+		{args: " `unterminated.txt", wantErr: "invalid quoted string"},
+		{args: ` "a.txt"b.txt`, wantErr: "invalid quoted string"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.args, func(t *testing.T) {
+			got, err := parseEmbedPatterns(test.args)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("parseEmbedPatterns(%q) returned error %v, want %q", test.args, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEmbedPatterns(%q) returned error: %s", test.args, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseEmbedPatterns(%q) returned diff (-want,+got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestParseGoEmbeds(t *testing.T) {
+	file, fset := parseSource(t, `
+package testcase
+
+import "embed"
+
+//go:embed a.txt
+var AString string
+
+//go:embed a.txt
+//go:embed "b.txt"
+var ABytes []byte
+
+var (
+	// Unrelated comment.
+	//go:embed dir
+	AFS embed.FS
+
+	NotEmbedded string
+)
+`)
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatalf("Failed to type check source code: %s", err)
+	}
+
+	embeds, err := parseGoEmbeds(fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatalf("parseGoEmbeds() returned error: %s", err)
+	}
+
+	got := map[string]goEmbed{}
+	for o, embed := range embeds {
+		got[o.Name()] = *embed
+	}
+	want := map[string]goEmbed{
+		"AString": {Patterns: []string{"a.txt"}, Kind: embedString},
+		"ABytes":  {Patterns: []string{"a.txt", "b.txt"}, Kind: embedBytes},
+		"AFS":     {Patterns: []string{"dir"}, Kind: embedFS},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseGoEmbeds() returned diff (-want,+got):\n%s", diff)
+	}
+	if _, ok := embeds[pkg.Scope().Lookup("NotEmbedded").(*types.Var)]; ok {
+		t.Errorf("parseGoEmbeds() returned a directive for NotEmbedded")
+	}
+}
+
+func TestParseGoEmbedsErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{{
+		src:  "package testcase\n\n//go:embed a.txt\nvar x string\n",
+		want: `go:embed only allowed in Go files that import "embed"`,
+	}, {
+		src:  "package testcase\n\nimport _ \"embed\"\n\n//go:embed a.txt\nvar x, y string\n",
+		want: "go:embed cannot apply to multiple vars",
+	}, {
+		src:  "package testcase\n\nimport _ \"embed\"\n\n//go:embed a.txt\nvar x string = \"\"\n",
+		want: "go:embed cannot apply to var with initializer",
+	}, {
+		src:  "package testcase\n\nimport _ \"embed\"\n\n//go:embed a.txt\nvar x int\n",
+		want: "go:embed cannot apply to var of type int",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			file, fset := parseSource(t, test.src)
+			info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+			conf := types.Config{Importer: importer.Default()}
+			if _, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, info); err != nil {
+				t.Fatalf("Failed to type check source code: %s", err)
+			}
+			_, err := parseGoEmbeds(fset, []*ast.File{file}, info)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("parseGoEmbeds() returned error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/compiler/astutil"
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+)
+
+// OutputFormat determines how the generated program is wrapped.
+type OutputFormat string
+
+const (
+	// FormatIIFE wraps the program into an immediately invoked function
+	// expression. The output can be loaded as a classic script or as a CommonJS
+	// module, in which case js.Module refers to the CommonJS module object.
+	FormatIIFE OutputFormat = "iife"
+	// FormatESM produces an ECMAScript module. Module evaluation awaits program
+	// initialization, after which properties of the js.Module's exports object
+	// become the module's exports.
+	FormatESM OutputFormat = "esm"
+)
+
+// ParseOutputFormat validates output format name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch f := OutputFormat(name); f {
+	case "", FormatIIFE:
+		return FormatIIFE, nil
+	case FormatESM:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q, must be one of: %s, %s", name, FormatIIFE, FormatESM)
+	}
+}
+
+// collectModuleExports finds the properties that the package sets on the
+// js.Module's exports object, such that they can be exported by name from an
+// ECMAScript module and declared in TypeScript declarations.
+//
+// Only property names known at compile time are considered, whether they are
+// set directly, e.g. `js.Module.Get("exports").Set("name", ...)`, or via
+// a variable holding the exports object. Types of the exports are derived from
+// the static types of the values being set, if ts isn't nil.
+func collectModuleExports(files []*ast.File, info *types.Info, ts *tsTypes) []ModuleExport {
+	isModule := func(e ast.Expr) bool {
+		var obj types.Object
+		switch e := astutil.RemoveParens(e).(type) {
+		case *ast.SelectorExpr:
+			obj = info.Uses[e.Sel]
+		case *ast.Ident:
+			obj = info.Uses[e]
+		}
+		return obj != nil && typesutil.IsJsPackage(obj.Pkg()) && obj.Name() == "Module"
+	}
+	// Variables which hold the exports object.
+	exportsVars := map[types.Object]bool{}
+	isExports := func(e ast.Expr) bool {
+		switch e := astutil.RemoveParens(e).(type) {
+		case *ast.Ident:
+			return exportsVars[info.Uses[e]]
+		case *ast.CallExpr:
+			name, ok := methodCallName(e, info, "Get")
+			return ok && name == "exports" && isModule(e.Fun.(*ast.SelectorExpr).X)
+		}
+		return false
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok && isExports(n.Rhs[i]) {
+						if obj := info.ObjectOf(id); obj != nil {
+							exportsVars[obj] = true
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) != len(n.Values) {
+					return true
+				}
+				for i, id := range n.Names {
+					if isExports(n.Values[i]) {
+						exportsVars[info.Defs[id]] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	var result []ModuleExport
+	seen := map[string]bool{}
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if name, ok := methodCallName(call, info, "Set"); ok && isExports(call.Fun.(*ast.SelectorExpr).X) && !seen[name] {
+				seen[name] = true
+				export := ModuleExport{Name: name}
+				if ts != nil {
+					export.Type = moduleExportType(call.Args[1], info, ts)
+				}
+				result = append(result, export)
+			}
+			return true
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// moduleExportType returns the TypeScript type of the value e set on the
+// exports object, which is externalized according to its static type, unless
+// it is a result of js.MakeWrapper or js.MakeFullWrapper.
+func moduleExportType(e ast.Expr, info *types.Info, ts *tsTypes) string {
+	if call, ok := astutil.RemoveParens(e).(*ast.CallExpr); ok && len(call.Args) == 1 {
+		var obj types.Object
+		switch fun := astutil.RemoveParens(call.Fun).(type) {
+		case *ast.SelectorExpr:
+			obj = info.Uses[fun.Sel]
+		case *ast.Ident:
+			obj = info.Uses[fun]
+		}
+		if _, isFunc := obj.(*types.Func); isFunc && typesutil.IsJsPackage(obj.Pkg()) {
+			switch obj.Name() {
+			case "MakeWrapper":
+				return ts.wrapperOf(info.TypeOf(call.Args[0]), false)
+			case "MakeFullWrapper":
+				return ts.wrapperOf(info.TypeOf(call.Args[0]), true)
+			}
+		}
+	}
+	t := info.TypeOf(e)
+	if t == nil {
+		return "any"
+	}
+	return ts.typeOf(t, false)
+}
+
+// methodCallName checks whether call is a call of the *js.Object method with
+// a constant string as the first argument, and returns the argument.
+func methodCallName(call *ast.CallExpr, info *types.Info, method string) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method || len(call.Args) == 0 {
+		return "", false
+	}
+	if s, ok := info.Selections[sel]; !ok || s.Kind() != types.MethodVal || !typesutil.IsJsObject(s.Recv()) {
+		return "", false
+	}
+	tv, ok := info.Types[call.Args[0]]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// writeModuleExports returns ECMAScript module code which exports properties
+// of the js.Module's exports object, see ProgramExports. The properties are
+// replaced with accessors of the exported variables, so that the named exports
+// are live bindings, which reflect the values set after the initialization.
+func writeModuleExports(exports []ModuleExport) string {
+	names := make([]string, len(exports))
+	for i, e := range exports {
+		names[i] = e.Name
+	}
+
+	var buf strings.Builder
+	buf.WriteString("var $moduleExports = $module.exports;\nexport default $moduleExports;\n")
+	if len(names) == 0 {
+		return buf.String()
+	}
+	specs := make([]string, len(names))
+	for i, name := range names {
+		local := fmt.Sprintf("$moduleExport%d", i)
+		fmt.Fprintf(&buf, "var %s = $moduleExports[%s];\n", local, encodeString(name))
+		fmt.Fprintf(&buf, "Object.defineProperty($moduleExports, %s, { get: function() { return %s; }, set: function(v) { %s = v; }, enumerable: true, configurable: true });\n", encodeString(name), local, local)
+		exported := name
+		if !jsIdentifier.MatchString(name) {
+			exported = encodeString(name)
+		}
+		specs[i] = local + " as " + exported
+	}
+	fmt.Fprintf(&buf, "export { %s };\n", strings.Join(specs, ", "))
+	return buf.String()
+}
+
+// esmNodeRequire makes CommonJS require() available to the program when an
+// ECMAScript module runs under Node.js. The module name is not a literal so
+// that bundlers targeting browsers leave the import alone.
+const esmNodeRequire = "var require = (typeof process !== \"undefined\" && process.versions !== undefined && process.versions.node !== undefined) ? (await import(/* @vite-ignore */ [\"module\"][0])).createRequire(import.meta.url) : undefined;\n"
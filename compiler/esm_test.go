@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/loader"
+)
+
+func TestCollectModuleExports(t *testing.T) {
+	const src = `package main
+
+import "github.com/gopherjs/gopherjs/js"
+
+const name = "Constant"
+
+func main() {
+	js.Module.Get("exports").Set("Direct", 1)
+	exports := js.Module.Get("exports")
+	exports.Set("ViaVariable", 2)
+	exports.Set(name, "three")
+	var other = (js.Module).Get("exports")
+	other.Set("odd-name", func(x int64) []string { return nil })
+	other.Set("Direct", "duplicate")
+
+	dynamic := "Dynamic"
+	exports.Set(dynamic, 5)
+	js.Global.Set("Global", 6)
+	js.Module.Get("notExports").Set("NotExported", 7)
+}
+`
+	conf := loader.Config{ParserMode: parser.ParseComments, Fset: token.NewFileSet()}
+	f, err := parser.ParseFile(conf.Fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse source code: %s", err)
+	}
+	conf.CreateFromFiles("main", f)
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Failed to type check source code: %s", err)
+	}
+	pkg := prog.Package("main")
+
+	got := collectModuleExports(pkg.Files, &pkg.Info, newTSTypes(pkg.Pkg))
+	want := []ModuleExport{
+		{Name: "Constant", Type: "string"},
+		{Name: "Direct", Type: "number"},
+		{Name: "ViaVariable", Type: "number"},
+		{Name: "odd-name", Type: "(x: number) => string[]"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("collectModuleExports() returned diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWriteModuleExports(t *testing.T) {
+	got := writeModuleExports([]ModuleExport{{Name: "a"}, {Name: "b"}, {Name: "odd-name"}})
+	want := `var $moduleExports = $module.exports;
+export default $moduleExports;
+var $moduleExport0 = $moduleExports["a"];
+Object.defineProperty($moduleExports, "a", { get: function() { return $moduleExport0; }, set: function(v) { $moduleExport0 = v; }, enumerable: true, configurable: true });
+var $moduleExport1 = $moduleExports["b"];
+Object.defineProperty($moduleExports, "b", { get: function() { return $moduleExport1; }, set: function(v) { $moduleExport1 = v; }, enumerable: true, configurable: true });
+var $moduleExport2 = $moduleExports["odd-name"];
+Object.defineProperty($moduleExports, "odd-name", { get: function() { return $moduleExport2; }, set: function(v) { $moduleExport2 = v; }, enumerable: true, configurable: true });
+export { $moduleExport0 as a, $moduleExport1 as b, $moduleExport2 as "odd-name" };
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("writeModuleExports() returned diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for name, want := range map[string]OutputFormat{"": FormatIIFE, "iife": FormatIIFE, "esm": FormatESM} {
+		if got, err := ParseOutputFormat(name); err != nil || got != want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v; want %q, nil", name, got, err, want)
+		}
+	}
+	if _, err := ParseOutputFormat("cjs"); err == nil {
+		t.Errorf("ParseOutputFormat(%q) returned no error", "cjs")
+	}
+}
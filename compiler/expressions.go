@@ -35,6 +35,9 @@ func (e *expression) StringWithParens() string {
 func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 	exprType := fc.pkgCtx.TypeOf(expr)
 	if value := fc.pkgCtx.Types[expr].Value; value != nil {
+		if typesutil.IsTypeParam(exprType) {
+			return fc.translateGenericConst(value, exprType)
+		}
 		basic := exprType.Underlying().(*types.Basic)
 		switch {
 		case isBoolean(basic):
@@ -100,7 +103,10 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 
 	switch e := expr.(type) {
 	case *ast.CompositeLit:
-		if ptrType, isPointer := exprType.(*types.Pointer); isPointer {
+		// The address of the literal is taken implicitly when it's an element
+		// of another composite literal, e.g. in []*T{{...}}.
+		ptrType, isPointer := exprType.(*types.Pointer)
+		if isPointer {
 			exprType = ptrType.Elem()
 		}
 
@@ -126,19 +132,25 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			return elements
 		}
 
-		switch t := exprType.Underlying().(type) {
+		switch t := typesutil.CoreType(exprType).(type) {
 		case *types.Array:
-			elements := collectIndexedElements(t.Elem())
-			if len(elements) == 0 {
-				return fc.formatExpr("%s.zero()", fc.typeName(t))
+			var array *expression
+			if elements := collectIndexedElements(t.Elem()); len(elements) == 0 {
+				array = fc.formatExpr("%s.zero()", fc.typeName(t))
+			} else {
+				zero := fc.translateExpr(fc.zeroValue(t.Elem())).String()
+				for len(elements) < int(t.Len()) {
+					elements = append(elements, zero)
+				}
+				array = fc.formatExpr(`$toNativeArray(%s, [%s])`, fc.kindOf(t.Elem()), strings.Join(elements, ", "))
 			}
-			zero := fc.translateExpr(fc.zeroValue(t.Elem())).String()
-			for len(elements) < int(t.Len()) {
-				elements = append(elements, zero)
+			if isPointer {
+				return fc.countAllocation(array, t)
 			}
-			return fc.formatExpr(`$toNativeArray(%s, [%s])`, typeKind(t.Elem()), strings.Join(elements, ", "))
+			return array
 		case *types.Slice:
-			return fc.formatExpr("new %s([%s])", fc.typeName(exprType), strings.Join(collectIndexedElements(t.Elem()), ", "))
+			elements := collectIndexedElements(t.Elem())
+			return fc.countAllocation(fc.formatExpr("new %s([%s])", fc.typeName(exprType), strings.Join(elements, ", ")), types.NewArray(t.Elem(), int64(len(elements))))
 		case *types.Map:
 			entries := make([]string, len(e.Elts))
 			for i, element := range e.Elts {
@@ -171,13 +183,17 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 					}
 				}
 			}
-			return fc.formatExpr("new %s.ptr(%s)", fc.typeName(exprType), strings.Join(elements, ", "))
+			lit := fc.formatExpr("new %s.ptr(%s)", fc.typeName(exprType), strings.Join(elements, ", "))
+			if isPointer {
+				return fc.countAllocation(lit, t)
+			}
+			return lit
 		default:
 			panic(fmt.Sprintf("Unhandled CompositeLit type: %T\n", t))
 		}
 
 	case *ast.FuncLit:
-		_, fun := translateFunction(e.Type, nil, e.Body, fc, exprType.(*types.Signature), fc.pkgCtx.FuncLitInfos[e], "")
+		_, fun := translateFunction(e.Type, nil, e.Body, fc, exprType.(*types.Signature), fc.pkgCtx.FuncLitInfos[e], "", fc.funcLitName())
 		if len(fc.pkgCtx.escapingVars) != 0 {
 			names := make([]string, 0, len(fc.pkgCtx.escapingVars))
 			for obj := range fc.pkgCtx.escapingVars {
@@ -203,39 +219,20 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 				// struct's object semantically equivalent to passing a pointer
 				// TODO(nevkontakte): Evaluate if performance gain justifies complexity
 				// introduced by the special case.
+				if _, isLit := astutil.RemoveParens(e.X).(*ast.CompositeLit); isLit {
+					return fc.countAllocation(fc.translateExpr(e.X), t)
+				}
 				return fc.translateExpr(e.X)
 			}
 
-			switch x := astutil.RemoveParens(e.X).(type) {
-			case *ast.CompositeLit:
-				return fc.formatExpr("$newDataPointer(%e, %s)", x, fc.typeName(fc.pkgCtx.TypeOf(e)))
-			case *ast.Ident:
-				obj := fc.pkgCtx.Uses[x].(*types.Var)
-				if fc.pkgCtx.escapingVars[obj] {
-					return fc.formatExpr("(%1s.$ptr || (%1s.$ptr = new %2s(function() { return this.$target[0]; }, function($v) { this.$target[0] = $v; }, %1s)))", fc.pkgCtx.objectNames[obj], fc.typeName(exprType))
-				}
-				return fc.formatExpr(`(%1s || (%1s = new %2s(function() { return %3s; }, function($v) { %4s })))`, fc.varPtrName(obj), fc.typeName(exprType), fc.objectName(obj), fc.translateAssign(x, fc.newIdent("$v", exprType), false))
-			case *ast.SelectorExpr:
-				sel, ok := fc.pkgCtx.SelectionOf(x)
-				if !ok {
-					// qualified identifier
-					obj := fc.pkgCtx.Uses[x.Sel].(*types.Var)
-					return fc.formatExpr(`(%1s || (%1s = new %2s(function() { return %3s; }, function($v) { %4s })))`, fc.varPtrName(obj), fc.typeName(exprType), fc.objectName(obj), fc.translateAssign(x, fc.newIdent("$v", exprType), false))
-				}
-				newSel := &ast.SelectorExpr{X: fc.newIdent("this.$target", fc.pkgCtx.TypeOf(x.X)), Sel: x.Sel}
-				fc.setType(newSel, exprType)
-				fc.pkgCtx.additionalSelections[newSel] = sel
-				return fc.formatExpr("(%1e.$ptr_%2s || (%1e.$ptr_%2s = new %3s(function() { return %4e; }, function($v) { %5s }, %1e)))", x.X, x.Sel.Name, fc.typeName(exprType), newSel, fc.translateAssign(newSel, fc.newIdent("$v", exprType), false))
-			case *ast.IndexExpr:
-				if _, ok := fc.pkgCtx.TypeOf(x.X).Underlying().(*types.Slice); ok {
-					return fc.formatExpr("$indexPtr(%1e.$array, %1e.$offset + %2e, %3s)", x.X, x.Index, fc.typeName(exprType))
-				}
-				return fc.formatExpr("$indexPtr(%e, %e, %s)", x.X, x.Index, fc.typeName(exprType))
-			case *ast.StarExpr:
-				return fc.translateExpr(x.X)
-			default:
-				panic(fmt.Sprintf("Unhandled: %T\n", x))
+			if typesutil.IsTypeParam(t) {
+				// Whether the pointer is represented by the object itself depends on
+				// the type argument, see above.
+				ptr := fc.translatePointerTo(e)
+				typ := fc.typeName(t)
+				return fc.formatExpr("(%s.kind === $kindStruct || %s.kind === $kindArray ? %e : %s)", typ, typ, e.X, ptr)
 			}
+			return fc.translatePointerTo(e)
 
 		case token.ARROW:
 			call := &ast.CallExpr{
@@ -249,6 +246,19 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			return fc.formatExpr("%e[0]", call)
 		}
 
+		if typesutil.IsTypeParam(t) {
+			switch e.Op {
+			case token.ADD:
+				return fc.translateExpr(e.X)
+			case token.SUB, token.XOR:
+				return fc.formatExpr(`$genericUnary("%s", %e, %s)`, e.Op.String(), e.X, fc.typeName(t))
+			case token.NOT:
+				return fc.formatExpr("!%e", e.X)
+			default:
+				panic(e.Op)
+			}
+		}
+
 		basic := t.Underlying().(*types.Basic)
 		switch e.Op {
 		case token.ADD:
@@ -287,10 +297,26 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		t := fc.pkgCtx.TypeOf(e.X)
 		t2 := fc.pkgCtx.TypeOf(e.Y)
 		_, isInterface := t2.Underlying().(*types.Interface)
+		if isInterface && typesutil.IsTypeParam(t2) {
+			isInterface = false // Type parameter's underlying type is its constraint.
+		}
 		if isInterface || types.Identical(t, types.Typ[types.UntypedNil]) {
 			t = t2
 		}
 
+		if typesutil.IsTypeParam(t) {
+			switch e.Op {
+			case token.EQL:
+				return fc.formatExpr("$equal(%e, %e, %s)", e.X, e.Y, fc.typeName(t))
+			case token.LAND, token.LOR:
+				// Handled below the same way as for any boolean operands.
+			case token.SHL, token.SHR:
+				return fc.formatExpr(`$genericBinary("%t", %e, %f, %s)`, e.Op, e.X, e.Y, fc.typeName(t))
+			default:
+				return fc.formatExpr(`$genericBinary("%t", %e, %e, %s)`, e.Op, e.X, e.Y, fc.typeName(t))
+			}
+		}
+
 		if basic, isBasic := t.Underlying().(*types.Basic); isBasic && isNumeric(basic) {
 			if is64Bit(basic) {
 				switch e.Op {
@@ -449,7 +475,11 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		return fc.formatParenExpr("%e", e.X)
 
 	case *ast.IndexExpr:
-		switch t := fc.pkgCtx.TypeOf(e.X).Underlying().(type) {
+		if fc.pkgCtx.Types[e.Index].IsType() {
+			// Explicit instantiation of a generic function.
+			return fc.translateExpr(e.X)
+		}
+		switch t := typesutil.CoreType(fc.pkgCtx.TypeOf(e.X)).(type) {
 		case *types.Pointer:
 			if _, ok := t.Elem().Underlying().(*types.Array); !ok {
 				// Should never happen in type-checked code.
@@ -496,8 +526,12 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			panic(fmt.Sprintf("Unhandled IndexExpr: %T\n", t))
 		}
 
+	case *ast.IndexListExpr:
+		// Explicit instantiation of a generic function.
+		return fc.translateExpr(e.X)
+
 	case *ast.SliceExpr:
-		if b, isBasic := fc.pkgCtx.TypeOf(e.X).Underlying().(*types.Basic); isBasic && isString(b) {
+		if b, isBasic := typesutil.CoreType(fc.pkgCtx.TypeOf(e.X)).(*types.Basic); isBasic && isString(b) {
 			switch {
 			case e.Low == nil && e.High == nil:
 				return fc.translateExpr(e.X)
@@ -531,6 +565,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		sel, ok := fc.pkgCtx.SelectionOf(e)
 		if !ok {
 			// qualified identifier
+			if inst, ok := fc.pkgCtx.Instances[e.Sel]; ok {
+				return fc.formatExpr("%s", fc.instanceName(obj, inst.TypeArgs))
+			}
 			return fc.formatExpr("%s", fc.objectName(obj))
 		}
 
@@ -550,6 +587,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			if !sel.Obj().Exported() {
 				fc.pkgCtx.dependencies[sel.Obj()] = true
 			}
+			if typesutil.IsTypeParam(sel.Recv()) {
+				return fc.formatExpr(`(%1s.kind === $kindInterface ? $ifaceMethodExpr("%2s") : $methodExpr(%1s, "%2s"))`, fc.typeName(sel.Recv()), sel.Obj().(*types.Func).Name())
+			}
 			if _, ok := sel.Recv().Underlying().(*types.Interface); ok {
 				return fc.formatExpr(`$ifaceMethodExpr("%s")`, sel.Obj().(*types.Func).Name())
 			}
@@ -565,7 +605,7 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			return fc.formatExpr("(%s)", fc.translateConversion(e.Args[0], fc.pkgCtx.TypeOf(plainFun)))
 		}
 
-		sig := fc.pkgCtx.TypeOf(plainFun).Underlying().(*types.Signature)
+		sig := typesutil.CoreType(fc.pkgCtx.TypeOf(plainFun)).(*types.Signature)
 
 		switch f := plainFun.(type) {
 		case *ast.Ident:
@@ -725,6 +765,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 				}
 			}
 		}
+		if typesutil.IsTypeParam(exprType) {
+			return fc.formatExpr("$genericDeref(%e, %s)", e.X, fc.typeName(exprType))
+		}
 		switch exprType.Underlying().(type) {
 		case *types.Struct, *types.Array:
 			return fc.translateExpr(e.X)
@@ -749,6 +792,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		case *types.Var, *types.Const:
 			return fc.formatExpr("%s", fc.objectName(o))
 		case *types.Func:
+			if inst, ok := fc.pkgCtx.Instances[e]; ok {
+				return fc.formatExpr("%s", fc.instanceName(o, inst.TypeArgs))
+			}
 			return fc.formatExpr("%s", fc.objectName(o))
 		case *types.TypeName:
 			return fc.formatExpr("%s", fc.typeName(o.Type()))
@@ -788,6 +834,61 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 	}
 }
 
+// countAllocation returns the expression e, which creates a new object of the
+// type t on the heap, preceded by increments of the $mallocs and $totalAlloc
+// counters, which runtime.ReadMemStats reports. The size of the object isn't
+// counted if t is nil or generic. The expression is returned as is unless
+// allocation tracking is enabled.
+func (fc *funcContext) countAllocation(e *expression, t types.Type) *expression {
+	if !fc.pkgCtx.trackAllocations {
+		return e
+	}
+	var size int64
+	if t != nil && !typesutil.IsGeneric(t) {
+		size = sizes32.Sizeof(t)
+	}
+	if size == 0 {
+		return fc.formatExpr("($mallocs++, %s)", e)
+	}
+	return fc.formatExpr("($mallocs++, $totalAlloc += %d, %s)", int(size), e)
+}
+
+// translatePointerTo translates taking an address of an operand, which is not
+// represented by a JavaScript object itself.
+func (fc *funcContext) translatePointerTo(e *ast.UnaryExpr) *expression {
+	exprType := fc.pkgCtx.TypeOf(e)
+	switch x := astutil.RemoveParens(e.X).(type) {
+	case *ast.CompositeLit:
+		return fc.formatExpr("$newDataPointer(%e, %s)", x, fc.typeName(fc.pkgCtx.TypeOf(e)))
+	case *ast.Ident:
+		obj := fc.pkgCtx.Uses[x].(*types.Var)
+		if fc.pkgCtx.escapingVars[obj] {
+			return fc.formatExpr("(%1s.$ptr || (%1s.$ptr = new %2s(function() { return this.$target[0]; }, function($v) { this.$target[0] = $v; }, %1s)))", fc.pkgCtx.objectNames[obj], fc.typeName(exprType))
+		}
+		return fc.formatExpr(`(%1s || (%1s = new %2s(function() { return %3s; }, function($v) { %4s })))`, fc.varPtrName(obj), fc.typeName(exprType), fc.objectName(obj), fc.translateAssign(x, fc.newIdent("$v", exprType), false))
+	case *ast.SelectorExpr:
+		sel, ok := fc.pkgCtx.SelectionOf(x)
+		if !ok {
+			// qualified identifier
+			obj := fc.pkgCtx.Uses[x.Sel].(*types.Var)
+			return fc.formatExpr(`(%1s || (%1s = new %2s(function() { return %3s; }, function($v) { %4s })))`, fc.varPtrName(obj), fc.typeName(exprType), fc.objectName(obj), fc.translateAssign(x, fc.newIdent("$v", exprType), false))
+		}
+		newSel := &ast.SelectorExpr{X: fc.newIdent("this.$target", fc.pkgCtx.TypeOf(x.X)), Sel: x.Sel}
+		fc.setType(newSel, exprType)
+		fc.pkgCtx.additionalSelections[newSel] = sel
+		return fc.formatExpr("(%1e.$ptr_%2s || (%1e.$ptr_%2s = new %3s(function() { return %4e; }, function($v) { %5s }, %1e)))", x.X, x.Sel.Name, fc.typeName(exprType), newSel, fc.translateAssign(newSel, fc.newIdent("$v", exprType), false))
+	case *ast.IndexExpr:
+		if _, ok := typesutil.CoreType(fc.pkgCtx.TypeOf(x.X)).(*types.Slice); ok {
+			return fc.formatExpr("$indexPtr(%1e.$array, %1e.$offset + %2e, %3s)", x.X, x.Index, fc.typeName(exprType))
+		}
+		return fc.formatExpr("$indexPtr(%e, %e, %s)", x.X, x.Index, fc.typeName(exprType))
+	case *ast.StarExpr:
+		return fc.translateExpr(x.X)
+	default:
+		panic(fmt.Sprintf("Unhandled: %T\n", x))
+	}
+}
+
 func (fc *funcContext) translateCall(e *ast.CallExpr, sig *types.Signature, fun *expression) *expression {
 	args := fc.translateArgs(sig, e.Args, e.Ellipsis.IsValid())
 	if fc.Blocking[e] {
@@ -797,6 +898,13 @@ func (fc *funcContext) translateCall(e *ast.CallExpr, sig *types.Signature, fun
 		if sig.Results().Len() != 0 {
 			returnVar = fc.newVariable("_r")
 		}
+		if !fc.pkgCtx.minify {
+			pos := e.Pos()
+			if !pos.IsValid() {
+				pos = fc.pos // Channel operations are calls synthesized by the compiler.
+			}
+			fc.resumePositions = append(fc.resumePositions, resumePosition{resumeCase: resumeCase, pos: pos, returnVar: returnVar})
+		}
 		fc.Printf("%[1]s = %[2]s(%[3]s); /* */ $s = %[4]d; case %[4]d: if($c) { $c = false; %[1]s = %[1]s.$blk(); } if (%[1]s && %[1]s.$blk !== undefined) { break s; }", returnVar, fun, strings.Join(args, ", "), resumeCase)
 		if sig.Results().Len() != 0 {
 			return fc.formatExpr("%s", returnVar)
@@ -826,7 +934,7 @@ func (fc *funcContext) delegatedCall(expr *ast.CallExpr) (callable *expression,
 	case *ast.SelectorExpr:
 		isJs = typesutil.IsJsPackage(fc.pkgCtx.Uses[fun.Sel].Pkg())
 	}
-	sig := fc.pkgCtx.TypeOf(expr.Fun).Underlying().(*types.Signature)
+	sig := typesutil.CoreType(fc.pkgCtx.TypeOf(expr.Fun)).(*types.Signature)
 	sigTypes := signatureTypes{Sig: sig}
 	args := fc.translateArgs(sig, expr.Args, expr.Ellipsis.IsValid())
 
@@ -889,6 +997,11 @@ func (fc *funcContext) makeReceiver(e *ast.SelectorExpr) *expression {
 		x = fc.setType(fakeSel, recvType)
 	}
 
+	if typesutil.IsTypeParam(recvType) {
+		// Methods of a type parameter are called via an interface value.
+		return fc.translateImplicitConversion(x, types.NewInterfaceType(nil, nil))
+	}
+
 	_, isPointer := recvType.Underlying().(*types.Pointer)
 	methodsRecvType := sel.Obj().Type().(*types.Signature).Recv().Type()
 	_, pointerExpected := methodsRecvType.(*types.Pointer)
@@ -917,12 +1030,12 @@ func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args
 		}
 		switch t.Elem().Underlying().(type) {
 		case *types.Struct, *types.Array:
-			return fc.formatExpr("%e", fc.zeroValue(t.Elem()))
+			return fc.countAllocation(fc.formatExpr("%e", fc.zeroValue(t.Elem())), t.Elem())
 		default:
-			return fc.formatExpr("$newDataPointer(%e, %s)", fc.zeroValue(t.Elem()), fc.typeName(t))
+			return fc.countAllocation(fc.formatExpr("$newDataPointer(%e, %s)", fc.zeroValue(t.Elem()), fc.typeName(t)), t.Elem())
 		}
 	case "make":
-		switch argType := fc.pkgCtx.TypeOf(args[0]).Underlying().(type) {
+		switch argType := typesutil.CoreType(fc.pkgCtx.TypeOf(args[0])).(type) {
 		case *types.Slice:
 			t := fc.typeName(fc.pkgCtx.TypeOf(args[0]))
 			if len(args) == 3 {
@@ -931,20 +1044,20 @@ func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args
 			return fc.formatExpr("$makeSlice(%s, %f)", t, args[1])
 		case *types.Map:
 			if len(args) == 2 && fc.pkgCtx.Types[args[1]].Value == nil {
-				return fc.formatExpr(`((%1f < 0 || %1f > 2147483647) ? $throwRuntimeError("makemap: size out of range") : new $global.Map())`, args[1])
+				return fc.formatExpr(`((%1f < 0 || %1f > 2147483647) ? $throwRuntimeError("makemap: size out of range") : ($mallocs++, new $global.Map()))`, args[1])
 			}
-			return fc.formatExpr("new $global.Map()")
+			return fc.countAllocation(fc.formatExpr("new $global.Map()"), nil)
 		case *types.Chan:
 			length := "0"
 			if len(args) == 2 {
 				length = fc.formatExpr("%f", args[1]).String()
 			}
-			return fc.formatExpr("new $Chan(%s, %s)", fc.typeName(fc.pkgCtx.TypeOf(args[0]).Underlying().(*types.Chan).Elem()), length)
+			return fc.countAllocation(fc.formatExpr("new $Chan(%s, %s)", fc.typeName(typesutil.CoreType(fc.pkgCtx.TypeOf(args[0])).(*types.Chan).Elem()), length), nil)
 		default:
 			panic(fmt.Sprintf("Unhandled make type: %T\n", argType))
 		}
 	case "len":
-		switch argType := fc.pkgCtx.TypeOf(args[0]).Underlying().(type) {
+		switch argType := typesutil.CoreType(fc.pkgCtx.TypeOf(args[0])).(type) {
 		case *types.Basic:
 			return fc.formatExpr("%e.length", args[0])
 		case *types.Slice:
@@ -960,7 +1073,7 @@ func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args
 			panic(fmt.Sprintf("Unhandled len type: %T\n", argType))
 		}
 	case "cap":
-		switch argType := fc.pkgCtx.TypeOf(args[0]).Underlying().(type) {
+		switch argType := typesutil.CoreType(fc.pkgCtx.TypeOf(args[0])).(type) {
 		case *types.Slice, *types.Chan:
 			return fc.formatExpr("%e.$capacity", args[0])
 		case *types.Pointer:
@@ -976,11 +1089,11 @@ func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args
 			argStr := fc.translateArgs(sig, args, ellipsis)
 			return fc.formatExpr("$appendSlice(%s, %s)", argStr[0], argStr[1])
 		}
-		sliceType := sig.Results().At(0).Type().Underlying().(*types.Slice)
+		sliceType := typesutil.CoreType(sig.Results().At(0).Type()).(*types.Slice)
 		return fc.formatExpr("$append(%e, %s)", args[0], strings.Join(fc.translateExprSlice(args[1:], sliceType.Elem()), ", "))
 	case "delete":
 		args = fc.expandTupleArgs(args)
-		keyType := fc.pkgCtx.TypeOf(args[0]).Underlying().(*types.Map).Key()
+		keyType := typesutil.CoreType(fc.pkgCtx.TypeOf(args[0])).(*types.Map).Key()
 		return fc.formatExpr(
 			`$mapDelete(%1e, %2s.keyFor(%3s))`,
 			args[0],
@@ -989,7 +1102,7 @@ func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args
 		)
 	case "copy":
 		args = fc.expandTupleArgs(args)
-		if basic, isBasic := fc.pkgCtx.TypeOf(args[1]).Underlying().(*types.Basic); isBasic && isString(basic) {
+		if basic, isBasic := typesutil.CoreType(fc.pkgCtx.TypeOf(args[1])).(*types.Basic); isBasic && isString(basic) {
 			return fc.formatExpr("$copyString(%e, %e)", args[0], args[1])
 		}
 		return fc.formatExpr("$copySlice(%e, %e)", args[0], args[1])
@@ -1046,6 +1159,16 @@ func (fc *funcContext) translateConversion(expr ast.Expr, desiredType types.Type
 		return fc.translateExpr(expr)
 	}
 
+	if typesutil.IsTypeParam(desiredType) {
+		if value := fc.pkgCtx.Types[expr].Value; value != nil {
+			return fc.translateGenericConst(value, desiredType)
+		}
+	}
+	if typesutil.IsTypeParam(exprType) || typesutil.IsTypeParam(desiredType) {
+		// The conversion depends on the type arguments.
+		return fc.formatExpr("$genericConvert(%e, %s, %s)", expr, fc.typeName(exprType), fc.typeName(desiredType))
+	}
+
 	if fc.pkgCtx.Pkg.Path() == "reflect" || fc.pkgCtx.Pkg.Path() == "internal/reflectlite" {
 		if call, isCall := expr.(*ast.CallExpr); isCall && types.Identical(fc.pkgCtx.TypeOf(call.Fun), types.Typ[types.UnsafePointer]) {
 			if ptr, isPtr := desiredType.(*types.Pointer); isPtr {
@@ -1211,6 +1334,16 @@ func (fc *funcContext) translateConversion(expr ast.Expr, desiredType types.Type
 }
 
 func (fc *funcContext) translateImplicitConversionWithCloning(expr ast.Expr, desiredType types.Type) *expression {
+	if typesutil.IsTypeParam(desiredType) {
+		switch expr.(type) {
+		case nil, *ast.CompositeLit:
+			// nothing
+		default:
+			return fc.formatExpr("$genericClone(%e, %s)", expr, fc.typeName(desiredType))
+		}
+		return fc.translateImplicitConversion(expr, desiredType)
+	}
+
 	switch desiredType.Underlying().(type) {
 	case *types.Struct, *types.Array:
 		switch expr.(type) {
@@ -1239,6 +1372,15 @@ func (fc *funcContext) translateImplicitConversion(expr ast.Expr, desiredType ty
 		return fc.formatExpr("%e", fc.zeroValue(desiredType))
 	}
 
+	if typesutil.IsTypeParam(desiredType) {
+		return fc.translateExpr(expr)
+	}
+	if typesutil.IsTypeParam(exprType) {
+		if _, isInterface := desiredType.Underlying().(*types.Interface); isInterface {
+			return fc.formatExpr("$genericBox(%e, %s)", expr, fc.typeName(exprType))
+		}
+	}
+
 	switch desiredType.Underlying().(type) {
 	case *types.Slice:
 		return fc.formatExpr("$convertSliceType(%1e, %2s)", expr, fc.typeName(desiredType))
@@ -1260,7 +1402,7 @@ func (fc *funcContext) translateImplicitConversion(expr ast.Expr, desiredType ty
 }
 
 func (fc *funcContext) translateConversionToSlice(expr ast.Expr, desiredType types.Type) *expression {
-	switch fc.pkgCtx.TypeOf(expr).Underlying().(type) {
+	switch typesutil.CoreType(fc.pkgCtx.TypeOf(expr)).(type) {
 	case *types.Array, *types.Pointer:
 		return fc.formatExpr("new %s(%e)", fc.typeName(desiredType), expr)
 	}
@@ -1438,6 +1580,12 @@ func (fc *funcContext) formatExprInternal(format string, a []interface{}, parens
 				out.WriteString(strconv.FormatInt(d, 10))
 				return
 			}
+			if typesutil.IsTypeParam(fc.pkgCtx.TypeOf(e)) {
+				out.WriteString("$genericFlatten(")
+				writeExpr("")
+				out.WriteString(")")
+				return
+			}
 			if is64Bit(fc.pkgCtx.TypeOf(e).Underlying().(*types.Basic)) {
 				out.WriteString("$flatten64(")
 				writeExpr("")
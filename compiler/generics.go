@@ -0,0 +1,234 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Generic functions and types are compiled once into factory functions, which
+// accept type arguments (runtime type objects) as their parameters and return
+// an instance of the function or type. Factory results are cached by the
+// prelude, so that each unique instance is only created once.
+//
+// Code inside of a generic declaration refers to the types which depend on its
+// type parameters via factory-local variables, which are initialized lazily
+// upon the first use. Instances which don't depend on any type parameters are
+// stored in lazily initialized package-level variables, each of which is
+// a separate declaration for the purposes of dead code elimination.
+
+// genericCtx maintains compiler context for a generic function or type being
+// translated into a factory function.
+type genericCtx struct {
+	// Factory-local variables, which cache types and instances.
+	vars []string
+	// Expressions evaluating to anonymous types that depend on type parameters.
+	types typeutil.Map
+	// Expressions evaluating to generic instances that depend on type
+	// parameters, grouped by the generic object.
+	instances map[types.Object]*typeutil.Map
+}
+
+// instance is a package-level variable, which caches an instance of a generic
+// function or type that doesn't depend on any type parameters.
+type instance struct {
+	// JavaScript expression, which evaluates to the instance.
+	expr string
+	// Fake object, which represents the instance for dead code elimination.
+	obj *types.TypeName
+	// Objects the instance depends upon.
+	deps map[types.Object]bool
+}
+
+// typeArgsKey returns a key identifying a list of type arguments in
+// a typeutil.Map.
+func typeArgsKey(args *types.TypeList) types.Type {
+	vars := make([]*types.Var, args.Len())
+	for i := range vars {
+		vars[i] = types.NewVar(token.NoPos, nil, "", args.At(i))
+	}
+	return types.NewTuple(vars...)
+}
+
+func isGenericList(args *types.TypeList) bool {
+	for i := 0; i < args.Len(); i++ {
+		if typesutil.IsGeneric(args.At(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeArgNames returns a comma-separated list of JavaScript expressions that
+// evaluate to the given type arguments.
+func (fc *funcContext) typeArgNames(args *types.TypeList) string {
+	names := make([]string, args.Len())
+	for i := range names {
+		names[i] = fc.typeName(args.At(i))
+	}
+	return strings.Join(names, ", ")
+}
+
+// instanceName returns a JavaScript expression, which evaluates to the instance
+// of the generic function or type obj with the given type arguments.
+func (fc *funcContext) instanceName(obj types.Object, args *types.TypeList) string {
+	key := typeArgsKey(args)
+
+	if isGenericList(args) {
+		g := fc.pkgCtx.generic
+		if g == nil {
+			panic(bailout(fmt.Errorf("instance of %s with type arguments %v outside of a generic context", obj, args)))
+		}
+		if g.instances == nil {
+			g.instances = make(map[types.Object]*typeutil.Map)
+		}
+		cache, ok := g.instances[obj]
+		if !ok {
+			cache = &typeutil.Map{}
+			g.instances[obj] = cache
+		}
+		if expr, ok := cache.At(key).(string); ok {
+			return expr
+		}
+		name := fc.newVariableWithLevel(obj.Name(), true)
+		g.vars = append(g.vars, name)
+		expr := fmt.Sprintf("(%[1]s || (%[1]s = %[2]s(%[3]s)))", name, fc.objectName(obj), fc.typeArgNames(args))
+		cache.Set(key, expr)
+		return expr
+	}
+
+	cache, ok := fc.pkgCtx.instanceMap[obj]
+	if !ok {
+		cache = &typeutil.Map{}
+		fc.pkgCtx.instanceMap[obj] = cache
+	}
+	inst, ok := cache.At(key).(*instance)
+	if !ok {
+		// Dependencies of the instance are tracked separately from the symbol
+		// being translated, so that the instance could be eliminated on its own.
+		prevDeps := fc.pkgCtx.dependencies
+		fc.pkgCtx.dependencies = make(map[types.Object]bool)
+		origin := fc.objectName(obj)
+		typeArgs := fc.typeArgNames(args)
+		deps := fc.pkgCtx.dependencies
+		fc.pkgCtx.dependencies = prevDeps
+
+		name := fc.newVariableWithLevel(obj.Name(), true)
+		inst = &instance{
+			expr: fmt.Sprintf("(%[1]s || (%[1]s = %[2]s(%[3]s)))", name, origin, typeArgs),
+			obj:  types.NewTypeName(token.NoPos, fc.pkgCtx.Pkg, name, nil), // fake types.TypeName
+			deps: deps,
+		}
+		cache.Set(key, inst)
+		fc.pkgCtx.instances = append(fc.pkgCtx.instances, inst)
+	}
+	fc.pkgCtx.dependencies[inst.obj] = true
+	return inst.expr
+}
+
+// genericTypeName returns a JavaScript expression, which evaluates to an
+// anonymous type that depends on type parameters.
+func (fc *funcContext) genericTypeName(ty types.Type) string {
+	g := fc.pkgCtx.generic
+	if g == nil {
+		panic(bailout(fmt.Errorf("type %v depends on type parameters outside of a generic context", ty)))
+	}
+	if expr, ok := g.types.At(ty).(string); ok {
+		return expr
+	}
+	name := fc.newVariableWithLevel(strings.ToLower(typeKind(ty)[5:])+"Type", true)
+	g.vars = append(g.vars, name)
+	expr := fmt.Sprintf("(%[1]s || (%[1]s = $%[2]sType(%[3]s)))", name, strings.ToLower(typeKind(ty)[5:]), fc.initArgs(ty))
+	g.types.Set(ty, expr)
+	return expr
+}
+
+// translateGenericFactory returns code of a factory function for a generic
+// declaration with the given type parameters. The factory body is produced by
+// the body callback, which is invoked with a context that has all type
+// parameters in scope. Type parameters in each of the aliases lists are
+// considered equivalent to the corresponding ones in tparams, which is the case
+// for receiver type parameters of methods.
+func (fc *funcContext) translateGenericFactory(tparams *types.TypeParamList, aliases []*types.TypeParamList, body func(factory *funcContext)) string {
+	if fc.pkgCtx.generic != nil {
+		panic(bailout(fmt.Errorf("nested generic declarations are not supported")))
+	}
+
+	factory := &funcContext{
+		FuncInfo:    fc.FuncInfo,
+		pkgCtx:      fc.pkgCtx,
+		parent:      fc,
+		allVars:     make(map[string]int, len(fc.allVars)),
+		flowDatas:   map[*types.Label]*flowData{nil: {}},
+		caseCounter: 1,
+		labelCases:  make(map[*types.Label]int),
+	}
+	for k, v := range fc.allVars {
+		factory.allVars[k] = v
+	}
+
+	params := make([]string, tparams.Len())
+	for i := range params {
+		params[i] = factory.newVariable(tparams.At(i).Obj().Name())
+		fc.pkgCtx.objectNames[tparams.At(i).Obj()] = params[i]
+		for _, alias := range aliases {
+			fc.pkgCtx.objectNames[alias.At(i).Obj()] = params[i]
+		}
+	}
+	factory.localVars = nil // Type parameters are passed as arguments.
+
+	g := &genericCtx{}
+	fc.pkgCtx.generic = g
+	bodyOutput := factory.CatchOutput(1, func() { body(factory) })
+	fc.pkgCtx.generic = nil
+
+	indent := strings.Repeat("\t", fc.pkgCtx.indentation)
+	varDefs := ""
+	if len(g.vars) > 0 {
+		varDefs = fmt.Sprintf("%s\tvar %s;\n", indent, strings.Join(g.vars, ", "))
+	}
+	return fmt.Sprintf("function(%s) {\n%s%s%s}", strings.Join(params, ", "), varDefs, bodyOutput, indent)
+}
+
+// kindOf returns a JavaScript expression, which evaluates to the kind of
+// the given type.
+func (fc *funcContext) kindOf(ty types.Type) string {
+	if typesutil.IsTypeParam(ty) {
+		return fc.typeName(ty) + ".kind"
+	}
+	return typeKind(ty)
+}
+
+// translateGenericConst translates a constant value, which has a type
+// parameter's type.
+func (fc *funcContext) translateGenericConst(value constant.Value, ty types.Type) *expression {
+	switch value.Kind() {
+	case constant.Bool:
+		return fc.formatExpr("%s", strconv.FormatBool(constant.BoolVal(value)))
+	case constant.String:
+		return fc.formatExpr("%s", encodeString(constant.StringVal(value)))
+	case constant.Int:
+		// The value is passed as high and low 32-bit words, like the constants
+		// of 64-bit types, since it may not be exact as a JavaScript number.
+		if d, ok := constant.Int64Val(value); ok {
+			return fc.formatExpr("$genericIntConst(%s, %s, %s)", fc.typeName(ty), strconv.FormatInt(d>>32, 10), strconv.FormatUint(uint64(d)&(1<<32-1), 10))
+		}
+		d, _ := constant.Uint64Val(value)
+		return fc.formatExpr("$genericIntConst(%s, %s, %s)", fc.typeName(ty), strconv.FormatUint(d>>32, 10), strconv.FormatUint(d&(1<<32-1), 10))
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return fc.formatExpr("$genericConst(%s, %s)", fc.typeName(ty), strconv.FormatFloat(f, 'g', -1, 64))
+	case constant.Complex:
+		r, _ := constant.Float64Val(constant.Real(value))
+		i, _ := constant.Float64Val(constant.Imag(value))
+		return fc.formatExpr("$genericConst(%s, %s, %s)", fc.typeName(ty), strconv.FormatFloat(r, 'g', -1, 64), strconv.FormatFloat(i, 'g', -1, 64))
+	default:
+		panic(bailout(fmt.Errorf("unhandled constant %v of type %v", value, ty)))
+	}
+}
@@ -0,0 +1,107 @@
+package compiler
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// BuildMode determines what kind of JavaScript program is produced.
+type BuildMode string
+
+const (
+	// BuildModeExe produces a program out of a main package, which runs the
+	// main function.
+	BuildModeExe BuildMode = "exe"
+	// BuildModeLibrary produces a program out of a non-main package, which
+	// exports the package's API to JavaScript once the package is initialized.
+	BuildModeLibrary BuildMode = "library"
+)
+
+// ParseBuildMode validates build mode name.
+func ParseBuildMode(name string) (BuildMode, error) {
+	switch m := BuildMode(name); m {
+	case "", "default", BuildModeExe:
+		return BuildModeExe, nil
+	case BuildModeLibrary:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unsupported build mode %q, must be one of: %s, %s", name, BuildModeExe, BuildModeLibrary)
+	}
+}
+
+// jsPkgPath is the import path of the package providing JavaScript interop.
+const jsPkgPath = "github.com/gopherjs/gopherjs/js"
+
+// Library describes the JavaScript API of a package built with
+// BuildModeLibrary.
+type Library struct {
+	// Declaration, which exports the package's API when the package is
+	// initialized. It is only included into the program in the library build
+	// mode.
+	Decl *Decl
+	// Exported values, along with their TypeScript types.
+	Exports []ModuleExport
+}
+
+// translateLibrary generates a declaration which exports the package's API to
+// JavaScript.
+//
+// Exported functions are wrapped using $externalize/$internalize conversions,
+// with js.MakeFullWrapper used for struct values. Functions that may block
+// return a Promise. Exported struct types are exported as constructors of
+// wrapped pointers to the zero value, optionally initialized with the fields of
+// the object passed to the constructor. Generic declarations are not exported,
+// since there is no way to instantiate them from JavaScript. TypeScript types
+// of the exports are recorded for the declaration file, if ts isn't nil.
+//
+// The exports are set on the "exports" object of the CommonJS module (or its
+// emulation for the ECMAScript modules), or on a global variable named after
+// the package when loaded as a classic script.
+func (fc *funcContext) translateLibrary(pkg *types.Package, ts *tsTypes) *Library {
+	lib := &Library{Decl: &Decl{}}
+	scope := pkg.Scope()
+	names := scope.Names()
+	sort.Strings(names)
+
+	lib.Decl.InitCode = fc.CatchOutput(1, func() {
+		fc.Printf("var $libraryExports = $module !== undefined ? $module.exports : ($global[%s] = {});", encodeString(pkg.Name()))
+		fc.Printf("var $libraryWrapper = $packages[%s].MakeFullWrapper;", encodeString(jsPkgPath))
+		for _, name := range names {
+			switch o := scope.Lookup(name).(type) {
+			case *types.Func:
+				sig := o.Type().(*types.Signature)
+				if !o.Exported() || sig.TypeParams().Len() > 0 {
+					continue
+				}
+				blocking := fc.pkgCtx.IsBlocking(o)
+				fc.Printf("$libraryExports.%s = $exportFunction(%s, %s, %t, $libraryWrapper);", encodeIdent(name), fc.objectName(o), fc.typeName(sig), blocking)
+				export := ModuleExport{Name: name}
+				if ts != nil {
+					export.Type = ts.libraryFuncType(sig, blocking)
+				}
+				lib.Exports = append(lib.Exports, export)
+			case *types.TypeName:
+				named, ok := o.Type().(*types.Named)
+				if !o.Exported() || o.IsAlias() || !ok || named.TypeParams().Len() > 0 {
+					continue
+				}
+				if st, ok := named.Underlying().(*types.Struct); !ok || isJsObjectStruct(st) {
+					// Structs embedding *js.Object wrap JavaScript objects, which
+					// can't be created out of Go.
+					continue
+				}
+				fc.Printf("$libraryExports.%s = $exportStructType(%s, $libraryWrapper);", encodeIdent(name), fc.typeName(named))
+				export := ModuleExport{Name: name}
+				if ts != nil {
+					export.Type = ts.libraryStructType(named)
+				}
+				lib.Exports = append(lib.Exports, export)
+			}
+		}
+		// Exported functions may be called at any time, so the program can't be
+		// considered deadlocked if all goroutines are asleep.
+		fc.Printf("$checkForDeadlock = false;")
+	})
+	return lib
+}
@@ -0,0 +1,89 @@
+package compiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibraryExports(t *testing.T) {
+	src := []byte(`package lib
+
+type Point struct{ X, Y int }
+
+func (p *Point) Move(dx, dy int) { p.X += dx; p.Y += dy }
+
+type Celsius float64
+
+type List[T any] struct{ items []T }
+
+func NewPoint(x, y int) *Point { return &Point{x, y} }
+
+func Map[T any](x T) T { return x }
+
+func unexported() {}
+
+var Var = 1
+
+const Const = 2
+`)
+	archive, err := compileArchive("example.com/lib", []source{{"lib.go", src}}, CompileOptions{Library: true, Declarations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.Library == nil {
+		t.Fatalf("Library of a non-main package is nil")
+	}
+	want := []ModuleExport{
+		{Name: "NewPoint", Type: "(x: number, y: number) => Point | null"},
+		{Name: "Point", Type: "{ new (init?: { X?: number; Y?: number }): Point }"},
+	}
+	if diff := cmp.Diff(want, archive.Library.Exports); diff != "" {
+		t.Errorf("Library.Exports returned diff (-want,+got):\n%s", diff)
+	}
+	if deps := archive.Library.Decl.DceDeps; !containsString(deps, "example.com/lib.NewPoint") || !containsString(deps, jsPkgPath+".MakeFullWrapper") {
+		t.Errorf("Library.Decl.DceDeps = %v, want exported symbols and js.MakeFullWrapper", deps)
+	}
+
+	exe, err := compileArchive("example.com/lib", []source{{"lib.go", src}}, CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exe.Library != nil {
+		t.Errorf("Library of a package compiled without CompileOptions.Library is not nil")
+	}
+
+	main, err := compileArchive("main", []source{{"main.go", []byte("package main\n\nfunc main() {}\n")}}, CompileOptions{Library: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if main.Library != nil {
+		t.Errorf("Library of a main package is not nil")
+	}
+	err = WriteProgramCode([]*Archive{main}, &SourceMapFilter{Writer: &bytes.Buffer{}}, "go1.18", FormatIIFE, BuildModeLibrary)
+	if err == nil || !strings.Contains(err.Error(), "requires a non-main package") {
+		t.Errorf("WriteProgramCode() of a main package in library mode returned error %v", err)
+	}
+}
+
+func TestParseBuildMode(t *testing.T) {
+	for name, want := range map[string]BuildMode{"": BuildModeExe, "default": BuildModeExe, "exe": BuildModeExe, "library": BuildModeLibrary} {
+		if got, err := ParseBuildMode(name); err != nil || got != want {
+			t.Errorf("ParseBuildMode(%q) = %q, %v; want %q, nil", name, got, err, want)
+		}
+	}
+	if _, err := ParseBuildMode("c-shared"); err == nil {
+		t.Errorf("ParseBuildMode(%q) returned no error", "c-shared")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+//go:build js
+// +build js
+
+package fuzz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Environment variables which pass the fuzzing parameters from the coordinator
+// to the worker process.
+const (
+	workerDirEnv     = "GOPHERJS_FUZZ_WORKER_DIR"
+	workerTimeoutEnv = "GOPHERJS_FUZZ_TIMEOUT"
+	workerLimitEnv   = "GOPHERJS_FUZZ_LIMIT"
+)
+
+// workerMaxBytes limits the size of the mutated []byte and string values.
+const workerMaxBytes = 1 << 20
+
+// CoordinateFuzzing runs a single worker process, which tests random inputs
+// that could trigger crashes and expose bugs.
+//
+// GopherJS doesn't support coverage instrumentation and shared memory, which
+// the original implementation relies on, so the worker simply mutates the seed
+// corpus entries at random until one of them fails, or the timeout or the limit
+// of calls to the fuzz function is reached. The failing input is written to
+// opts.CorpusDir and isn't minimized.
+func CoordinateFuzzing(ctx context.Context, opts CoordinateFuzzingOpts) (err error) {
+	dir, err := os.MkdirTemp("", "gopherjs-fuzz")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	seed := opts.Seed
+	if len(seed) == 0 {
+		vals := make([]any, len(opts.Types))
+		for i, t := range opts.Types {
+			vals[i] = reflect.Zero(t).Interface()
+		}
+		seed = []CorpusEntry{{Values: vals}}
+	}
+	for i, e := range seed {
+		data := e.Data
+		if data == nil && e.Values == nil {
+			if data, err = os.ReadFile(e.Path); err != nil {
+				return err
+			}
+		} else if data == nil {
+			data = marshalCorpusFile(e.Values...)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("seed-%06d", i)), data, 0666); err != nil {
+			return err
+		}
+	}
+
+	status, err := runFuzzWorker(dir, opts)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "crasher"))
+	if errors.Is(err, os.ErrNotExist) {
+		if status != 0 {
+			return fmt.Errorf("fuzzing process exited with status %d", status)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	msg, _ := os.ReadFile(filepath.Join(dir, "error"))
+	if len(msg) == 0 {
+		msg = []byte(fmt.Sprintf("fuzzing process terminated unexpectedly: exit status %d", status))
+	}
+	entry := CorpusEntry{Data: data}
+	if err := writeToCorpus(&entry, opts.CorpusDir); err != nil {
+		return fmt.Errorf("%s\nfailed to write the failing input: %w", msg, err)
+	}
+	return &workerCrashError{path: entry.Path, err: errors.New(strings.TrimSuffix(string(msg), "\n"))}
+}
+
+// runFuzzWorker runs the test binary in a Node.js child process with the same
+// arguments and the -test.fuzzworker flag, waits for it to exit, and returns
+// its exit status.
+func runFuzzWorker(dir string, opts CoordinateFuzzingOpts) (int, error) {
+	require := js.Global.Get("require")
+	if require == js.Undefined {
+		return 0, errors.New("fuzzing is only supported in Node.js")
+	}
+	process := js.Global.Get("process")
+	args := process.Get("execArgv").Call("concat", process.Get("argv").Call("slice", 1), []string{"-test.fuzzworker"})
+	env := js.Global.Get("Object").Call("assign", js.M{}, process.Get("env"), js.M{
+		workerDirEnv:     dir,
+		workerTimeoutEnv: opts.Timeout.String(),
+		workerLimitEnv:   strconv.FormatInt(opts.Limit, 10),
+	})
+	result := require.Invoke("child_process").Call("spawnSync", process.Get("execPath"), args, js.M{
+		"stdio": "inherit",
+		"env":   env,
+	})
+	if e := result.Get("error"); e != js.Undefined {
+		return 0, &js.Error{Object: e}
+	}
+	if status := result.Get("status"); status != nil {
+		return status.Int(), nil
+	}
+	return -1, nil // Killed by a signal.
+}
+
+// RunFuzzWorker runs the fuzz function with inputs derived from the seed corpus
+// passed by CoordinateFuzzing, until one of them fails or the limits are
+// reached.
+func RunFuzzWorker(ctx context.Context, fn func(CorpusEntry) error) error {
+	dir := os.Getenv(workerDirEnv)
+	if dir == "" {
+		return errors.New("the fuzzing worker must be started by the fuzzing coordinator")
+	}
+	timeout, err := time.ParseDuration(os.Getenv(workerTimeoutEnv))
+	if err != nil {
+		return err
+	}
+	limit, err := strconv.ParseInt(os.Getenv(workerLimitEnv), 10, 64)
+	if err != nil {
+		return err
+	}
+	seed, err := readWorkerSeed(dir)
+	if err != nil {
+		return err
+	}
+
+	// The process may exit rather than report a failure, for example on a call
+	// to os.Exit, so the input being tested is saved when it does.
+	var current []byte
+	crasher := filepath.Join(dir, "crasher")
+	js.Global.Get("process").Call("on", "exit", func() {
+		if current != nil {
+			js.Global.Get("fs").Call("writeFileSync", crasher, current)
+		}
+	})
+
+	m := newMutator()
+	start := time.Now()
+	lastLog := start
+	for execs := int64(0); limit == 0 || execs < limit; execs++ {
+		if ctx.Err() != nil || (timeout > 0 && time.Since(start) > timeout) {
+			break
+		}
+		if time.Since(lastLog) > 3*time.Second {
+			lastLog = time.Now()
+			elapsed := lastLog.Sub(start)
+			fmt.Fprintf(os.Stderr, "fuzz: elapsed: %s, execs: %d (%.0f/sec)\n", elapsed.Round(time.Second), execs, float64(execs)/elapsed.Seconds())
+		}
+
+		// Test the seed corpus as is first.
+		vals := seed[int(execs)%len(seed)]
+		if execs >= int64(len(seed)) {
+			vals = copyValues(seed[m.rand(len(seed))])
+			m.mutate(vals, workerMaxBytes)
+		}
+		current = marshalCorpusFile(vals...)
+		if err := fn(CorpusEntry{Data: current, Values: vals}); err != nil {
+			if err := os.WriteFile(crasher, current, 0666); err != nil {
+				return err
+			}
+			current = nil
+			return os.WriteFile(filepath.Join(dir, "error"), []byte(err.Error()), 0666)
+		}
+		current = nil
+	}
+	return nil
+}
+
+// readWorkerSeed reads the values of the seed corpus entries written to dir by
+// CoordinateFuzzing.
+func readWorkerSeed(dir string) ([][]any, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "seed-*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var seed [][]any
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		vals, err := unmarshalCorpusFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed corpus entry: %w", err)
+		}
+		seed = append(seed, vals)
+	}
+	if len(seed) == 0 {
+		return nil, errors.New("empty seed corpus")
+	}
+	return seed, nil
+}
+
+// copyValues returns a copy of the values, which the mutator can modify in
+// place.
+func copyValues(vals []any) []any {
+	c := make([]any, len(vals))
+	for i, v := range vals {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		c[i] = v
+	}
+	return c
+}
+
+// workerCrashError is a failure found by the worker, which was written to the
+// seed corpus. The testing package reports the path of the file, to re-run the
+// failing input.
+type workerCrashError struct {
+	path string
+	err  error
+}
+
+func (e *workerCrashError) Error() string     { return e.err.Error() }
+func (e *workerCrashError) Unwrap() error     { return e.err }
+func (e *workerCrashError) CrashPath() string { return e.path }
@@ -0,0 +1,51 @@
+//go:build js
+// +build js
+
+package metrics
+
+import "runtime"
+
+// memStatsMetrics maps the names of the supported metrics to their values,
+// derived from runtime.MemStats. The memory classes add up to the total the
+// same way as in the upstream runtime; the memory which JavaScript doesn't
+// report separately, such as the stacks and the runtime's metadata, is zero.
+var memStatsMetrics = map[string]func(*runtime.MemStats) uint64{
+	"/gc/heap/allocs:bytes":                       func(m *runtime.MemStats) uint64 { return m.TotalAlloc },
+	"/gc/heap/allocs:objects":                     func(m *runtime.MemStats) uint64 { return m.Mallocs },
+	"/memory/classes/heap/free:bytes":             func(m *runtime.MemStats) uint64 { return m.HeapIdle - m.HeapReleased },
+	"/memory/classes/heap/objects:bytes":          func(m *runtime.MemStats) uint64 { return m.HeapAlloc },
+	"/memory/classes/heap/released:bytes":         func(m *runtime.MemStats) uint64 { return m.HeapReleased },
+	"/memory/classes/heap/stacks:bytes":           func(m *runtime.MemStats) uint64 { return m.StackInuse },
+	"/memory/classes/heap/unused:bytes":           func(m *runtime.MemStats) uint64 { return m.HeapInuse - m.HeapAlloc },
+	"/memory/classes/metadata/mcache/free:bytes":  func(m *runtime.MemStats) uint64 { return m.MCacheSys - m.MCacheInuse },
+	"/memory/classes/metadata/mcache/inuse:bytes": func(m *runtime.MemStats) uint64 { return m.MCacheInuse },
+	"/memory/classes/metadata/mspan/free:bytes":   func(m *runtime.MemStats) uint64 { return m.MSpanSys - m.MSpanInuse },
+	"/memory/classes/metadata/mspan/inuse:bytes":  func(m *runtime.MemStats) uint64 { return m.MSpanInuse },
+	"/memory/classes/metadata/other:bytes":        func(m *runtime.MemStats) uint64 { return m.GCSys },
+	"/memory/classes/os-stacks:bytes":             func(m *runtime.MemStats) uint64 { return m.StackSys - m.StackInuse },
+	"/memory/classes/other:bytes":                 func(m *runtime.MemStats) uint64 { return m.OtherSys },
+	"/memory/classes/profiling/buckets:bytes":     func(m *runtime.MemStats) uint64 { return m.BuckHashSys },
+	"/memory/classes/total:bytes":                 func(m *runtime.MemStats) uint64 { return m.Sys },
+}
+
+// Read populates each Value field in the given slice of metric samples.
+//
+// GopherJS only supports the metrics of the memory classes, the heap
+// allocations and the number of goroutines, which are derived from
+// runtime.ReadMemStats. The values of the other samples, including the
+// histograms, are populated as KindBad.
+func Read(m []Sample) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	for i := range m {
+		s := &m[i]
+		switch f, ok := memStatsMetrics[s.Name]; {
+		case ok:
+			s.Value = Value{kind: KindUint64, scalar: f(&stats)}
+		case s.Name == "/sched/goroutines:goroutines":
+			s.Value = Value{kind: KindUint64, scalar: uint64(runtime.NumGoroutine())}
+		default:
+			s.Value = Value{kind: KindBad}
+		}
+	}
+}
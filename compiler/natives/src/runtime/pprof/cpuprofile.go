@@ -0,0 +1,273 @@
+//go:build js
+// +build js
+
+package pprof
+
+import (
+	"compress/gzip"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// v8Frames maps the pseudo-frames of the V8 profiler to the functions the
+// upstream Go profiles report for the same kind of samples.
+var v8Frames = map[string]string{
+	"(garbage collector)": "runtime._GC",
+	"(program)":           "runtime._ExternalCode",
+}
+
+// jsLocation is a position in a JavaScript function of the profiled program.
+// The lines and columns are zero-based, as in the V8 profile.
+type jsLocation struct {
+	function string
+	url      string
+	funcLine int // Position of the function.
+	funcCol  int
+	line     int // Position in the function the sample was taken at.
+	col      int
+}
+
+// jsFunc is a function of the profile.
+type jsFunc struct {
+	name      string
+	file      string
+	startLine int
+}
+
+// jsProfileBuilder converts a CPU profile recorded by the V8 profiler, or the
+// stacks of the goroutines, into the pprof format.
+type jsProfileBuilder struct {
+	pb        protobuf
+	strings   []string
+	stringMap map[string]int
+	locs      map[jsLocation]uint64
+	pcLocs    map[uintptr]uint64
+	lastLocID uint64
+	funcs     map[jsFunc]uint64
+	maps      sourceMaps
+}
+
+func newJSProfileBuilder() *jsProfileBuilder {
+	return &jsProfileBuilder{
+		strings:   []string{""},
+		stringMap: map[string]int{"": 0},
+		locs:      map[jsLocation]uint64{},
+		pcLocs:    map[uintptr]uint64{},
+		funcs:     map[jsFunc]uint64{},
+		maps:      sourceMaps{},
+	}
+}
+
+// writeCPUProfile converts the CPU profile recorded by the V8 profiler since
+// start, which sampled the stacks hz times per second, into the pprof format,
+// and writes it to w.
+//
+// The functions are reported with the names the compiler gives them, which are
+// the same as in upstream Go, and with the source positions from the source map
+// of the program. V8 only reports the lines the samples were taken at in the
+// innermost functions, so the positions of the callers are those of their
+// first statements.
+func writeCPUProfile(w io.Writer, profile *js.Object, start time.Time, hz int) error {
+	b := newJSProfileBuilder()
+	period := int64(time.Second) / int64(hz)
+	b.pbValueType(tagProfile_SampleType, "samples", "count")
+	b.pbValueType(tagProfile_SampleType, "cpu", "nanoseconds")
+
+	// The profile is a call tree, each node of which counts the samples taken
+	// in its function when it was called along the path from the root.
+	nodes := profile.Get("nodes")
+	parents := map[int]*js.Object{}
+	for i := 0; i < nodes.Length(); i++ {
+		node := nodes.Index(i)
+		if children := node.Get("children"); children != js.Undefined {
+			for j := 0; j < children.Length(); j++ {
+				parents[children.Index(j).Int()] = node
+			}
+		}
+	}
+	for i := 0; i < nodes.Length(); i++ {
+		node := nodes.Index(i)
+		hits := node.Get("hitCount").Int()
+		frame := node.Get("callFrame")
+		if name := frame.Get("functionName").String(); hits == 0 || name == "(root)" || name == "(idle)" {
+			continue
+		}
+
+		var callers []uint64
+		for p := parents[node.Get("id").Int()]; p != nil; p = parents[p.Get("id").Int()] {
+			caller := p.Get("callFrame")
+			if caller.Get("functionName").String() == "(root)" {
+				break
+			}
+			callers = append(callers, b.location(newJSLocation(caller)))
+		}
+
+		leaf := newJSLocation(frame)
+		ticks := node.Get("positionTicks")
+		if ticks == js.Undefined || ticks.Length() == 0 {
+			b.pbSample([]int64{int64(hits), int64(hits) * period}, append([]uint64{b.location(leaf)}, callers...))
+			continue
+		}
+		for j := 0; j < ticks.Length(); j++ {
+			tick := ticks.Index(j)
+			// The lines of the ticks are one-based, and their columns unknown.
+			leaf.line, leaf.col = tick.Get("line").Int()-1, 0
+			n := int64(tick.Get("ticks").Int())
+			b.pbSample([]int64{n, n * period}, append([]uint64{b.location(leaf)}, callers...))
+		}
+	}
+
+	duration := (profile.Get("endTime").Int64() - profile.Get("startTime").Int64()) * int64(time.Microsecond)
+	b.pb.int64Opt(tagProfile_TimeNanos, start.UnixNano())
+	b.pb.int64Opt(tagProfile_DurationNanos, duration)
+	b.pbValueType(tagProfile_PeriodType, "cpu", "nanoseconds")
+	b.pb.int64Opt(tagProfile_Period, period)
+	return b.build(w)
+}
+
+// newJSLocation returns the location of the start of the function of a frame
+// of the V8 profile.
+func newJSLocation(frame *js.Object) jsLocation {
+	line, col := frame.Get("lineNumber").Int(), frame.Get("columnNumber").Int()
+	return jsLocation{
+		function: frame.Get("functionName").String(),
+		url:      frame.Get("url").String(),
+		funcLine: line,
+		funcCol:  col,
+		line:     line,
+		col:      col,
+	}
+}
+
+func (b *jsProfileBuilder) stringIndex(s string) int64 {
+	id, ok := b.stringMap[s]
+	if !ok {
+		id = len(b.strings)
+		b.strings = append(b.strings, s)
+		b.stringMap[s] = id
+	}
+	return int64(id)
+}
+
+func (b *jsProfileBuilder) pbValueType(tag int, typ, unit string) {
+	start := b.pb.startMessage()
+	b.pb.int64(tagValueType_Type, b.stringIndex(typ))
+	b.pb.int64(tagValueType_Unit, b.stringIndex(unit))
+	b.pb.endMessage(tag, start)
+}
+
+func (b *jsProfileBuilder) pbSample(values []int64, locs []uint64) {
+	start := b.pb.startMessage()
+	b.pb.int64s(tagSample_Value, values)
+	b.pb.uint64s(tagSample_Location, locs)
+	b.pb.endMessage(tagProfile_Sample, start)
+}
+
+// location returns the ID of the location l, which is emitted along with its
+// function the first time it's used.
+func (b *jsProfileBuilder) location(l jsLocation) uint64 {
+	if id, ok := b.locs[l]; ok {
+		return id
+	}
+
+	var f jsFunc
+	var line int
+	if name, ok := v8Frames[l.function]; ok {
+		f.name = name
+	} else {
+		f.name = l.function
+		if f.name == "" {
+			f.name = "(anonymous)"
+		}
+		f.file, f.startLine = scriptPath(l.url), l.funcLine+1
+		line = l.line + 1
+		// The function header isn't mapped, but its first statement is.
+		if file, startLine, ok := b.maps.lookup(l.url, l.funcLine, l.funcCol, sourceMapLookahead); ok {
+			f.file, f.startLine, line = file, startLine, startLine
+		}
+		// The samples may be taken in the code of other functions inlined by
+		// V8, which is ignored.
+		if l.line != l.funcLine || l.col != l.funcCol {
+			if file, mapped, ok := b.maps.lookup(l.url, l.line, l.col, 1); ok && file == f.file {
+				line = mapped
+			}
+		}
+	}
+	id := b.emitLocation(f, line)
+	b.locs[l] = id
+	return id
+}
+
+// pcLocation returns the ID of the location of the program counter pc returned
+// by the runtime, which is emitted along with its function the first time it's
+// used.
+func (b *jsProfileBuilder) pcLocation(pc uintptr) uint64 {
+	if id, ok := b.pcLocs[pc]; ok {
+		return id
+	}
+	fn := runtime.FuncForPC(pc)
+	file, line := fn.FileLine(pc)
+	id := b.emitLocation(jsFunc{name: fn.Name(), file: file}, line)
+	b.pcLocs[pc] = id
+	return id
+}
+
+// emitLocation emits a new location at the line of the function f, along with
+// f the first time it's used, and returns the ID of the location.
+func (b *jsProfileBuilder) emitLocation(f jsFunc, line int) uint64 {
+	funcID, ok := b.funcs[f]
+	if !ok {
+		funcID = uint64(len(b.funcs) + 1)
+		b.funcs[f] = funcID
+		start := b.pb.startMessage()
+		b.pb.uint64Opt(tagFunction_ID, funcID)
+		b.pb.int64Opt(tagFunction_Name, b.stringIndex(f.name))
+		b.pb.int64Opt(tagFunction_SystemName, b.stringIndex(f.name))
+		b.pb.int64Opt(tagFunction_Filename, b.stringIndex(f.file))
+		b.pb.int64Opt(tagFunction_StartLine, int64(f.startLine))
+		b.pb.endMessage(tagProfile_Function, start)
+	}
+
+	// The locations have no real addresses, but tools expect them to be unique
+	// within the mapping of the program.
+	b.lastLocID++
+	id := b.lastLocID
+	start := b.pb.startMessage()
+	b.pb.uint64Opt(tagLocation_ID, id)
+	b.pb.uint64Opt(tagLocation_MappingID, 1)
+	b.pb.uint64Opt(tagLocation_Address, id)
+	lineStart := b.pb.startMessage()
+	b.pb.uint64Opt(tagLine_FunctionID, funcID)
+	b.pb.int64Opt(tagLine_Line, int64(line))
+	b.pb.endMessage(tagLocation_Line, lineStart)
+	b.pb.endMessage(tagProfile_Location, start)
+	return id
+}
+
+// build writes the profile to w, with the mapping of the program, which
+// already contains all the information about the functions.
+func (b *jsProfileBuilder) build(w io.Writer) error {
+	program := ""
+	if process := js.Global.Get("process"); process != js.Undefined {
+		program = process.Get("argv").Index(1).String()
+	}
+	start := b.pb.startMessage()
+	b.pb.uint64Opt(tagMapping_ID, 1)
+	b.pb.uint64Opt(tagMapping_Start, 1)
+	b.pb.uint64Opt(tagMapping_Limit, b.lastLocID+1)
+	b.pb.int64Opt(tagMapping_Filename, b.stringIndex(program))
+	b.pb.bool(tagMapping_HasFunctions, true)
+	b.pb.bool(tagMapping_HasFilenames, true)
+	b.pb.bool(tagMapping_HasLineNumbers, true)
+	b.pb.endMessage(tagProfile_Mapping, start)
+	b.pb.strings(tagProfile_StringTable, b.strings)
+
+	zw, _ := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	if _, err := zw.Write(b.pb.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,112 @@
+//go:build js
+// +build js
+
+package pprof
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var goroutineProfile = &Profile{
+	name:  "goroutine",
+	count: runtime.NumGoroutine,
+	write: writeGoroutine,
+}
+
+// writeGoroutine writes the stacks of the goroutines to w, grouped by stack in
+// the pprof format, or in the text format of the upstream profile if debug is
+// 1. If debug is 2 or more, the goroutines are listed one by one instead, in the
+// format of runtime.Stack.
+func writeGoroutine(w io.Writer, debug int) error {
+	if debug >= 2 {
+		return writeGoroutineStacks(w)
+	}
+
+	var records []runtime.StackRecord
+	for n, ok := runtime.GoroutineProfile(nil); !ok; {
+		records = make([]runtime.StackRecord, n+10)
+		n, ok = runtime.GoroutineProfile(records)
+		records = records[:n]
+	}
+
+	// Count the goroutines with the same stack, and sort the stacks by their
+	// counts like upstream Go.
+	count := map[string]int{}
+	stacks := map[string][]uintptr{}
+	var keys []string
+	for i := range records {
+		stk := records[i].Stack()
+		var b strings.Builder
+		b.WriteString("@")
+		for _, pc := range stk {
+			fmt.Fprintf(&b, " %#x", pc)
+		}
+		k := b.String()
+		if count[k] == 0 {
+			stacks[k] = stk
+			keys = append(keys, k)
+		}
+		count[k]++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ci, cj := count[keys[i]], count[keys[j]]; ci != cj {
+			return ci > cj
+		}
+		return keys[i] < keys[j]
+	})
+
+	if debug > 0 {
+		tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+		fmt.Fprintf(tw, "goroutine profile: total %d\n", len(records))
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%d %s\n", count[k], k)
+			frames := runtime.CallersFrames(stacks[k])
+			for {
+				frame, more := frames.Next()
+				fmt.Fprintf(tw, "#\t%#x\t%s+%#x\t%s:%d\n", frame.PC, frame.Function, frame.PC-frame.Entry, frame.File, frame.Line)
+				if !more {
+					break
+				}
+			}
+			fmt.Fprintf(tw, "\n")
+		}
+		return tw.Flush()
+	}
+
+	b := newJSProfileBuilder()
+	b.pbValueType(tagProfile_SampleType, "goroutine", "count")
+	for _, k := range keys {
+		var locs []uint64
+		for _, pc := range stacks[k] {
+			locs = append(locs, b.pcLocation(pc))
+		}
+		b.pbSample([]int64{int64(count[k])}, locs)
+	}
+	b.pbValueType(tagProfile_PeriodType, "goroutine", "count")
+	b.pb.int64Opt(tagProfile_Period, 1)
+	return b.build(w)
+}
+
+// writeGoroutineStacks writes the traces of all the goroutines to w, like the
+// upstream function of the same name.
+func writeGoroutineStacks(w io.Writer) error {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		if len(buf) >= 64<<20 {
+			break // Truncate the traces, like upstream Go.
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	_, err := w.Write(buf)
+	return err
+}
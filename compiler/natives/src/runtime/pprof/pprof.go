@@ -4,8 +4,12 @@
 package pprof
 
 import (
+	"errors"
 	"io"
 	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
 )
 
 type Profile struct {
@@ -17,15 +21,24 @@ type Profile struct {
 }
 
 func (p *Profile) WriteTo(w io.Writer, debug int) error {
+	if p.name == "" {
+		panic("pprof: use of zero Profile")
+	}
+	if p.write != nil {
+		return p.write(w, debug)
+	}
 	return nil
 }
 
 func (p *Profile) Count() int {
+	if p.count != nil {
+		return p.count()
+	}
 	return 0
 }
 
 func (p *Profile) Name() string {
-	return ""
+	return p.name
 }
 
 func (p *Profile) Add(value interface{}, skip int) {
@@ -34,17 +47,99 @@ func (p *Profile) Add(value interface{}, skip int) {
 func (p *Profile) Remove(value interface{}) {
 }
 
+// cpuProfileRate is the number of samples per second taken by the CPU profiler,
+// the same as in upstream Go.
+const cpuProfileRate = 100
+
+// cpuProfile is the state of the CPU profiler.
+var cpuProfile struct {
+	sync.Mutex
+	session *js.Object // Node.js inspector session running the V8 profiler.
+	w       io.Writer
+	start   time.Time
+}
+
+// StartCPUProfile enables CPU profiling for the current process. While
+// profiling, the profile is buffered by the JavaScript engine, and written to w
+// by StopCPUProfile. StartCPUProfile returns an error if profiling is already
+// enabled.
+//
+// GopherJS samples the stacks with the V8 profiler through the inspector
+// module, so CPU profiling is only supported in Node.js.
 func StartCPUProfile(w io.Writer) error {
+	cpuProfile.Lock()
+	defer cpuProfile.Unlock()
+	if cpuProfile.session != nil {
+		return errors.New("cpu profiling already in use")
+	}
+	require := js.Global.Get("require")
+	if require == js.Undefined {
+		return errors.New("cpu profiling is only supported in Node.js")
+	}
+	session := require.Invoke("inspector").Get("Session").New()
+	session.Call("connect")
+	for _, m := range []struct {
+		method string
+		params js.M
+	}{
+		{"Profiler.enable", js.M{}},
+		{"Profiler.setSamplingInterval", js.M{"interval": 1000000 / cpuProfileRate}},
+		{"Profiler.start", js.M{}},
+	} {
+		if _, err := inspectorPost(session, m.method, m.params); err != nil {
+			session.Call("disconnect")
+			return err
+		}
+	}
+	cpuProfile.session = session
+	cpuProfile.w = w
+	cpuProfile.start = time.Now()
 	return nil
 }
 
+// StopCPUProfile stops the current CPU profile, if any, and writes it. It only
+// returns after the profile is completely written.
 func StopCPUProfile() {
+	cpuProfile.Lock()
+	defer cpuProfile.Unlock()
+	if cpuProfile.session == nil {
+		return
+	}
+	session := cpuProfile.session
+	cpuProfile.session = nil
+	defer session.Call("disconnect")
+
+	result, err := inspectorPost(session, "Profiler.stop", js.M{})
+	if err == nil {
+		err = writeCPUProfile(cpuProfile.w, result.Get("profile"), cpuProfile.start, cpuProfileRate)
+	}
+	if err != nil {
+		panic("runtime/pprof: converting profile: " + err.Error())
+	}
+}
+
+// inspectorPost sends a message to the inspector session, and returns the
+// result. The session connected to the main thread dispatches the messages
+// synchronously.
+func inspectorPost(session *js.Object, method string, params js.M) (result *js.Object, err error) {
+	session.Call("post", method, params, func(e, r *js.Object) {
+		if e != nil {
+			err = &js.Error{Object: e}
+		}
+		result = r
+	})
+	return result, err
 }
 
 func WriteHeapProfile(w io.Writer) error {
 	return nil
 }
 
+// Lookup returns the profile with the given name, or nil if no such profile
+// exists. Only the goroutine profile is supported.
 func Lookup(name string) *Profile {
+	if name == goroutineProfile.name {
+		return goroutineProfile
+	}
 	return nil
 }
@@ -0,0 +1,165 @@
+//go:build js
+// +build js
+
+package pprof
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// sourceMapLookahead limits the number of lines searched for the first mapped
+// position after an unmapped one, such as a function header.
+const sourceMapLookahead = 16
+
+// sourceMaps caches the source maps of the scripts by their URLs, or nil if a
+// script has no source map.
+type sourceMaps map[string]*sourceMap
+
+// sourceMap maps the positions in a generated script to the Go sources.
+type sourceMap struct {
+	sources []string
+	lines   [][]sourceMapSegment // Segments of each generated line, by column.
+}
+
+// sourceMapSegment maps a generated column to a line in a source, if source
+// isn't -1.
+type sourceMapSegment struct {
+	column int
+	source int
+	line   int // Zero-based.
+}
+
+// lookup returns the source file and the one-based line of the first mapped
+// position at or after the zero-based line and column in the script with the
+// URL, searching at most the given number of lines. It's the position of the Go
+// statement the generated code at the position belongs to.
+func (m sourceMaps) lookup(url string, line, col, lines int) (string, int, bool) {
+	sm, ok := m[url]
+	if !ok {
+		sm = loadSourceMap(url)
+		m[url] = sm
+	}
+	if sm == nil {
+		return "", 0, false
+	}
+	for l := line; l >= 0 && l < len(sm.lines) && l < line+lines; l++ {
+		for _, s := range sm.lines[l] {
+			if s.source >= 0 && s.source < len(sm.sources) && (l > line || s.column >= col) {
+				return sm.sources[s.source], s.line + 1, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// loadSourceMap reads the source map referred to by the sourceMappingURL
+// comment at the end of the script with the URL, or returns nil if it can't be
+// read.
+func loadSourceMap(url string) (sm *sourceMap) {
+	if !strings.HasPrefix(url, "file://") {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			sm = nil // Invalid source map.
+		}
+	}()
+	path := scriptPath(url)
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	const comment = "\n//# sourceMappingURL="
+	i := bytes.LastIndex(script, []byte(comment))
+	if i < 0 {
+		return nil
+	}
+	ref := string(script[i+len(comment):])
+	if j := strings.IndexByte(ref, '\n'); j >= 0 {
+		ref = ref[:j]
+	}
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, ":") {
+		return nil // Not a file next to the script, e.g. a data URL.
+	}
+	if !strings.HasPrefix(ref, "/") {
+		ref = path[:strings.LastIndexAny(path, `/\`)+1] + ref
+	}
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return nil
+	}
+
+	parsed := js.Global.Get("JSON").Call("parse", string(data))
+	sources := parsed.Get("sources")
+	sm = &sourceMap{lines: decodeMappings(parsed.Get("mappings").String())}
+	for i := 0; i < sources.Length(); i++ {
+		sm.sources = append(sm.sources, sources.Index(i).String())
+	}
+	return sm
+}
+
+// scriptPath returns the path of the script with the URL, if it's a file URL,
+// or the URL otherwise.
+func scriptPath(url string) string {
+	if !strings.HasPrefix(url, "file://") {
+		return url
+	}
+	return js.Global.Get("require").Invoke("url").Call("fileURLToPath", url).String()
+}
+
+// decodeMappings decodes the mappings of a source map, which are encoded as
+// Base64 VLQ fields relative to the previous segment.
+func decodeMappings(mappings string) [][]sourceMapSegment {
+	var lines [][]sourceMapSegment
+	var source, line int
+	for _, l := range strings.Split(mappings, ";") {
+		var segments []sourceMapSegment
+		column := 0
+		for _, s := range strings.Split(l, ",") {
+			fields := decodeVLQ(s)
+			if len(fields) == 0 {
+				continue
+			}
+			column += fields[0]
+			segment := sourceMapSegment{column: column, source: -1}
+			if len(fields) >= 4 {
+				source += fields[1]
+				line += fields[2]
+				segment.source, segment.line = source, line
+			}
+			segments = append(segments, segment)
+		}
+		lines = append(lines, segments)
+	}
+	return lines
+}
+
+// decodeVLQ decodes the Base64 VLQ fields of a source map segment.
+func decodeVLQ(s string) []int {
+	const digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	var fields []int
+	value, shift := 0, 0
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(digits, s[i])
+		if d < 0 {
+			return nil
+		}
+		value += (d & 31) << shift
+		if d&32 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			fields = append(fields, -(value >> 1))
+		} else {
+			fields = append(fields, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	return fields
+}
@@ -102,9 +102,10 @@ var (
 	// Caller() and Callers() functions and assigning them arbitrary integer values.
 	//
 	// We use the map and the slice below to convert a "file:line" position
-	// into an integer position counter and then to a Func instance.
+	// into an integer position counter and then to a Func instance. The counter
+	// 0 is reserved, since it terminates the stacks in StackRecord.
 	knownPositions   = map[string]uintptr{}
-	positionCounters = []*Func{}
+	positionCounters = []*Func{nil}
 )
 
 func registerPosition(funcName string, file string, line int, col int) uintptr {
@@ -150,6 +151,9 @@ var (
 	// the stack trace.
 	hiddenFrames = map[string]bool{
 		"$callDeferred": true,
+		// Method wrappers, which call the method of the same name on another
+		// receiver type, are also hidden by the upstream Go runtime.
+		"$methodWrapper": true,
 	}
 	// The following GopherJS prelude functions have differently-named
 	// counterparts in the upstream Go runtime. Some standard library code relies
@@ -212,7 +216,7 @@ func ParseCallFrame(info *js.Object) basicFrame {
 	var file, funcName string
 	var line, col int
 
-	pos := info.Call("substring", openIdx+1, info.Call("indexOf", ")").Int())
+	pos := info.Call("substring", openIdx+1, info.Call("indexOf", ")", openIdx).Int())
 	parts := pos.Call("split", ":")
 
 	if pos.String() == "<anonymous>" {
@@ -223,8 +227,21 @@ func ParseCallFrame(info *js.Object) basicFrame {
 		col = parts.Index(parts.Length() - 1).Int()
 	}
 	fn := info.Call("substring", info.Call("indexOf", "at ").Int()+3, info.Call("indexOf", " (").Int())
-	if idx := fn.Call("indexOf", "[as ").Int(); idx > 0 {
-		fn = fn.Call("substring", idx+4, fn.Call("indexOf", "]"))
+	if idx := fn.Call("indexOf", " [as ").Int(); idx > 0 {
+		// The function was called as a property with a different name.
+		name := fn.Call("substring", 0, idx)
+		alias := fn.Call("substring", idx+5, fn.Call("indexOf", "]"))
+		internal := name.Call("substring", name.Call("lastIndexOf", ".").Int()+1)
+		switch {
+		case alias.Call("startsWith", "$").Bool():
+			// Resumed blocking functions are called as $blk, which tells nothing
+			// about the function itself.
+			fn = name
+		case hiddenFrames[internal.String()]:
+			fn = internal
+		default:
+			fn = alias
+		}
 	}
 	funcName = fn.String()
 
@@ -255,7 +272,8 @@ func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
 // The returned call stack represents the logical Go call stack, which excludes
 // certain runtime-internal call frames that would be present in the raw
 // JavaScript stack trace. This is done to improve interoperability with the
-// upstream Go. Use JavaScript native APIs to access the raw call stack.
+// upstream Go. Use JavaScript native APIs to access the raw call stack. In
+// minified builds, the frames have the JavaScript names of the functions.
 //
 // To translate these PCs into symbolic information such as function names and
 // line numbers, use CallersFrames. CallersFrames accounts for inlined functions
@@ -310,7 +328,14 @@ type Frame struct {
 	Entry    uintptr
 }
 
-func GC() {}
+// GC runs a garbage collection if the JavaScript environment exposes it, for
+// example Node.js started with the --expose-gc flag, and does nothing
+// otherwise.
+func GC() {
+	if gc := js.Global.Get("gc"); gc != js.Undefined {
+		gc.Invoke()
+	}
+}
 
 func Goexit() {
 	js.Global.Get("$curGoroutine").Set("exit", true)
@@ -381,14 +406,217 @@ type MemStats struct {
 	}
 }
 
+// ReadMemStats populates m with memory allocator statistics.
+//
+// The heap statistics come from the JavaScript environment, which doesn't
+// distinguish the memory of Go values: process.memoryUsage() in Node.js, and
+// performance.memory in browsers which support it. Browsers which support
+// performance.measureUserAgentSpecificMemory() also report the total memory of
+// the page from the latest completed measurement, which is started in the
+// background by ReadMemStats.
+//
+// Mallocs and TotalAlloc are counted by the code the compiler emits for new,
+// make, composite literals and append when the program is built with
+// --track_allocs, which gopherjs test does by default, and are left zero
+// otherwise. The garbage collector's statistics, Frees and HeapObjects aren't
+// available, and are left zero.
 func ReadMemStats(m *MemStats) {
-	// TODO(nevkontakte): This function is effectively unimplemented and may
-	// lead to silent unexpected behaviors. Consider panicing explicitly.
+	*m = MemStats{
+		Mallocs:    uint64(js.Global.Get("$mallocs").Int64()),
+		TotalAlloc: uint64(js.Global.Get("$totalAlloc").Int64()),
+	}
+
+	var heapAlloc, heapSys, sys float64
+	if process := js.Global.Get("process"); process != js.Undefined && process.Get("memoryUsage") != js.Undefined {
+		// ArrayBuffers, which back slices of numbers, are allocated outside of
+		// the V8 heap.
+		usage := process.Call("memoryUsage")
+		heapAlloc = usage.Get("heapUsed").Float() + usage.Get("arrayBuffers").Float()
+		heapSys = usage.Get("heapTotal").Float() + usage.Get("arrayBuffers").Float()
+		sys = usage.Get("rss").Float()
+	} else if performance := js.Global.Get("performance"); performance != js.Undefined {
+		if memory := performance.Get("memory"); memory != js.Undefined {
+			heapAlloc = memory.Get("usedJSHeapSize").Float()
+			heapSys = memory.Get("totalJSHeapSize").Float()
+		}
+		if measured := measureMemory(performance); measured > 0 {
+			if heapSys == 0 {
+				heapAlloc, heapSys = measured, measured
+			}
+			sys = measured
+		}
+	}
+	if sys < heapSys {
+		sys = heapSys
+	}
+
+	m.HeapAlloc = uint64(heapAlloc)
+	m.HeapSys = uint64(heapSys)
+	m.HeapInuse = m.HeapAlloc
+	m.HeapIdle = m.HeapSys - m.HeapInuse
+	m.Alloc = m.HeapAlloc
+	m.Sys = uint64(sys)
+	m.OtherSys = m.Sys - m.HeapSys
+}
+
+var (
+	measuringMemory bool    // Whether a measurement of the page's memory is in progress.
+	measuredMemory  float64 // Bytes reported by the latest completed measurement.
+)
+
+// measureMemory starts a measurement of the memory used by the page with
+// performance.measureUserAgentSpecificMemory(), unless one is in progress, and
+// returns the result of the latest one, or 0 if none has completed yet or the
+// API isn't available.
+func measureMemory(performance *js.Object) float64 {
+	// The API is only available to cross-origin isolated pages.
+	if performance.Get("measureUserAgentSpecificMemory") == js.Undefined || !js.Global.Get("crossOriginIsolated").Bool() {
+		return 0
+	}
+	if !measuringMemory {
+		measuringMemory = true
+		performance.Call("measureUserAgentSpecificMemory").Call("then", func(result *js.Object) {
+			measuredMemory = result.Get("bytes").Float()
+			measuringMemory = false
+		}, func(err *js.Object) {
+			measuringMemory = false
+		})
+	}
+	return measuredMemory
+}
+
+// finalizerCall is a call of a finalizer set by SetFinalizer, which is made
+// once the object it was set for is garbage-collected.
+type finalizerCall struct {
+	fn  func(*js.Object)
+	obj *js.Object // Pointer passed to fn, with the contents of the collected one.
 }
 
-func SetFinalizer(x, f interface{}) {
-	// TODO(nevkontakte): This function is effectively unimplemented and may
-	// lead to silent unexpected behaviors. Consider panicing explicitly.
+var (
+	finalizerRegistry *js.Object // FinalizationRegistry, which calls runFinalizer.
+	finalizerIDs      *js.Object // WeakMap of the objects with finalizers to their IDs.
+	finalizers        = map[int]*finalizerCall{}
+	lastFinalizerID   int
+)
+
+// SetFinalizer sets the finalizer associated with obj to the provided finalizer
+// function, which is called in a new goroutine once obj becomes unreachable.
+//
+// It relies on the FinalizationRegistry of the JavaScript environment, and the
+// finalizer is never run if it isn't available. A garbage-collected JavaScript
+// object can't be resurrected, so the finalizer is passed a new pointer to the
+// same contents: the fields of the struct obj points to are moved to another
+// object, which is kept for the finalizer, and obj only forwards the accesses
+// to them. For the same reason, finalizers can't be set on pointers to arrays.
+func SetFinalizer(obj interface{}, finalizer interface{}) {
+	if obj == nil {
+		throw("runtime.SetFinalizer: first argument is nil")
+	}
+	o := js.InternalObject(obj)
+	etyp := o.Get("constructor")
+	if etyp.Get("kind") != js.Global.Get("$kindPtr") {
+		throw("runtime.SetFinalizer: first argument is " + etyp.Get("string").String() + ", not pointer")
+	}
+	elem := etyp.Get("elem")
+	if elem.Get("kind") == js.Global.Get("$kindArray") {
+		throw("runtime.SetFinalizer: first argument is " + etyp.Get("string").String() + ", pointers to arrays are not supported by GopherJS")
+	}
+	if o == etyp.Get("nil") {
+		throw("runtime.SetFinalizer: pointer not in allocated block")
+	}
+
+	if finalizer == nil {
+		if finalizerRegistry == nil {
+			return // No finalizers were set.
+		}
+		if id := finalizerIDs.Call("get", o); id != js.Undefined {
+			finalizerRegistry.Call("unregister", o)
+			finalizerIDs.Call("delete", o)
+			delete(finalizers, id.Int())
+		}
+		return
+	}
+
+	f := js.InternalObject(finalizer)
+	ftyp := f.Get("constructor")
+	if ftyp.Get("kind") != js.Global.Get("$kindFunc") {
+		throw("runtime.SetFinalizer: second argument is " + ftyp.Get("string").String() + ", not a function")
+	}
+	if ftyp.Get("variadic").Bool() {
+		throw("runtime.SetFinalizer: cannot pass " + etyp.Get("string").String() + " to finalizer " + ftyp.Get("string").String() + " because dotdotdot")
+	}
+	params := ftyp.Get("params")
+	if params.Length() != 1 || !finalizerAccepts(params.Index(0), o) {
+		throw("runtime.SetFinalizer: cannot pass " + etyp.Get("string").String() + " to finalizer " + ftyp.Get("string").String())
+	}
+	if js.Global.Get("FinalizationRegistry") == js.Undefined {
+		return // Objects are never known to be unreachable, ignore the finalizer.
+	}
+	if finalizerRegistry == nil {
+		finalizerIDs = js.Global.Get("WeakMap").New()
+		finalizerRegistry = js.Global.Get("FinalizationRegistry").New(runFinalizer)
+	}
+	if finalizerIDs.Call("has", o).Bool() {
+		throw("runtime.SetFinalizer: finalizer already set")
+	}
+
+	var held *js.Object
+	if elem.Get("kind") == js.Global.Get("$kindStruct") {
+		held = js.Global.Get("Object").Call("create", etyp.Get("prototype"))
+		held.Set("$val", held)
+		fields := elem.Get("fields")
+		for i := 0; i < fields.Length(); i++ {
+			moveField(o, held, fields.Index(i).Get("prop").String())
+		}
+	} else {
+		held = etyp.New(o.Get("$get"), o.Get("$set"), o.Get("$target"))
+	}
+	call := &finalizerCall{obj: held}
+	js.InternalObject(call).Set("fn", f.Get("$val"))
+
+	lastFinalizerID++
+	finalizers[lastFinalizerID] = call
+	finalizerIDs.Call("set", o, lastFinalizerID)
+	finalizerRegistry.Call("register", o, lastFinalizerID, o)
+}
+
+// finalizerAccepts returns whether a finalizer with a parameter of the type
+// fint can be called with the pointer obj, following the same rules as the
+// upstream SetFinalizer.
+func finalizerAccepts(fint *js.Object, obj *js.Object) bool {
+	etyp := obj.Get("constructor")
+	switch {
+	case fint == etyp:
+		return true
+	case fint.Get("kind") == js.Global.Get("$kindPtr"):
+		return (!fint.Get("named").Bool() || !etyp.Get("named").Bool()) && fint.Get("elem") == etyp.Get("elem")
+	case fint.Get("kind") == js.Global.Get("$kindInterface"):
+		return js.Global.Call("$assertType", obj, fint, true).Index(1).Bool()
+	}
+	return false
+}
+
+// moveField moves the value of the field prop of the struct object o to the
+// object held, and makes o forward the accesses to the field to held.
+func moveField(o, held *js.Object, prop string) {
+	held.Set(prop, o.Get(prop))
+	js.Global.Get("Object").Call("defineProperty", o, prop, js.M{
+		"get":          func() *js.Object { return held.Get(prop) },
+		"set":          func(v *js.Object) { held.Set(prop, v) },
+		"enumerable":   true,
+		"configurable": true,
+	})
+}
+
+// runFinalizer is called by the FinalizationRegistry once the object with the
+// finalizer id was garbage-collected.
+func runFinalizer(id int) {
+	call, ok := finalizers[id]
+	if !ok {
+		return // The finalizer was removed.
+	}
+	delete(finalizers, id)
+	go call.fn(call.obj)
 }
 
 type Func struct {
@@ -442,14 +670,127 @@ func SetMutexProfileFraction(rate int) int {
 // number of bytes written to buf. If all is true, Stack formats stack traces of
 // all other goroutines into buf after the trace for the current goroutine.
 //
-// Unlike runtime.Callers(), it returns an unprocessed, runtime-specific text
-// representation of the JavaScript stack trace.
+// The stack traces are in the format of the upstream Go runtime, without the
+// arguments of the functions. The positions in the stack of the calling
+// goroutine are those of its JavaScript stack trace, and the other goroutines
+// are reported at the blocking calls they're suspended at, which are only known
+// in non-minified builds.
 func Stack(buf []byte, all bool) int {
-	s := js.Global.Get("Error").New().Get("stack")
-	if s == js.Undefined {
-		return 0
+	cur := js.Global.Get("$curGoroutine")
+	var frames []js.M
+	for _, frame := range goroutineFrames(callstack(1, maxStackDepth)) {
+		frames = append(frames, js.M{"name": frame.FuncName, "file": frame.File, "line": frame.Line})
+	}
+	s := js.Global.Call("$goroutineTrace", cur, frames).String()
+	if all {
+		for _, g := range liveGoroutines() {
+			if g.Get("id").Int() != cur.Get("id").Int() {
+				s += "\n" + js.Global.Call("$goroutineTrace", g).String()
+			}
+		}
+	}
+	return copy(buf, s)
+}
+
+// maxStackDepth limits the number of frames of the stack of the calling
+// goroutine in goroutine dumps.
+const maxStackDepth = 100
+
+// liveGoroutines returns the goroutines which haven't exited yet, ordered by
+// their IDs.
+func liveGoroutines() []*js.Object {
+	gs := js.Global.Get("Array").Call("from", js.Global.Get("$goroutines").Call("values"))
+	result := make([]*js.Object, gs.Length())
+	for i := range result {
+		result[i] = gs.Index(i)
+	}
+	return result
+}
+
+// blockedFrames returns the frames of the goroutine g, which isn't running, at
+// the blocking calls it's suspended at. They're empty if g hasn't started yet,
+// or if the program is minified.
+func blockedFrames(g *js.Object) []basicFrame {
+	fs := js.Global.Call("$blockedFrames", g)
+	frames := make([]basicFrame, fs.Length())
+	for i := range frames {
+		f := fs.Index(i)
+		frames[i] = basicFrame{FuncName: f.Get("name").String(), File: f.Get("file").String(), Line: f.Get("line").Int()}
+	}
+	return frames
+}
+
+// isGoFunc reports whether the function of a frame was compiled from Go, as
+// opposed to the prelude and other JavaScript functions, the names of which
+// aren't qualified by a package.
+func isGoFunc(name string) bool {
+	for i := len(name) - 1; i > 0; i-- {
+		if name[i] == '.' {
+			return i+1 < len(name) && name[i+1] != '$' && name[i+1] != '<'
+		}
+	}
+	return false
+}
+
+// goroutineFrames returns the frames of the Go functions in the stack of the
+// calling goroutine, which ends with the function the goroutine was started
+// with.
+func goroutineFrames(stack []basicFrame) []basicFrame {
+	var frames []basicFrame
+	for _, frame := range stack {
+		if frame.FuncName == "runtime.goexit" {
+			break
+		}
+		if isGoFunc(frame.FuncName) {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+// A StackRecord describes a single execution stack.
+type StackRecord struct {
+	Stack0 [32]uintptr // stack trace for this record; ends at first 0 entry
+}
+
+// Stack returns the stack trace associated with the record,
+// a prefix of r.Stack0.
+func (r *StackRecord) Stack() []uintptr {
+	for i, v := range r.Stack0 {
+		if v == 0 {
+			return r.Stack0[0:i]
+		}
+	}
+	return r.Stack0[0:]
+}
+
+// GoroutineProfile returns n, the number of records in the active goroutine
+// stack profile. If len(p) >= n, GoroutineProfile copies the profile into p
+// and returns n, true. If len(p) < n, GoroutineProfile does not change p and
+// returns n, false.
+//
+// The goroutines other than the current one are reported at the blocking calls
+// they're suspended at, like in the traces of Stack.
+func GoroutineProfile(p []StackRecord) (n int, ok bool) {
+	gs := liveGoroutines()
+	if len(p) < len(gs) {
+		return len(gs), false
+	}
+	cur := js.Global.Get("$curGoroutine").Get("id").Int()
+	for i, g := range gs {
+		frames := goroutineFrames(callstack(1, len(p[i].Stack0)))
+		if g.Get("id").Int() != cur {
+			frames = blockedFrames(g)
+		}
+		p[i] = StackRecord{}
+		for j, frame := range frames {
+			if j == len(p[i].Stack0) {
+				break
+			}
+			p[i].Stack0[j] = registerPosition(frame.FuncName, frame.File, frame.Line, frame.Col)
+		}
 	}
-	return copy(buf, s.Call("substr", s.Call("indexOf", "\n").Int()+1).String())
+	return len(gs), true
 }
 
 func LockOSThread() {}
@@ -33,6 +33,7 @@ func runtime_SemacquireMutex(s *uint32, lifo bool, skipframes int) {
 		} else {
 			semWaiters[s] = append(semWaiters[s], ch)
 		}
+		js.Global.Get("$curGoroutine").Set("waitReason", "semacquire")
 		<-ch
 		semAwoken[s] -= 1
 		if semAwoken[s] == 0 {
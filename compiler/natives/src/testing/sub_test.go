@@ -3,10 +3,6 @@
 
 package testing
 
-func TestBenchmarkReadMemStatsBeforeFirstRun(t *T) {
-	t.Skip("runtime.ReadMemStats() is not supported by GopherJS.")
-}
-
 func TestTRun(t *T) {
 	// TODO(nevkontakte): This test performs string comparisons expecting to find
 	// sub_test.go in the output, but GopherJS currently reports caller
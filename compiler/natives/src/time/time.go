@@ -35,6 +35,7 @@ func now() (sec int64, nsec int32, mono int64) {
 func Sleep(d Duration) {
 	c := make(chan struct{})
 	js.Global.Call("$setTimeout", js.InternalObject(func() { close(c) }), int(d/Millisecond))
+	js.Global.Get("$curGoroutine").Set("waitReason", "sleep")
 	<-c
 }
 
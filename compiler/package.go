@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"go/ast"
 	"go/constant"
-	"go/scanner"
 	"go/token"
 	"go/types"
 	"sort"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/gopherjs/gopherjs/compiler/analysis"
 	"github.com/gopherjs/gopherjs/compiler/astutil"
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
 	"github.com/neelance/astrewrite"
 	"golang.org/x/tools/go/gcexportdata"
 	"golang.org/x/tools/go/types/typeutil"
@@ -31,12 +31,17 @@ type pkgContext struct {
 	varPtrNames  map[*types.Var]string
 	anonTypes    []*types.TypeName
 	anonTypeMap  typeutil.Map
+	instances    []*instance
+	instanceMap  map[types.Object]*typeutil.Map
+	generic      *genericCtx
 	escapingVars map[*types.Var]bool
 	indentation  int
 	dependencies map[types.Object]bool
 	minify       bool
 	fileSet      *token.FileSet
 	errList      ErrorList
+	// Whether to count heap allocations, see CompileOptions.TrackAllocations.
+	trackAllocations bool
 }
 
 func (p *pkgContext) SelectionOf(e *ast.SelectorExpr) (selection, bool) {
@@ -87,6 +92,19 @@ type funcContext struct {
 	delayedOutput []byte
 	posAvailable  bool
 	pos           token.Pos
+	funcName      string // Go name of the function, reported in stack traces.
+	funcLitCount  int    // Number of function literals in the function so far.
+	// Blocking calls of the function, which are reported in goroutine dumps.
+	// They're only recorded in non-minified builds.
+	resumePositions []resumePosition
+}
+
+// resumePosition is a blocking call, after which a function is resumed at the
+// case resumeCase of its body, with the pending call saved in returnVar.
+type resumePosition struct {
+	resumeCase int
+	pos        token.Pos
+	returnVar  string
 }
 
 type flowData struct {
@@ -123,7 +141,25 @@ func (pi packageImporter) Import(path string) (*types.Package, error) {
 	return pi.importContext.Packages[a.ImportPath], nil
 }
 
-func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, importContext *ImportContext, minify bool) (_ *Archive, err error) {
+// CompileOptions control the code generated by Compile, and its optional
+// outputs.
+type CompileOptions struct {
+	Minify bool
+	// Generate the JavaScript API of the package for BuildModeLibrary, unless
+	// it's a main package.
+	Library bool
+	// Collect the TypeScript types of the package's exports, which are written
+	// by WriteTypeScriptDeclarations.
+	Declarations bool
+	// Emit code counting heap allocations made by new, make, composite literals
+	// and append, which are reported by runtime.ReadMemStats and
+	// testing.AllocsPerRun. The counters are left zero otherwise.
+	TrackAllocations bool
+}
+
+func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, importContext *ImportContext, options CompileOptions) (_ *Archive, err error) {
+	minify := options.Minify
+
 	defer func() {
 		e := recover()
 		if e == nil {
@@ -150,6 +186,7 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		Implicits:  make(map[ast.Node]types.Object),
 		Selections: make(map[*ast.SelectorExpr]*types.Selection),
 		Scopes:     make(map[ast.Node]*types.Scope),
+		Instances:  make(map[*ast.Ident]types.Instance),
 	}
 
 	var errList ErrorList
@@ -159,11 +196,7 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 	for _, file := range files {
 		found, err := parseGoLinknames(fileSet, importPath, file)
 		if err != nil {
-			if errs, ok := err.(ErrorList); ok {
-				errList = append(errList, errs...)
-			} else {
-				errList = append(errList, err)
-			}
+			errList = append(errList, diagnose(ErrorList{err}, importPath, PhaseLinkname, fileSet, files)...)
 		}
 		goLinknames = append(goLinknames, found...)
 	}
@@ -180,7 +213,7 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 			if previousErr != nil && previousErr.Error() == err.Error() {
 				return
 			}
-			errList = append(errList, err)
+			errList = append(errList, diagnose(ErrorList{err}, importPath, PhaseTypeCheck, fileSet, files)...)
 			previousErr = err
 		},
 	}
@@ -189,13 +222,6 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		return nil, importError
 	}
 	if errList != nil {
-		if len(errList) > 10 {
-			pos := token.NoPos
-			if last, ok := errList[9].(types.Error); ok {
-				pos = last.Pos
-			}
-			errList = append(errList[:10], types.Error{Fset: fileSet, Pos: pos, Msg: "too many errors"})
-		}
 		return nil, errList
 	}
 	if err != nil {
@@ -203,6 +229,11 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 	}
 	importContext.Packages[importPath] = typesPkg
 
+	embeds, err := parseGoEmbeds(fileSet, files, typesInfo)
+	if err != nil {
+		return nil, diagnose(ErrorList{err}, importPath, PhaseCompile, fileSet, files)
+	}
+
 	exportData := new(bytes.Buffer)
 	if err := gcexportdata.Write(exportData, nil, typesPkg); err != nil {
 		return nil, fmt.Errorf("failed to write export data: %v", err)
@@ -240,16 +271,20 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 			pkgVars:      make(map[string]string),
 			objectNames:  make(map[types.Object]string),
 			varPtrNames:  make(map[*types.Var]string),
+			instanceMap:  make(map[types.Object]*typeutil.Map),
 			escapingVars: make(map[*types.Var]bool),
 			indentation:  1,
 			dependencies: make(map[types.Object]bool),
 			minify:       minify,
 			fileSet:      fileSet,
+
+			trackAllocations: options.TrackAllocations,
 		},
 		allVars:     make(map[string]int),
 		flowDatas:   map[*types.Label]*flowData{nil: {}},
 		caseCounter: 1,
 		labelCases:  make(map[*types.Label]int),
+		funcName:    goPkgPath(typesPkg) + ".glob.",
 	}
 	for name := range reservedKeywords {
 		funcCtx.allVars[name] = 1
@@ -357,7 +392,11 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		if funcCtx.pkgCtx.HasPointer[o] && !o.Exported() {
 			d.Vars = append(d.Vars, funcCtx.varPtrName(o))
 		}
-		if _, ok := varsWithInit[o]; !ok {
+		if embed, ok := embeds[o]; ok {
+			d.DceDeps = collectDependencies(func() {
+				d.InitCode = []byte(fmt.Sprintf("\t\t%s = %s;\n", funcCtx.objectName(o), funcCtx.translateEmbed(o, embed)))
+			})
+		} else if _, ok := varsWithInit[o]; !ok {
 			d.DceDeps = collectDependencies(func() {
 				d.InitCode = []byte(fmt.Sprintf("\t\t%s = %s;\n", funcCtx.objectName(o), funcCtx.translateExpr(funcCtx.zeroValue(o.Type())).String()))
 			})
@@ -399,13 +438,6 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 	var mainFunc *types.Func
 	for _, fun := range functions {
 		o := funcCtx.pkgCtx.Defs[fun.Name].(*types.Func)
-
-		if fun.Type.TypeParams.NumFields() > 0 {
-			return nil, scanner.Error{
-				Pos: fileSet.Position(fun.Type.TypeParams.Pos()),
-				Msg: fmt.Sprintf("function %s: type parameters are not supported by GopherJS: https://github.com/gopherjs/gopherjs/issues/1013", o.Name()),
-			}
-		}
 		funcInfo := funcCtx.pkgCtx.FuncDeclInfos[o]
 		d := Decl{
 			FullName: o.FullName(),
@@ -457,10 +489,13 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		id := funcCtx.newIdent("", types.NewSignature(nil, nil, nil, false))
 		funcCtx.pkgCtx.Uses[id] = mainFunc
 		call := &ast.CallExpr{Fun: id}
+		// Let the program loader know that the packages are initialized.
+		mainStarted := &ast.CallExpr{Fun: funcCtx.newIdent("$mainStarted", types.NewSignature(nil, nil, nil, false))}
 		ifStmt := &ast.IfStmt{
 			Cond: funcCtx.newIdent("$pkg === $mainPkg", types.Typ[types.Bool]),
 			Body: &ast.BlockStmt{
 				List: []ast.Stmt{
+					&ast.ExprStmt{X: mainStarted},
 					&ast.ExprStmt{X: call},
 					&ast.AssignStmt{
 						Lhs: []ast.Expr{funcCtx.newIdent("$mainFinished", types.Typ[types.Bool])},
@@ -481,6 +516,20 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		})
 	}
 
+	// JavaScript API of the package in the library build mode
+	var ts *tsTypes
+	if options.Declarations {
+		ts = newTSTypes(typesPkg)
+	}
+	var library *Library
+	if options.Library && typesPkg.Name() != "main" {
+		deps := collectDependencies(func() {
+			library = funcCtx.translateLibrary(typesPkg, ts)
+		})
+		library.Decl.DceDeps = append(deps, jsPkgPath+".MakeFullWrapper")
+		library.Decl.InitCode = removeWhitespace(library.Decl.InitCode, minify)
+	}
+
 	// named types
 	var typeDecls []*Decl
 	for _, o := range funcCtx.pkgCtx.typeNames {
@@ -489,78 +538,29 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		}
 		typeName := funcCtx.objectName(o)
 
-		if named, ok := o.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
-			return nil, scanner.Error{
-				Pos: fileSet.Position(o.Pos()),
-				Msg: fmt.Sprintf("type %s: type parameters are not supported by GopherJS: https://github.com/gopherjs/gopherjs/issues/1013", o.Name()),
-			}
-		}
-
 		d := Decl{
 			Vars:            []string{typeName},
 			DceObjectFilter: o.Name(),
 		}
 		d.DceDeps = collectDependencies(func() {
+			named := o.Type().(*types.Named)
+			lhs := typeName
+			if isPkgLevel(o) {
+				lhs += " = $pkg." + encodeIdent(o.Name())
+			}
+
+			if named.TypeParams().Len() > 0 {
+				d.DeclCode = funcCtx.CatchOutput(0, func() {
+					funcCtx.Printf("%s = $genericType(%s);", lhs, funcCtx.translateGenericType(o))
+				})
+				return
+			}
+
 			d.DeclCode = funcCtx.CatchOutput(0, func() {
-				typeName := funcCtx.objectName(o)
-				lhs := typeName
-				if isPkgLevel(o) {
-					lhs += " = $pkg." + encodeIdent(o.Name())
-				}
-				size := int64(0)
-				constructor := "null"
-				switch t := o.Type().Underlying().(type) {
-				case *types.Struct:
-					params := make([]string, t.NumFields())
-					for i := 0; i < t.NumFields(); i++ {
-						params[i] = fieldName(t, i) + "_"
-					}
-					constructor = fmt.Sprintf("function(%s) {\n\t\tthis.$val = this;\n\t\tif (arguments.length === 0) {\n", strings.Join(params, ", "))
-					for i := 0; i < t.NumFields(); i++ {
-						constructor += fmt.Sprintf("\t\t\tthis.%s = %s;\n", fieldName(t, i), funcCtx.translateExpr(funcCtx.zeroValue(t.Field(i).Type())).String())
-					}
-					constructor += "\t\t\treturn;\n\t\t}\n"
-					for i := 0; i < t.NumFields(); i++ {
-						constructor += fmt.Sprintf("\t\tthis.%[1]s = %[1]s_;\n", fieldName(t, i))
-					}
-					constructor += "\t}"
-				case *types.Basic, *types.Array, *types.Slice, *types.Chan, *types.Signature, *types.Interface, *types.Pointer, *types.Map:
-					size = sizes32.Sizeof(t)
-				}
-				if tPointer, ok := o.Type().Underlying().(*types.Pointer); ok {
-					if _, ok := tPointer.Elem().Underlying().(*types.Array); ok {
-						// Array pointers have non-default constructors to support wrapping
-						// of the native objects.
-						constructor = "$arrayPtrCtor()"
-					}
-				}
-				funcCtx.Printf(`%s = $newType(%d, %s, "%s.%s", %t, "%s", %t, %s);`, lhs, size, typeKind(o.Type()), o.Pkg().Name(), o.Name(), o.Name() != "", o.Pkg().Path(), o.Exported(), constructor)
+				funcCtx.Printf(`%s = %s;`, lhs, funcCtx.newTypeCall(o, fmt.Sprintf(`"%s.%s"`, o.Pkg().Name(), o.Name())))
 			})
 			d.MethodListCode = funcCtx.CatchOutput(0, func() {
-				named := o.Type().(*types.Named)
-				if _, ok := named.Underlying().(*types.Interface); ok {
-					return
-				}
-				var methods []string
-				var ptrMethods []string
-				for i := 0; i < named.NumMethods(); i++ {
-					method := named.Method(i)
-					name := method.Name()
-					if reservedKeywords[name] {
-						name += "$"
-					}
-					pkgPath := ""
-					if !method.Exported() {
-						pkgPath = method.Pkg().Path()
-					}
-					t := method.Type().(*types.Signature)
-					entry := fmt.Sprintf(`{prop: "%s", name: %s, pkg: "%s", typ: $funcType(%s)}`, name, encodeString(method.Name()), pkgPath, funcCtx.initArgs(t))
-					if _, isPtr := t.Recv().Type().(*types.Pointer); isPtr {
-						ptrMethods = append(ptrMethods, entry)
-						continue
-					}
-					methods = append(methods, entry)
-				}
+				methods, ptrMethods := funcCtx.methodListEntries(named)
 				if len(methods) > 0 {
 					funcCtx.Printf("%s.methods = [%s];", funcCtx.typeName(named), strings.Join(methods, ", "))
 				}
@@ -590,6 +590,21 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		typeDecls = append(typeDecls, &d)
 	}
 
+	// instances of generic functions and types
+	for i := 0; i < len(funcCtx.pkgCtx.instances); i++ {
+		inst := funcCtx.pkgCtx.instances[i]
+		d := Decl{
+			Vars:            []string{inst.obj.Name()},
+			DceObjectFilter: inst.obj.Name(),
+		}
+		d.DceDeps = collectDependencies(func() {
+			for o := range inst.deps {
+				funcCtx.pkgCtx.dependencies[o] = true
+			}
+		})
+		typeDecls = append(typeDecls, &d)
+	}
+
 	var allDecls []*Decl
 	for _, d := range append(append(append(importDecls, typeDecls...), varDecls...), funcDecls...) {
 		d.DeclCode = removeWhitespace(d.DeclCode, minify)
@@ -600,7 +615,7 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 	}
 
 	if len(funcCtx.pkgCtx.errList) != 0 {
-		return nil, funcCtx.pkgCtx.errList
+		return nil, diagnose(funcCtx.pkgCtx.errList, importPath, PhaseCompile, fileSet, files)
 	}
 
 	return &Archive{
@@ -613,9 +628,113 @@ func Compile(importPath string, files []*ast.File, fileSet *token.FileSet, impor
 		Minified:     minify,
 		GoLinknames:  goLinknames,
 		BuildTime:    time.Now(),
+
+		TrackedAllocations: options.TrackAllocations,
+
+		ModuleExports:    collectModuleExports(files, typesInfo, ts),
+		TypeDeclarations: ts.declarations(),
+		Library:          library,
 	}, nil
 }
 
+// newTypeCall returns a $newType() call, which creates a named type o. The
+// type string is given as a JavaScript expression, since for instances of
+// generic types it is computed at run time.
+func (fc *funcContext) newTypeCall(o *types.TypeName, typeString string) string {
+	size := int64(0)
+	constructor := "null"
+	switch t := o.Type().Underlying().(type) {
+	case *types.Struct:
+		params := make([]string, t.NumFields())
+		for i := 0; i < t.NumFields(); i++ {
+			params[i] = fieldName(t, i) + "_"
+		}
+		constructor = fmt.Sprintf("function(%s) {\n\t\tthis.$val = this;\n\t\tif (arguments.length === 0) {\n", strings.Join(params, ", "))
+		for i := 0; i < t.NumFields(); i++ {
+			constructor += fmt.Sprintf("\t\t\tthis.%s = %s;\n", fieldName(t, i), fc.translateExpr(fc.zeroValue(t.Field(i).Type())).String())
+		}
+		constructor += "\t\t\treturn;\n\t\t}\n"
+		for i := 0; i < t.NumFields(); i++ {
+			constructor += fmt.Sprintf("\t\tthis.%[1]s = %[1]s_;\n", fieldName(t, i))
+		}
+		constructor += "\t}"
+	case *types.Basic, *types.Array, *types.Slice, *types.Chan, *types.Signature, *types.Interface, *types.Pointer, *types.Map:
+		if !typesutil.IsGeneric(t) {
+			size = sizes32.Sizeof(t)
+		}
+	}
+	if tPointer, ok := o.Type().Underlying().(*types.Pointer); ok {
+		if _, ok := tPointer.Elem().Underlying().(*types.Array); ok {
+			// Array pointers have non-default constructors to support wrapping
+			// of the native objects.
+			constructor = "$arrayPtrCtor()"
+		}
+	}
+	return fmt.Sprintf(`$newType(%d, %s, %s, %t, "%s", %t, %s)`, size, typeKind(o.Type()), typeString, o.Name() != "", o.Pkg().Path(), o.Exported(), constructor)
+}
+
+// methodListEntries returns reflection metadata for methods of a named type
+// with value and pointer receivers respectively.
+func (fc *funcContext) methodListEntries(named *types.Named) (methods []string, ptrMethods []string) {
+	if _, ok := named.Underlying().(*types.Interface); ok {
+		return nil, nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		method := named.Method(i)
+		name := method.Name()
+		if reservedKeywords[name] {
+			name += "$"
+		}
+		pkgPath := ""
+		if !method.Exported() {
+			pkgPath = method.Pkg().Path()
+		}
+		t := method.Type().(*types.Signature)
+		entry := fmt.Sprintf(`{prop: "%s", name: %s, pkg: "%s", typ: $funcType(%s)}`, name, encodeString(method.Name()), pkgPath, fc.initArgs(t))
+		if _, isPtr := t.Recv().Type().(*types.Pointer); isPtr {
+			ptrMethods = append(ptrMethods, entry)
+			continue
+		}
+		methods = append(methods, entry)
+	}
+	return methods, ptrMethods
+}
+
+// translateGenericType returns a factory function for the generic type o. The
+// factory returns the new type along with a function that completes its
+// initialization. Methods are added to the instances separately, see
+// translateToplevelFunction.
+func (fc *funcContext) translateGenericType(o *types.TypeName) string {
+	named := o.Type().(*types.Named)
+	var aliases []*types.TypeParamList
+	for i := 0; i < named.NumMethods(); i++ {
+		aliases = append(aliases, named.Method(i).Type().(*types.Signature).RecvTypeParams())
+	}
+	return fc.translateGenericFactory(named.TypeParams(), aliases, func(factory *funcContext) {
+		params := make([]string, named.TypeParams().Len())
+		for i := range params {
+			params[i] = factory.typeName(named.TypeParams().At(i))
+		}
+		typeString := fmt.Sprintf(`"%s.%s[" + $typeArgsString([%s]) + "]"`, o.Pkg().Name(), o.Name(), strings.Join(params, ", "))
+		factory.Printf("var $typ = %s;", factory.newTypeCall(o, typeString))
+		factory.Printf("return [$typ, function() {")
+		factory.Indent(func() {
+			methods, ptrMethods := factory.methodListEntries(named)
+			if len(methods) > 0 {
+				factory.Printf("$typ.methods = [%s];", strings.Join(methods, ", "))
+			}
+			if len(ptrMethods) > 0 {
+				factory.Printf("$ptrType($typ).methods = [%s];", strings.Join(ptrMethods, ", "))
+			}
+			switch t := named.Underlying().(type) {
+			case *types.Array, *types.Chan, *types.Interface, *types.Map, *types.Pointer, *types.Slice, *types.Signature, *types.Struct:
+				factory.Printf("$typ.init(%s);", factory.initArgs(t))
+			}
+		})
+		factory.Printf("}];")
+	})
+}
+
 func (fc *funcContext) initArgs(ty types.Type) string {
 	switch t := ty.(type) {
 	case *types.Array:
@@ -675,21 +794,33 @@ func (fc *funcContext) translateToplevelFunction(fun *ast.FuncDecl, info *analys
 	}
 
 	var joinedParams string
-	primaryFunction := func(funcRef string) []byte {
+	functionExpr := func(fc *funcContext, funcRef string) string {
 		if fun.Body == nil {
-			return []byte(fmt.Sprintf("\t%s = function() {\n\t\t$throwRuntimeError(\"native function not implemented: %s\");\n\t};\n", funcRef, o.FullName()))
+			return fmt.Sprintf("function() {\n\t\t$throwRuntimeError(\"native function not implemented: %s\");\n\t}", o.FullName())
 		}
 
-		params, fun := translateFunction(fun.Type, recv, fun.Body, fc, sig, info, funcRef)
+		params, fun := translateFunction(fun.Type, recv, fun.Body, fc, sig, info, funcRef, goFuncName(o))
 		joinedParams = strings.Join(params, ", ")
-		return []byte(fmt.Sprintf("\t%s = %s;\n", funcRef, fun))
+		return fun
+	}
+	primaryFunction := func(funcRef string) []byte {
+		return []byte(fmt.Sprintf("\t%s = %s;\n", funcRef, functionExpr(fc, funcRef)))
 	}
 
 	code := bytes.NewBuffer(nil)
 
 	if fun.Recv == nil {
 		funcRef := fc.objectName(o)
-		code.Write(primaryFunction(funcRef))
+		if sig.TypeParams().Len() > 0 {
+			// Generic function instances are returned by a factory, so there is no
+			// stable reference to them and they need a name to be resumable.
+			factory := fc.translateGenericFactory(sig.TypeParams(), nil, func(factory *funcContext) {
+				factory.Printf("return %s;", functionExpr(factory, ""))
+			})
+			fmt.Fprintf(code, "\t%s = $genericFunc(%s);\n", funcRef, factory)
+		} else {
+			code.Write(primaryFunction(funcRef))
+		}
 		if fun.Name.IsExported() {
 			fmt.Fprintf(code, "\t$pkg.%s = %s;\n", encodeIdent(fun.Name.Name), funcRef)
 		}
@@ -702,7 +833,35 @@ func (fc *funcContext) translateToplevelFunction(fun *ast.FuncDecl, info *analys
 	if isPointer {
 		namedRecvType = ptr.Elem().(*types.Named)
 	}
-	typeName := fc.objectName(namedRecvType.Obj())
+
+	if tparams := sig.RecvTypeParams(); tparams.Len() > 0 {
+		// Methods of a generic type are installed into each of its instances by
+		// a function, which receives instance's type arguments.
+		installer := fc.translateGenericFactory(tparams, nil, func(factory *funcContext) {
+			factory.Printf("var $typ = %s;", factory.typeName(namedRecvType))
+			factory.Write(factory.translateMethod(fun, o, "$typ", func(funcRef string) []byte {
+				return []byte(fmt.Sprintf("%s%s = %s;\n", strings.Repeat("\t", factory.pkgCtx.indentation), funcRef, functionExpr(factory, funcRef)))
+			}, &joinedParams))
+		})
+		return []byte(fmt.Sprintf("\t%s.addMethods(%s);\n", fc.objectName(namedRecvType.Obj()), installer))
+	}
+
+	return fc.translateMethod(fun, o, fc.objectName(namedRecvType.Obj()), primaryFunction, &joinedParams)
+}
+
+// translateMethod returns code that defines method o of the named type, which
+// is referred to as typeName, and wrappers for the method on other receiver
+// types as necessary. The method itself is defined by primaryFunction.
+func (fc *funcContext) translateMethod(fun *ast.FuncDecl, o *types.Func, typeName string, primaryFunction func(funcRef string) []byte, joinedParams *string) []byte {
+	code := bytes.NewBuffer(nil)
+	indent := strings.Repeat("\t", fc.pkgCtx.indentation)
+
+	recvType := o.Type().(*types.Signature).Recv().Type()
+	ptr, isPointer := recvType.(*types.Pointer)
+	namedRecvType, _ := recvType.(*types.Named)
+	if isPointer {
+		namedRecvType = ptr.Elem().(*types.Named)
+	}
 	funName := fun.Name.Name
 	if reservedKeywords[funName] {
 		funName += "$"
@@ -710,14 +869,14 @@ func (fc *funcContext) translateToplevelFunction(fun *ast.FuncDecl, info *analys
 
 	if _, isStruct := namedRecvType.Underlying().(*types.Struct); isStruct {
 		code.Write(primaryFunction(typeName + ".ptr.prototype." + funName))
-		fmt.Fprintf(code, "\t%s.prototype.%s = function(%s) { return this.$val.%s(%s); };\n", typeName, funName, joinedParams, funName, joinedParams)
+		fmt.Fprintf(code, "%s%s.prototype.%s = $methodWrapper(function(%s) { return this.$val.%s(%s); });\n", indent, typeName, funName, *joinedParams, funName, *joinedParams)
 		return code.Bytes()
 	}
 
 	if isPointer {
 		if _, isArray := ptr.Elem().Underlying().(*types.Array); isArray {
 			code.Write(primaryFunction(typeName + ".prototype." + funName))
-			fmt.Fprintf(code, "\t$ptrType(%s).prototype.%s = function(%s) { return (new %s(this.$get())).%s(%s); };\n", typeName, funName, joinedParams, typeName, funName, joinedParams)
+			fmt.Fprintf(code, "%s$ptrType(%s).prototype.%s = $methodWrapper(function(%s) { return (new %s(this.$get())).%s(%s); });\n", indent, typeName, funName, *joinedParams, typeName, funName, *joinedParams)
 			return code.Bytes()
 		}
 		return primaryFunction(fmt.Sprintf("$ptrType(%s).prototype.%s", typeName, funName))
@@ -728,11 +887,11 @@ func (fc *funcContext) translateToplevelFunction(fun *ast.FuncDecl, info *analys
 		value = fmt.Sprintf("new %s(%s)", typeName, value)
 	}
 	code.Write(primaryFunction(typeName + ".prototype." + funName))
-	fmt.Fprintf(code, "\t$ptrType(%s).prototype.%s = function(%s) { return %s.%s(%s); };\n", typeName, funName, joinedParams, value, funName, joinedParams)
+	fmt.Fprintf(code, "%s$ptrType(%s).prototype.%s = $methodWrapper(function(%s) { return %s.%s(%s); });\n", indent, typeName, funName, *joinedParams, value, funName, *joinedParams)
 	return code.Bytes()
 }
 
-func translateFunction(typ *ast.FuncType, recv *ast.Ident, body *ast.BlockStmt, outerContext *funcContext, sig *types.Signature, info *analysis.FuncInfo, funcRef string) ([]string, string) {
+func translateFunction(typ *ast.FuncType, recv *ast.Ident, body *ast.BlockStmt, outerContext *funcContext, sig *types.Signature, info *analysis.FuncInfo, funcRef string, funcName string) ([]string, string) {
 	if info == nil {
 		panic("nil info")
 	}
@@ -747,6 +906,7 @@ func translateFunction(typ *ast.FuncType, recv *ast.Ident, body *ast.BlockStmt,
 		flowDatas:   map[*types.Label]*flowData{nil: {}},
 		caseCounter: 1,
 		labelCases:  make(map[*types.Label]int),
+		funcName:    funcName,
 	}
 	for k, v := range outerContext.allVars {
 		c.allVars[k] = v
@@ -833,7 +993,7 @@ func translateFunction(typ *ast.FuncType, recv *ast.Ident, body *ast.BlockStmt,
 		// If a blocking function is being resumed, initialize local variables from the saved context.
 		localVarDefs = fmt.Sprintf("var {%s, $c} = $restore(this, {%s});\n", strings.Join(localVars, ", "), strings.Join(params, ", "))
 		// If the function gets blocked, save local variables for future.
-		saveContext := fmt.Sprintf("var $f = {$blk: "+funcRef+", $c: true, $r, %s};", strings.Join(c.localVars, ", "))
+		saveContext := fmt.Sprintf("var $f = {$blk: "+funcRef+", $c: true, $r, %s%s};", c.translateResumePositions(), strings.Join(c.localVars, ", "))
 
 		suffix = " " + saveContext + "return $f;" + suffix
 	} else if len(c.localVars) > 0 {
@@ -881,5 +1041,33 @@ func translateFunction(typ *ast.FuncType, recv *ast.Ident, body *ast.BlockStmt,
 
 	c.pkgCtx.escapingVars = prevEV
 
-	return params, fmt.Sprintf("function%s(%s) {\n%s%s}", functionName, strings.Join(params, ", "), bodyOutput, strings.Repeat("\t", c.pkgCtx.indentation))
+	fun := fmt.Sprintf("function%s(%s) {\n%s%s}", functionName, strings.Join(params, ", "), bodyOutput, strings.Repeat("\t", c.pkgCtx.indentation))
+	if c.pkgCtx.minify {
+		// Minified code is kept compact, so stack traces only have the
+		// JavaScript names of the functions.
+		return params, fun
+	}
+	return params, namedFunction(funcName, fun)
+}
+
+// translateResumePositions returns the $pos property of the saved context of a
+// blocking function, which describes the function and its blocking calls for
+// goroutine dumps. It has the form "name file case:line:var,case:line:var",
+// where the variable holds the pending call, which is resumed at the case. It's
+// empty if the calls aren't recorded.
+func (fc *funcContext) translateResumePositions() string {
+	if len(fc.resumePositions) == 0 {
+		return ""
+	}
+	var file string
+	var calls []string
+	for _, p := range fc.resumePositions {
+		line := 0
+		if p.pos.IsValid() {
+			pos := fc.goPosition(p.pos)
+			file, line = pos.Filename, pos.Line
+		}
+		calls = append(calls, fmt.Sprintf("%d:%d:%s", p.resumeCase, line, p.returnVar))
+	}
+	return "$pos: " + encodeString(fc.funcName+" "+file+" "+strings.Join(calls, ",")) + ", "
 }
@@ -0,0 +1,322 @@
+package prelude
+
+const generics = `
+/* Returns a cache key for a list of type arguments. */
+var $typeArgsKey = function(args) {
+  return $mapArray(args, function(t) { return t.id; }).join(",");
+};
+
+/* Returns a string representation of type arguments the way reflect spells
+   them in an instantiated type name: with full package paths. */
+var $typeArgsString = function(args) {
+  var str = function(t) {
+    if (t.named) {
+      if (t.pkg === "" || t.string.indexOf(".") === -1) {
+        return t.string;
+      }
+      return t.pkg + t.string.substr(t.string.indexOf("."));
+    }
+    switch (t.kind) {
+    case $kindPtr:
+      return "*" + str(t.elem);
+    case $kindSlice:
+      return "[]" + str(t.elem);
+    case $kindArray:
+      return "[" + t.len + "]" + str(t.elem);
+    case $kindMap:
+      return "map[" + str(t.key) + "]" + str(t.elem);
+    case $kindChan:
+      return (t.recvOnly ? "<-" : "") + "chan" + (t.sendOnly ? "<- " : " ") + str(t.elem);
+    default:
+      return t.string;
+    }
+  };
+  return $mapArray(args, str).join(",");
+};
+
+/* Wraps a factory of a generic function. The factory is called once per
+   unique list of type arguments and must return the instantiated function. */
+var $genericFunc = function(factory) {
+  var instances = new Map();
+  return function() {
+    var args = Array.prototype.slice.call(arguments);
+    var key = $typeArgsKey(args);
+    var instance = instances.get(key);
+    if (instance === undefined) {
+      instance = factory.apply(null, args);
+      instances.set(key, instance);
+    }
+    return instance;
+  };
+};
+
+/* Wraps a factory of a generic type. The factory must return a tuple of the
+   new type and a function completing its initialization, which is invoked
+   after the type has been cached so that recursive references resolve. */
+var $genericType = function(factory) {
+  var instances = new Map();
+  var installers = [];
+  var gen = function() {
+    var args = Array.prototype.slice.call(arguments);
+    var key = $typeArgsKey(args);
+    var typ = instances.get(key);
+    if (typ === undefined) {
+      var result = factory.apply(null, args);
+      typ = result[0];
+      typ.typeArgs = args;
+      instances.set(key, typ);
+      result[1]();
+      for (var i = 0; i < installers.length; i++) {
+        installers[i].apply(null, typ.typeArgs);
+      }
+    }
+    return typ;
+  };
+  /* Registers a function which installs methods into every instance. */
+  gen.addMethods = function(installer) {
+    installers.push(installer);
+    instances.forEach(function(typ) { installer.apply(null, typ.typeArgs); });
+  };
+  return gen;
+};
+
+var $genericFix = function(x, typ) {
+  switch (typ.kind) {
+  case $kindInt8:
+    return x << 24 >> 24;
+  case $kindUint8:
+    return x << 24 >>> 24;
+  case $kindInt16:
+    return x << 16 >> 16;
+  case $kindUint16:
+    return x << 16 >>> 16;
+  case $kindInt:
+  case $kindInt32:
+    return x >> 0;
+  case $kindUint:
+  case $kindUint32:
+  case $kindUintptr:
+    return x >>> 0;
+  case $kindFloat32:
+    return $fround(x);
+  default:
+    return x;
+  }
+};
+
+var $genericIs64 = function(typ) {
+  return typ.kind === $kindInt64 || typ.kind === $kindUint64;
+};
+
+var $genericIsComplex = function(typ) {
+  return typ.kind === $kindComplex64 || typ.kind === $kindComplex128;
+};
+
+var $genericIsInteger = function(typ) {
+  return typ.kind >= $kindInt && typ.kind <= $kindUintptr;
+};
+
+/* Converts a constant into a value of a type parameter's actual type. */
+var $genericConst = function(typ, value, imag) {
+  if ($genericIs64(typ)) {
+    return new typ(Math.floor(value / 4294967296), value >>> 0);
+  }
+  if ($genericIsComplex(typ)) {
+    return new typ(value, imag || 0);
+  }
+  if (typ.kind === $kindFloat32) {
+    return $fround(value);
+  }
+  return value;
+};
+
+/* Converts an integer constant, given as its high and low 32-bit words, into a
+   value of a type parameter's actual type. */
+var $genericIntConst = function(typ, high, low) {
+  if ($genericIs64(typ)) {
+    return new typ(high, low);
+  }
+  if ($genericIsComplex(typ)) {
+    return new typ(high * 4294967296 + low, 0);
+  }
+  return high * 4294967296 + low;
+};
+
+/* Flattens a shift count, which may be a 64-bit value. */
+var $genericFlatten = function(x) {
+  return x.$high !== undefined ? $flatten64(x) : x;
+};
+
+var $genericBinary = function(op, x, y, typ) {
+  if ($genericIs64(typ)) {
+    switch (op) {
+    case "+": return new typ(x.$high + y.$high, x.$low + y.$low);
+    case "-": return new typ(x.$high - y.$high, x.$low - y.$low);
+    case "*": return $mul64(x, y);
+    case "/": return $div64(x, y, false);
+    case "%": return $div64(x, y, true);
+    case "&": return new typ(x.$high & y.$high, (x.$low & y.$low) >>> 0);
+    case "|": return new typ(x.$high | y.$high, (x.$low | y.$low) >>> 0);
+    case "^": return new typ(x.$high ^ y.$high, (x.$low ^ y.$low) >>> 0);
+    case "&^": return new typ(x.$high & ~y.$high, (x.$low & ~y.$low) >>> 0);
+    case "<<": return $shiftLeft64(x, y);
+    case ">>": return typ.kind === $kindInt64 ? $shiftRightInt64(x, y) : $shiftRightUint64(x, y);
+    case "<": return x.$high < y.$high || (x.$high === y.$high && x.$low < y.$low);
+    case "<=": return x.$high < y.$high || (x.$high === y.$high && x.$low <= y.$low);
+    case ">": return x.$high > y.$high || (x.$high === y.$high && x.$low > y.$low);
+    case ">=": return x.$high > y.$high || (x.$high === y.$high && x.$low >= y.$low);
+    }
+  }
+  if ($genericIsComplex(typ)) {
+    switch (op) {
+    case "+": return new typ(x.$real + y.$real, x.$imag + y.$imag);
+    case "-": return new typ(x.$real - y.$real, x.$imag - y.$imag);
+    case "*": return new typ(x.$real * y.$real - x.$imag * y.$imag, x.$real * y.$imag + x.$imag * y.$real);
+    case "/": return $divComplex(x, y);
+    }
+  }
+  switch (op) {
+  case "+": return $genericFix(x + y, typ);
+  case "-": return $genericFix(x - y, typ);
+  case "*":
+    switch (typ.kind) {
+    case $kindInt:
+    case $kindInt32:
+      return $imul(x, y);
+    case $kindUint:
+    case $kindUint32:
+    case $kindUintptr:
+      return $imul(x, y) >>> 0;
+    }
+    return $genericFix(x * y, typ);
+  case "/":
+    if ($genericIsInteger(typ) && y === 0) {
+      $throwRuntimeError("integer divide by zero");
+    }
+    return $genericFix(x / y, typ);
+  case "%":
+    if (y === 0) {
+      $throwRuntimeError("integer divide by zero");
+    }
+    return x % y;
+  case "&": return $genericFix(x & y, typ);
+  case "|": return $genericFix(x | y, typ);
+  case "^": return $genericFix(x ^ y, typ);
+  case "&^": return $genericFix(x & ~y, typ);
+  case "<<": return y >= 32 ? 0 : $genericFix(x << y, typ);
+  case ">>":
+    switch (typ.kind) {
+    case $kindUint:
+    case $kindUint8:
+    case $kindUint16:
+    case $kindUint32:
+    case $kindUintptr:
+      return y >= 32 ? 0 : $genericFix(x >>> y, typ);
+    }
+    return $genericFix(x >> $min(y, 31), typ);
+  case "<": return x < y;
+  case "<=": return x <= y;
+  case ">": return x > y;
+  case ">=": return x >= y;
+  }
+  $throwRuntimeError("unsupported generic operation: " + op + " on " + typ.string);
+};
+
+var $genericUnary = function(op, x, typ) {
+  if ($genericIs64(typ)) {
+    switch (op) {
+    case "-": return new typ(-x.$high, -x.$low);
+    case "^": return new typ(~x.$high, ~x.$low >>> 0);
+    }
+  }
+  if ($genericIsComplex(typ)) {
+    return new typ(-x.$real, -x.$imag);
+  }
+  switch (op) {
+  case "-": return $genericFix(-x, typ);
+  case "^": return $genericFix(~x, typ);
+  }
+  $throwRuntimeError("unsupported generic operation: " + op + " on " + typ.string);
+};
+
+/* Converts a value between types, at least one of which is a type parameter. */
+var $genericConvert = function(x, from, to) {
+  if (from === to) {
+    return x;
+  }
+  if (to.kind === $kindInterface) {
+    return $genericBox(x, from);
+  }
+  var fromNumeric = from.kind >= $kindInt && from.kind <= $kindComplex128;
+  var toNumeric = to.kind >= $kindInt && to.kind <= $kindComplex128;
+  if (fromNumeric && toNumeric) {
+    if ($genericIsComplex(to)) {
+      return new to(x.$real, x.$imag);
+    }
+    if ($genericIs64(from)) {
+      if ($genericIs64(to)) {
+        return new to(x.$high, x.$low);
+      }
+      if (to.kind === $kindFloat32 || to.kind === $kindFloat64) {
+        return $genericFix($flatten64(x), to);
+      }
+      return $genericFix(x.$low, to);
+    }
+    if ($genericIs64(to)) {
+      x = Math.trunc(x);
+      return new to(Math.floor(x / 4294967296), x >>> 0);
+    }
+    if ($genericIsInteger(to) && !$genericIsInteger(from)) {
+      x = Math.trunc(x);
+    }
+    return $genericFix(x, to);
+  }
+  if (to.kind === $kindString) {
+    if (fromNumeric) {
+      return $encodeRune($genericIs64(from) ? x.$low : x);
+    }
+    if (from.kind === $kindSlice) {
+      return from.elem.kind === $kindInt32 ? $runesToString(x) : $bytesToString(x);
+    }
+    return x;
+  }
+  if (to.kind === $kindSlice && from.kind === $kindString) {
+    return new to(to.elem.kind === $kindInt32 ? $stringToRunes(x) : $stringToBytes(x));
+  }
+  if (to.kind === $kindSlice) {
+    return $convertSliceType(x, to);
+  }
+  if (to.kind === $kindPtr && from.kind === $kindPtr && to.elem.kind === $kindStruct && from.elem !== to.elem) {
+    return $pointerOfStructConversion(x, to);
+  }
+  if (to.kind === $kindStruct || to.kind === $kindArray) {
+    return $clone(x, to);
+  }
+  return x;
+};
+
+/* Boxes a value of a type parameter into an interface value. */
+var $genericBox = function(x, typ) {
+  if (typ.kind === $kindInterface) {
+    return x;
+  }
+  if (typ === $jsObjectPtr) {
+    return new $jsObjectPtr(x);
+  }
+  if (typ.wrapped) {
+    return new typ(x);
+  }
+  return x;
+};
+
+var $genericClone = function(x, typ) {
+  return typ.copy ? $clone(x, typ) : x;
+};
+
+var $genericDeref = function(p, typ) {
+  if (typ.kind === $kindStruct || typ.kind === $kindArray) {
+    return p;
+  }
+  return p.$get();
+};
+`
@@ -125,36 +125,61 @@ var $recover = function() {
 };
 var $throw = function(err) { throw err; };
 
-var $noGoroutine = { asleep: false, exit: false, deferStack: [], panicStack: [] };
+var $noGoroutine = { id: 0, asleep: false, exit: false, deferStack: [], panicStack: [] };
 var $curGoroutine = $noGoroutine, $totalGoroutines = 0, $awakeGoroutines = 0, $checkForDeadlock = true, $exportedFunctions = 0;
+// $goroutines holds the live goroutines by their IDs, for the goroutine dumps
+// of runtime.Stack and the deadlock errors.
+var $goroutines = new Map(), $lastGoroutineID = 0;
 var $mainFinished = false;
-var $go = function(fun, args) {
+// $mainStarted is called by the initializer of the main package once all the
+// packages are initialized, right before main is called, see $goMain.
+var $mainStarted = function() {};
+// $go starts a new goroutine. The optional createdBy is the name of the function
+// containing the go statement and the position of the statement, separated by a
+// space, which are reported in goroutine dumps of non-minified builds. Optional onExit and onPanic
+// callbacks are invoked when the goroutine exits or panics, respectively. If
+// onPanic is not provided, the panic is thrown as an uncaught exception.
+var $go = function(fun, args, createdBy, onExit, onPanic) {
   $totalGoroutines++;
   $awakeGoroutines++;
   var $goroutine = function() {
     try {
       $curGoroutine = $goroutine;
+      $goroutine.waitReason = undefined;
+      $goroutine.suspended = undefined;
       var r = fun.apply(undefined, args);
       if (r && r.$blk !== undefined) {
         fun = function() { return r.$blk(); };
         args = [];
+        $goroutine.suspended = r;
         return;
       }
       $goroutine.exit = true;
     } catch (err) {
       if (!$goroutine.exit) {
-        throw err;
+        if ($goroutine.onPanic === undefined) {
+          throw err;
+        }
+        $goroutine.onPanic(err);
       }
     } finally {
       $curGoroutine = $noGoroutine;
       if ($goroutine.exit) { /* also set by runtime.Goexit() */
         $totalGoroutines--;
+        $goroutines.delete($goroutine.id);
         $goroutine.asleep = true;
+        if ($goroutine.onExit !== undefined) {
+          $goroutine.onExit();
+        }
       }
       if ($goroutine.asleep) {
         $awakeGoroutines--;
         if (!$mainFinished && $awakeGoroutines === 0 && $checkForDeadlock && $exportedFunctions === 0) {
-          console.error("fatal error: all goroutines are asleep - deadlock!");
+          var report = "fatal error: all goroutines are asleep - deadlock!\n";
+          $goroutines.forEach(function(goroutine) {
+            report += "\n" + $goroutineTrace(goroutine);
+          });
+          console.error(report.slice(0, -1));
           if ($global.process !== undefined) {
             $global.process.exit(2);
           }
@@ -166,9 +191,83 @@ var $go = function(fun, args) {
   $goroutine.exit = false;
   $goroutine.deferStack = [];
   $goroutine.panicStack = [];
+  $goroutine.onExit = onExit;
+  $goroutine.onPanic = onPanic;
+  $goroutine.id = ++$lastGoroutineID;
+  $goroutine.createdBy = createdBy;
+  $goroutine.waitReason = undefined;
+  $goroutine.suspended = undefined;
+  $goroutines.set($goroutine.id, $goroutine);
   $schedule($goroutine);
 };
 
+// $goMain starts the program's main goroutine and returns a promise, which is
+// resolved once the packages are initialized and main is called, or rejected if
+// the initialization panics. The main function keeps running in the goroutine,
+// and its panics are thrown as uncaught exceptions.
+var $goMain = function(fun) {
+  return new Promise(function(resolve, reject) {
+    $mainStarted = function() {
+      $curGoroutine.onPanic = undefined;
+      resolve();
+    };
+    $go(fun, [], undefined, resolve, reject);
+  });
+};
+
+// $blockedFrames returns the functions a goroutine is suspended in, innermost
+// first, with the positions of the blocking calls they're resumed after. The
+// saved context of each function describes its blocking calls in $pos, with the
+// variables holding the pending calls, which lead to the saved contexts of the
+// callees. The package initializers have no $pos, and hold the pending call in
+// $r. The frames are only known in non-minified builds.
+var $blockedFrames = function(goroutine) {
+  var frames = [];
+  var f = goroutine.suspended;
+  while (f !== undefined && f !== null) {
+    if (f.$pos === undefined) {
+      f = f.$r;
+      continue;
+    }
+    var name = f.$pos.indexOf(" "), calls = f.$pos.lastIndexOf(" ");
+    var cases = f.$pos.substring(calls + 1).split(","), callee = undefined;
+    for (var i = 0; i < cases.length; i++) {
+      var c = cases[i].split(":");
+      if (Number(c[0]) === f.$s) {
+        frames.unshift({ name: f.$pos.substring(0, name), file: f.$pos.substring(name + 1, calls), line: Number(c[1]) });
+        callee = f[c[2]];
+        break;
+      }
+    }
+    f = callee;
+  }
+  return frames;
+};
+
+// $goroutineTrace formats the status and the frames of a goroutine like the
+// tracebacks of the upstream runtime. The frames of the running goroutine are
+// passed by the caller, otherwise those it's suspended in are reported.
+var $goroutineTrace = function(goroutine, frames) {
+  var status = goroutine.waitReason || "waiting";
+  if (goroutine === $curGoroutine) {
+    status = "running";
+  } else if (!goroutine.asleep) {
+    status = "runnable";
+  }
+  if (frames === undefined) {
+    frames = $blockedFrames(goroutine);
+  }
+  var trace = "goroutine " + goroutine.id + " [" + status + "]:\n";
+  for (var i = 0; i < frames.length; i++) {
+    trace += frames[i].name + "(...)\n\t" + frames[i].file + ":" + frames[i].line + "\n";
+  }
+  if (goroutine.createdBy !== undefined) {
+    var sep = goroutine.createdBy.indexOf(" ");
+    trace += "created by " + goroutine.createdBy.substring(0, sep) + "\n\t" + goroutine.createdBy.substring(sep + 1) + "\n";
+  }
+  return trace;
+};
+
 var $scheduled = [];
 var $runScheduled = function() {
   // For nested setTimeout calls browsers enforce 4ms minimum delay. We minimize
@@ -216,11 +315,17 @@ var $setTimeout = function(f, t) {
   }, t);
 };
 
-var $block = function() {
+// $block puts the current goroutine to sleep, until it's scheduled again. The
+// reason is reported in goroutine dumps, unless the caller of the blocking
+// operation has set a more specific one, such as "sleep" in time.Sleep.
+var $block = function(reason) {
   if ($curGoroutine === $noGoroutine) {
     $throwRuntimeError("cannot block in JavaScript callback, fix by wrapping code in goroutine");
   }
   $curGoroutine.asleep = true;
+  if ($curGoroutine.waitReason === undefined) {
+    $curGoroutine.waitReason = reason;
+  }
 };
 
 var $restore = function(context, params) {
@@ -251,7 +356,7 @@ var $send = function(chan, value) {
     $schedule(thisGoroutine);
     return value;
   });
-  $block();
+  $block(chan === $chanNil ? "chan send (nil chan)" : "chan send");
   return {
     $blk: function() {
       if (closedDuringSend) {
@@ -280,7 +385,7 @@ var $recv = function(chan) {
     $schedule(thisGoroutine);
   };
   chan.$recvQueue.push(queueEntry);
-  $block();
+  $block(chan === $chanNil ? "chan receive (nil chan)" : "chan receive");
   return f;
 };
 var $close = function(chan) {
@@ -387,7 +492,7 @@ var $select = function(comms) {
       }
     })(i);
   }
-  $block();
+  $block(comms.length === 0 ? "select (no cases)" : "select");
   return f;
 };
 `
@@ -150,18 +150,7 @@ var $externalizeFunction = function(v, t, passThis, makeWrapper) {
   if (v.$externalizeWrapper === undefined) {
     $checkForDeadlock = false;
     v.$externalizeWrapper = function() {
-      var args = [];
-      for (var i = 0; i < t.params.length; i++) {
-        if (t.variadic && i === t.params.length - 1) {
-          var vt = t.params[i].elem, varargs = [];
-          for (var j = i; j < arguments.length; j++) {
-            varargs.push($internalize(arguments[j], vt, makeWrapper));
-          }
-          args.push(new (t.params[i])(varargs));
-          break;
-        }
-        args.push($internalize(arguments[i], t.params[i], makeWrapper));
-      }
+      var args = $internalizeArguments(arguments, t, makeWrapper);
       var result = v.apply(passThis ? this : undefined, args);
       switch (t.results.length) {
       case 0:
@@ -179,6 +168,94 @@ var $externalizeFunction = function(v, t, passThis, makeWrapper) {
   return v.$externalizeWrapper;
 };
 
+// $internalizeArguments converts JavaScript arguments of a call to an
+// externalized function of type t into Go values.
+var $internalizeArguments = function(jsArgs, t, makeWrapper) {
+  var args = [];
+  for (var i = 0; i < t.params.length; i++) {
+    if (t.variadic && i === t.params.length - 1) {
+      var vt = t.params[i].elem, varargs = [];
+      for (var j = i; j < jsArgs.length; j++) {
+        varargs.push($internalize(jsArgs[j], vt, makeWrapper));
+      }
+      args.push(new (t.params[i])(varargs));
+      break;
+    }
+    args.push($internalize(jsArgs[i], t.params[i], makeWrapper));
+  }
+  return args;
+};
+
+// $exportFunction wraps a Go function v of type t for a library's JavaScript
+// API. If the last result is a non-nil error, it is thrown as an Error. The
+// rest of the results are externalized and returned as a single value, or an
+// array if there are several. Functions that may block are run in a new
+// goroutine and return a Promise of the results instead.
+var $exportFunction = function(v, t, blocking, makeWrapper) {
+  var convertResults = function(result) {
+    var n = t.results.length;
+    var results = n === 1 ? [result] : result;
+    if (n > 0 && t.results[n - 1] === $error) {
+      var err = results[n - 1];
+      if (err !== $ifaceNil) {
+        throw new Error(err.Error());
+      }
+      n--;
+    }
+    var externalized = [];
+    for (var i = 0; i < n; i++) {
+      externalized.push($externalize($copyIfRequired(results[i], t.results[i]), t.results[i], makeWrapper));
+    }
+    return n === 1 ? externalized[0] : (n === 0 ? undefined : externalized);
+  };
+
+  if (!blocking) {
+    return function() {
+      return convertResults(v.apply(undefined, $internalizeArguments(arguments, t, makeWrapper)));
+    };
+  }
+  return function() {
+    var args = $internalizeArguments(arguments, t, makeWrapper);
+    return new Promise(function(resolve, reject) {
+      var result;
+      var step = function() { return v.apply(undefined, args); };
+      var run = function() {
+        var r = step();
+        if (r && r.$blk !== undefined) {
+          step = function() { return r.$blk(); };
+          return { $blk: run };
+        }
+        result = r;
+      };
+      $go(run, [], undefined, function() {
+        try {
+          resolve(convertResults(result));
+        } catch (e) {
+          reject(e);
+        }
+      }, reject);
+    });
+  };
+};
+
+// $exportStructType returns a constructor for a library's JavaScript API, which
+// creates a wrapped pointer to a new value of the struct type typ. Exported
+// fields are initialized from the properties of the optional argument.
+var $exportStructType = function(typ, makeWrapper) {
+  return function(init) {
+    var v = new typ.ptr();
+    if (init !== undefined && init !== null) {
+      for (var i = 0; i < typ.fields.length; i++) {
+        var f = typ.fields[i];
+        if (f.exported && init[f.name] !== undefined) {
+          v[f.prop] = $internalize(init[f.name], f.typ, makeWrapper);
+        }
+      }
+    }
+    return makeWrapper(v);
+  };
+};
+
 var $internalize = function(v, t, recv, seen, makeWrapper) {
   if (t === $jsObjectPtr) {
     return v;
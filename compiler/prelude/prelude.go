@@ -3,7 +3,7 @@ package prelude
 //go:generate go run genmin.go
 
 // Prelude is the GopherJS JavaScript interop layer.
-const Prelude = prelude + numeric + types + goroutines + jsmapping
+const Prelude = prelude + numeric + types + generics + goroutines + jsmapping
 
 const prelude = `Error.stackTraceLimit = Infinity;
 
@@ -15,7 +15,9 @@ if (typeof window !== "undefined") { /* web page */
   $global = self;
 } else if (typeof global !== "undefined") { /* Node.js */
   $global = global;
-  $global.require = require;
+  if (typeof require !== "undefined") { /* not defined in ECMAScript modules */
+    $global.require = require;
+  }
 } else { /* others (e.g. Nashorn) */
   $global = this;
 }
@@ -92,6 +94,76 @@ var $initAllLinknames = function() {
   }
 }
 
+// $embedNames returns a sorted list of files embedded into the package pkg
+// that match any of the go:embed patterns.
+var $embedNames = function(pkg, patterns) {
+  var embeds = pkg.$embeds;
+  var names = [];
+  for (var i = 0; i < patterns.length; i++) {
+    var files = embeds !== undefined ? embeds.patterns[patterns[i]] : undefined;
+    if (files === undefined) {
+      $throwRuntimeError("go:embed: pattern " + patterns[i] + " has not been resolved by the build");
+    }
+    for (var j = 0; j < files.length; j++) {
+      if (names.indexOf(files[j]) === -1) {
+        names.push(files[j]);
+      }
+    }
+  }
+  return names.sort();
+};
+
+// $embedString returns contents of the single file embedded into the package
+// pkg, which matches the go:embed patterns.
+var $embedString = function(pkg, patterns) {
+  var names = $embedNames(pkg, patterns);
+  if (names.length !== 1) {
+    $throwRuntimeError("go:embed: multiple files for a string or []byte variable");
+  }
+  return pkg.$embeds.files[names[0]];
+};
+
+// $embedFS returns a value of embed.FS type typ, which contains files embedded
+// into the package pkg that match the go:embed patterns.
+var $embedFS = function(typ, pkg, patterns) {
+  var names = $embedNames(pkg, patterns);
+  var entries = {};
+  for (var i = 0; i < names.length; i++) {
+    entries[names[i]] = pkg.$embeds.files[names[i]];
+    // Parent directories are listed explicitly with a trailing slash.
+    for (var dir = names[i]; dir.lastIndexOf("/") !== -1;) {
+      dir = dir.substring(0, dir.lastIndexOf("/"));
+      entries[dir + "/"] = "";
+    }
+  }
+  // Entries are sorted by directory first and name second, just like the Go
+  // toolchain does, since embed.FS relies on that for lookups.
+  var split = function(name) {
+    if (name[name.length - 1] === "/") {
+      name = name.substring(0, name.length - 1);
+    }
+    var i = name.lastIndexOf("/");
+    return i === -1 ? [".", name] : [name.substring(0, i), name.substring(i + 1)];
+  };
+  var list = $keys(entries).sort(function(a, b) {
+    var x = split(a), y = split(b);
+    if (x[0] !== y[0]) {
+      return x[0] < y[0] ? -1 : 1;
+    }
+    return x[1] < y[1] ? -1 : (x[1] > y[1] ? 1 : 0);
+  });
+
+  // embed.FS is defined as struct { files *[]file }.
+  var filesPtrType = typ.fields[0].typ, fileType = filesPtrType.elem.elem;
+  var files = $mapArray(list, function(name) {
+    var f = new fileType.ptr();
+    f[fileType.fields[0].prop] = name;
+    f[fileType.fields[1].prop] = entries[name];
+    return f;
+  });
+  return new typ.ptr($newDataPointer(new filesPtrType.elem(files), filesPtrType));
+};
+
 var $mapArray = function(array, f) {
   var newArray = new array.constructor(array.length);
   for (var i = 0; i < array.length; i++) {
@@ -108,6 +180,14 @@ var $mapIndex = function(m, key) {
 var $mapDelete = function(m, key) {
   typeof m.delete === "function" && m.delete(key)
 };
+// $methodWrapper names the function fn, which calls a method of the same name
+// on another receiver, so that runtime.Callers hides it the same way the
+// upstream Go runtime hides autogenerated wrappers.
+var $methodWrapper = function(fn) {
+  Object.defineProperty(fn, "name", { value: "$methodWrapper" });
+  return fn;
+};
+
 // Returns a method bound to the receiver instance, safe to invoke as a 
 // standalone function. Bound function is cached for later reuse.
 var $methodVal = function(recv, name) {
@@ -126,7 +206,7 @@ var $methodVal = function(recv, name) {
 var $methodExpr = function(typ, name) {
   var method = typ.prototype[name];
   if (method.$expr === undefined) {
-    method.$expr = function() {
+    method.$expr = $methodWrapper(function() {
       $stackDepthOffset--;
       try {
         if (typ.wrapped) {
@@ -136,7 +216,7 @@ var $methodExpr = function(typ, name) {
       } finally {
         $stackDepthOffset++;
       }
-    };
+    });
   }
   return method.$expr;
 };
@@ -145,14 +225,14 @@ var $ifaceMethodExprs = {};
 var $ifaceMethodExpr = function(name) {
   var expr = $ifaceMethodExprs["$" + name];
   if (expr === undefined) {
-    expr = $ifaceMethodExprs["$" + name] = function() {
+    expr = $ifaceMethodExprs["$" + name] = $methodWrapper(function() {
       $stackDepthOffset--;
       try {
         return Function.call.apply(arguments[0][name], arguments);
       } finally {
         $stackDepthOffset++;
       }
-    };
+    });
   }
   return expr;
 };
@@ -423,6 +503,21 @@ var $pointerOfStructConversion = function(obj, type) {
   return proxy;
 };
 
+// $trackAllocations is set when the program is compiled with allocation
+// tracking, which updates $mallocs and $totalAlloc.
+var $trackAllocations = false;
+
+// $mallocs counts heap allocations made by Go code: objects created by new,
+// make, composite literals and growing slices in append. It is reported by
+// runtime.ReadMemStats as MemStats.Mallocs.
+var $mallocs = 0;
+
+// $totalAlloc approximately counts the bytes allocated by Go code, using the
+// sizes of the types on a 32-bit target. Generic types and the contents of
+// maps and channels aren't accounted for. It is reported by runtime.ReadMemStats as
+// MemStats.TotalAlloc.
+var $totalAlloc = 0;
+
 var $append = function(slice) {
   return $internalAppend(slice, arguments, 1, arguments.length - 1);
 };
@@ -448,6 +543,10 @@ var $internalAppend = function(slice, array, offset, length) {
   if (newLength > newCapacity) {
     newOffset = 0;
     newCapacity = Math.max(newLength, slice.$capacity < 1024 ? slice.$capacity * 2 : Math.floor(slice.$capacity * 5 / 4));
+    if ($trackAllocations) {
+      $mallocs++;
+      $totalAlloc += newCapacity * slice.constructor.elem.size;
+    }
 
     if (slice.$array.constructor === Array) {
       newArray = slice.$array.slice(slice.$offset, slice.$offset + slice.$length);
@@ -3,4 +3,4 @@
 package prelude
 
 // Minified is an uglifyjs-minified version of Prelude.
-const Minified = "Error.stackTraceLimit=1/0;var $global,$module,$NaN=NaN;if(\"undefined\"!=typeof window?$global=window:\"undefined\"!=typeof self?$global=self:\"undefined\"!=typeof global?($global=global).require=require:$global=this,void 0===$global||void 0===$global.Array)throw new Error(\"no global object found\");if(\"undefined\"!=typeof module&&($module=module),!$global.fs&&$global.require)try{var fs=$global.require(\"fs\");\"object\"==typeof fs&&null!==fs&&0!==Object.keys(fs).length&&($global.fs=fs)}catch(e){}if(!$global.fs){var outputBuf=\"\",decoder=new TextDecoder(\"utf-8\");$global.fs={constants:{O_WRONLY:-1,O_RDWR:-1,O_CREAT:-1,O_TRUNC:-1,O_APPEND:-1,O_EXCL:-1},writeSync:function(e,n){var r=(outputBuf+=decoder.decode(n)).lastIndexOf(\"\\n\");return-1!=r&&(console.log(outputBuf.substr(0,r)),outputBuf=outputBuf.substr(r+1)),n.length},write:function(e,n,r,t,i,a){0===r&&t===n.length&&null===i?a(null,this.writeSync(e,n)):a(enosys())}}}var $throwRuntimeError,$linknames={},$packages={},$idCounter=0,$keys=function(e){return e?Object.keys(e):[]},$flushConsole=function(){},$throwNilPointerError=function(){$throwRuntimeError(\"invalid memory address or nil pointer dereference\")},$call=function(e,n,r){return e.apply(n,r)},$makeFunc=function(e){return function(){return $externalize(e(this,new($sliceType($jsObjectPtr))($global.Array.prototype.slice.call(arguments,[]))),$emptyInterface)}},$unused=function(e){},$print=console.log;if(void 0!==$global.process&&$global.require)try{var util=$global.require(\"util\");$print=function(){$global.process.stderr.write(util.format.apply(this,arguments))}}catch(e){}var $println=console.log,$initAllLinknames=function(){for(var e=$keys($packages),n=0;n<e.length;n++){var r=$packages[e[n]].$initLinknames;\"function\"==typeof r&&r()}},$mapArray=function(e,n){for(var r=new e.constructor(e.length),t=0;t<e.length;t++)r[t]=n(e[t]);return r},$mapIndex=function(e,n){return\"function\"==typeof e.get?e.get(n):void 0},$mapDelete=function(e,n){\"function\"==typeof e.delete&&e.delete(n)},$methodVal=function(e,n){var r=e.$methodVals||{};e.$methodVals=r;var t=r[n];return void 0!==t?t:(t=e[n].bind(e),r[n]=t,t)},$methodExpr=function(e,n){var r=e.prototype[n];return void 0===r.$expr&&(r.$expr=function(){$stackDepthOffset--;try{return e.wrapped&&(arguments[0]=new e(arguments[0])),Function.call.apply(r,arguments)}finally{$stackDepthOffset++}}),r.$expr},$ifaceMethodExprs={},$ifaceMethodExpr=function(e){var n=$ifaceMethodExprs[\"$\"+e];return void 0===n&&(n=$ifaceMethodExprs[\"$\"+e]=function(){$stackDepthOffset--;try{return Function.call.apply(arguments[0][e],arguments)}finally{$stackDepthOffset++}}),n},$subslice=function(e,n,r,t){if(void 0===r&&(r=e.$length),void 0===t&&(t=e.$capacity),(n<0||r<n||t<r||r>e.$capacity||t>e.$capacity)&&$throwRuntimeError(\"slice bounds out of range\"),e===e.constructor.nil)return e;var i=new e.constructor(e.$array);return i.$offset=e.$offset+n,i.$length=r-n,i.$capacity=t-n,i},$substring=function(e,n,r){return(n<0||r<n||r>e.length)&&$throwRuntimeError(\"slice bounds out of range\"),e.substring(n,r)},$sliceToNativeArray=function(e){return e.$array.constructor!==Array?e.$array.subarray(e.$offset,e.$offset+e.$length):e.$array.slice(e.$offset,e.$offset+e.$length)},$sliceToGoArray=function(e,n){var r=n.elem;return void 0!==r&&e.$length<r.len&&$throwRuntimeError(\"cannot convert slice with length \"+e.$length+\" to pointer to array with length \"+r.len),e==e.constructor.nil?n.nil:e.$array.constructor!==Array?e.$array.subarray(e.$offset,e.$offset+r.len):0==e.$offset&&e.$length==e.$capacity&&e.$length==r.len?e.$array:0==r.len?new r([]):void $throwRuntimeError(\"gopherjs: non-numeric slice to underlying array conversion is not supported for subslices\")},$convertSliceType=function(e,n){return e==e.constructor.nil?n.nil:$subslice(new n(e.$array),e.$offset,e.$offset+e.$length)},$decodeRune=function(e,n){var r=e.charCodeAt(n);if(r<128)return[r,1];if(r!=r||r<192)return[65533,1];var t=e.charCodeAt(n+1);if(t!=t||t<128||192<=t)return[65533,1];if(r<224)return(a=(31&r)<<6|63&t)<=127?[65533,1]:[a,2];var i=e.charCodeAt(n+2);if(i!=i||i<128||192<=i)return[65533,1];if(r<240)return(a=(15&r)<<12|(63&t)<<6|63&i)<=2047?[65533,1]:55296<=a&&a<=57343?[65533,1]:[a,3];var a,o=e.charCodeAt(n+3);return o!=o||o<128||192<=o?[65533,1]:r<248?(a=(7&r)<<18|(63&t)<<12|(63&i)<<6|63&o)<=65535||1114111<a?[65533,1]:[a,4]:[65533,1]},$encodeRune=function(e){return(e<0||e>1114111||55296<=e&&e<=57343)&&(e=65533),e<=127?String.fromCharCode(e):e<=2047?String.fromCharCode(192|e>>6,128|63&e):e<=65535?String.fromCharCode(224|e>>12,128|e>>6&63,128|63&e):String.fromCharCode(240|e>>18,128|e>>12&63,128|e>>6&63,128|63&e)},$stringToBytes=function(e){for(var n=new Uint8Array(e.length),r=0;r<e.length;r++)n[r]=e.charCodeAt(r);return n},$bytesToString=function(e){if(0===e.$length)return\"\";for(var n=\"\",r=0;r<e.$length;r+=1e4)n+=String.fromCharCode.apply(void 0,e.$array.subarray(e.$offset+r,e.$offset+Math.min(e.$length,r+1e4)));return n},$stringToRunes=function(e){for(var n,r=new Int32Array(e.length),t=0,i=0;i<e.length;i+=n[1],t++)n=$decodeRune(e,i),r[t]=n[0];return r.subarray(0,t)},$runesToString=function(e){if(0===e.$length)return\"\";for(var n=\"\",r=0;r<e.$length;r++)n+=$encodeRune(e.$array[e.$offset+r]);return n},$copyString=function(e,n){for(var r=Math.min(n.length,e.$length),t=0;t<r;t++)e.$array[e.$offset+t]=n.charCodeAt(t);return r},$copySlice=function(e,n){var r=Math.min(n.$length,e.$length);return $copyArray(e.$array,n.$array,e.$offset,n.$offset,r,e.constructor.elem),r},$copyArray=function(e,n,r,t,i,a){if(0!==i&&(e!==n||r!==t))if(n.subarray)e.set(n.subarray(t,t+i),r);else{switch(a.kind){case $kindArray:case $kindStruct:if(e===n&&r>t){for(var o=i-1;o>=0;o--)a.copy(e[r+o],n[t+o]);return}for(o=0;o<i;o++)a.copy(e[r+o],n[t+o]);return}if(e===n&&r>t)for(o=i-1;o>=0;o--)e[r+o]=n[t+o];else for(o=0;o<i;o++)e[r+o]=n[t+o]}},$clone=function(e,n){var r=n.zero();return n.copy(r,e),r},$pointerOfStructConversion=function(e,n){void 0===e.$proxies&&(e.$proxies={},e.$proxies[e.constructor.string]=e);var r=e.$proxies[n.string];if(void 0===r){for(var t={},i=0;i<n.elem.fields.length;i++)!function(n){t[n]={get:function(){return e[n]},set:function(r){e[n]=r}}}(n.elem.fields[i].prop);(r=Object.create(n.prototype,t)).$val=r,e.$proxies[n.string]=r,r.$proxies=e.$proxies}return r},$append=function(e){return $internalAppend(e,arguments,1,arguments.length-1)},$appendSlice=function(e,n){if(n.constructor===String){var r=$stringToBytes(n);return $internalAppend(e,r,0,r.length)}return $internalAppend(e,n.$array,n.$offset,n.$length)},$internalAppend=function(e,n,r,t){if(0===t)return e;var i=e.$array,a=e.$offset,o=e.$length+t,c=e.$capacity;if(o>c)if(a=0,c=Math.max(o,e.$capacity<1024?2*e.$capacity:Math.floor(5*e.$capacity/4)),e.$array.constructor===Array){(i=e.$array.slice(e.$offset,e.$offset+e.$length)).length=c;for(var $=e.constructor.elem.zero,u=e.$length;u<c;u++)i[u]=$()}else(i=new e.$array.constructor(c)).set(e.$array.subarray(e.$offset,e.$offset+e.$length));$copyArray(i,n,a+e.$length,r,t,e.constructor.elem);var l=new e.constructor(i);return l.$offset=a,l.$length=o,l.$capacity=c,l},$equal=function(e,n,r){if(r===$jsObjectPtr)return e===n;switch(r.kind){case $kindComplex64:case $kindComplex128:return e.$real===n.$real&&e.$imag===n.$imag;case $kindInt64:case $kindUint64:return e.$high===n.$high&&e.$low===n.$low;case $kindArray:if(e.length!==n.length)return!1;for(var t=0;t<e.length;t++)if(!$equal(e[t],n[t],r.elem))return!1;return!0;case $kindStruct:for(t=0;t<r.fields.length;t++){var i=r.fields[t];if(!$equal(e[i.prop],n[i.prop],i.typ))return!1}return!0;case $kindInterface:return $interfaceIsEqual(e,n);default:return e===n}},$interfaceIsEqual=function(e,n){return e===$ifaceNil||n===$ifaceNil?e===n:e.constructor===n.constructor&&(e.constructor===$jsObjectPtr?e.object===n.object:(e.constructor.comparable||$throwRuntimeError(\"comparing uncomparable type \"+e.constructor.string),$equal(e.$val,n.$val,e.constructor)))},$min=Math.min,$mod=function(e,n){return e%n},$parseInt=parseInt,$parseFloat=function(e){return void 0!==e&&null!==e&&e.constructor===Number?e:parseFloat(e)},$froundBuf=new Float32Array(1),$fround=Math.fround||function(e){return $froundBuf[0]=e,$froundBuf[0]},$imul=Math.imul||function(e,n){var r=65535&e,t=65535&n;return r*t+((e>>>16&65535)*t+r*(n>>>16&65535)<<16>>>0)>>0},$floatKey=function(e){return e!=e?\"NaN$\"+ ++$idCounter:String(e)},$flatten64=function(e){return 4294967296*e.$high+e.$low},$shiftLeft64=function(e,n){return 0===n?e:n<32?new e.constructor(e.$high<<n|e.$low>>>32-n,e.$low<<n>>>0):n<64?new e.constructor(e.$low<<n-32,0):new e.constructor(0,0)},$shiftRightInt64=function(e,n){return 0===n?e:n<32?new e.constructor(e.$high>>n,(e.$low>>>n|e.$high<<32-n)>>>0):n<64?new e.constructor(e.$high>>31,e.$high>>n-32>>>0):e.$high<0?new e.constructor(-1,4294967295):new e.constructor(0,0)},$shiftRightUint64=function(e,n){return 0===n?e:n<32?new e.constructor(e.$high>>>n,(e.$low>>>n|e.$high<<32-n)>>>0):n<64?new e.constructor(0,e.$high>>>n-32):new e.constructor(0,0)},$mul64=function(e,n){var r=e.$high>>>16,t=65535&e.$high,i=e.$low>>>16,a=65535&e.$low,o=n.$high>>>16,c=65535&n.$high,$=n.$low>>>16,u=65535&n.$low,l=0,s=0,f=0,d=0;f+=(d+=a*u)>>>16,s+=(f+=i*u)>>>16,f&=65535,s+=(f+=a*$)>>>16,l+=(s+=t*u)>>>16,s&=65535,l+=(s+=i*$)>>>16,s&=65535,l+=(s+=a*c)>>>16,l+=r*u+t*$+i*c+a*o;var p=((l&=65535)<<16|(s&=65535))>>>0,h=((f&=65535)<<16|(d&=65535))>>>0;return new e.constructor(p,h)},$div64=function(e,n,r){0===n.$high&&0===n.$low&&$throwRuntimeError(\"integer divide by zero\");var t=1,i=1,a=e.$high,o=e.$low;a<0&&(t=-1,i=-1,a=-a,0!==o&&(a--,o=4294967296-o));var c=n.$high,$=n.$low;n.$high<0&&(t*=-1,c=-c,0!==$&&(c--,$=4294967296-$));for(var u=0,l=0,s=0;c<2147483648&&(a>c||a===c&&o>$);)c=(c<<1|$>>>31)>>>0,$=$<<1>>>0,s++;for(var f=0;f<=s;f++)u=u<<1|l>>>31,l=l<<1>>>0,(a>c||a===c&&o>=$)&&(a-=c,(o-=$)<0&&(a--,o+=4294967296),4294967296===++l&&(u++,l=0)),$=($>>>1|c<<31)>>>0,c>>>=1;return r?new e.constructor(a*i,o*i):new e.constructor(u*t,l*t)},$divComplex=function(e,n){var r=e.$real===1/0||e.$real===-1/0||e.$imag===1/0||e.$imag===-1/0,t=n.$real===1/0||n.$real===-1/0||n.$imag===1/0||n.$imag===-1/0,i=!r&&(e.$real!=e.$real||e.$imag!=e.$imag),a=!t&&(n.$real!=n.$real||n.$imag!=n.$imag);if(i||a)return new e.constructor(NaN,NaN);if(r&&!t)return new e.constructor(1/0,1/0);if(!r&&t)return new e.constructor(0,0);if(0===n.$real&&0===n.$imag)return 0===e.$real&&0===e.$imag?new e.constructor(NaN,NaN):new e.constructor(1/0,1/0);if(Math.abs(n.$real)<=Math.abs(n.$imag)){var o=n.$real/n.$imag,c=n.$real*o+n.$imag;return new e.constructor((e.$real*o+e.$imag)/c,(e.$imag*o-e.$real)/c)}o=n.$imag/n.$real,c=n.$imag*o+n.$real;return new e.constructor((e.$imag*o+e.$real)/c,(e.$imag-e.$real*o)/c)},$kindBool=1,$kindInt=2,$kindInt8=3,$kindInt16=4,$kindInt32=5,$kindInt64=6,$kindUint=7,$kindUint8=8,$kindUint16=9,$kindUint32=10,$kindUint64=11,$kindUintptr=12,$kindFloat32=13,$kindFloat64=14,$kindComplex64=15,$kindComplex128=16,$kindArray=17,$kindChan=18,$kindFunc=19,$kindInterface=20,$kindMap=21,$kindPtr=22,$kindSlice=23,$kindString=24,$kindStruct=25,$kindUnsafePointer=26,$methodSynthesizers=[],$addMethodSynthesizer=function(e){null!==$methodSynthesizers?$methodSynthesizers.push(e):e()},$synthesizeMethods=function(){$methodSynthesizers.forEach(function(e){e()}),$methodSynthesizers=null},$ifaceKeyFor=function(e){if(e===$ifaceNil)return\"nil\";var n=e.constructor;return n.string+\"$\"+n.keyFor(e.$val)},$identity=function(e){return e},$typeIDCounter=0,$idKey=function(e){return void 0===e.$id&&($idCounter++,e.$id=$idCounter),String(e.$id)},$arrayPtrCtor=function(){return function(e){this.$get=function(){return e},this.$set=function(e){typ.copy(this,e)},this.$val=e}},$newType=function(e,n,r,t,i,a,o){var c;switch(n){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindUnsafePointer:(c=function(e){this.$val=e}).wrapped=!0,c.keyFor=$identity;break;case $kindString:(c=function(e){this.$val=e}).wrapped=!0,c.keyFor=function(e){return\"$\"+e};break;case $kindFloat32:case $kindFloat64:(c=function(e){this.$val=e}).wrapped=!0,c.keyFor=function(e){return $floatKey(e)};break;case $kindInt64:(c=function(e,n){this.$high=e+Math.floor(Math.ceil(n)/4294967296)>>0,this.$low=n>>>0,this.$val=this}).keyFor=function(e){return e.$high+\"$\"+e.$low};break;case $kindUint64:(c=function(e,n){this.$high=e+Math.floor(Math.ceil(n)/4294967296)>>>0,this.$low=n>>>0,this.$val=this}).keyFor=function(e){return e.$high+\"$\"+e.$low};break;case $kindComplex64:(c=function(e,n){this.$real=$fround(e),this.$imag=$fround(n),this.$val=this}).keyFor=function(e){return e.$real+\"$\"+e.$imag};break;case $kindComplex128:(c=function(e,n){this.$real=e,this.$imag=n,this.$val=this}).keyFor=function(e){return e.$real+\"$\"+e.$imag};break;case $kindArray:(c=function(e){this.$val=e}).wrapped=!0,c.ptr=$newType(4,$kindPtr,\"*\"+r,!1,\"\",!1,$arrayPtrCtor()),c.init=function(e,n){c.elem=e,c.len=n,c.comparable=e.comparable,c.keyFor=function(n){return Array.prototype.join.call($mapArray(n,function(n){return String(e.keyFor(n)).replace(/\\\\/g,\"\\\\\\\\\").replace(/\\$/g,\"\\\\$\")}),\"$\")},c.copy=function(n,r){$copyArray(n,r,0,0,r.length,e)},c.ptr.init(c),Object.defineProperty(c.ptr.nil,\"nilCheck\",{get:$throwNilPointerError})};break;case $kindChan:(c=function(e){this.$val=e}).wrapped=!0,c.keyFor=$idKey,c.init=function(e,n,r){c.elem=e,c.sendOnly=n,c.recvOnly=r};break;case $kindFunc:(c=function(e){this.$val=e}).wrapped=!0,c.init=function(e,n,r){c.params=e,c.results=n,c.variadic=r,c.comparable=!1};break;case $kindInterface:(c={implementedBy:{},missingMethodFor:{}}).keyFor=$ifaceKeyFor,c.init=function(e){c.methods=e,e.forEach(function(e){$ifaceNil[e.prop]=$throwNilPointerError})};break;case $kindMap:(c=function(e){this.$val=e}).wrapped=!0,c.init=function(e,n){c.key=e,c.elem=n,c.comparable=!1};break;case $kindPtr:(c=o||function(e,n,r){this.$get=e,this.$set=n,this.$target=r,this.$val=this}).keyFor=$idKey,c.init=function(e){c.elem=e,c.wrapped=e.kind===$kindArray,c.nil=new c($throwNilPointerError,$throwNilPointerError)};break;case $kindSlice:(c=function(e){e.constructor!==c.nativeArray&&(e=new c.nativeArray(e)),this.$array=e,this.$offset=0,this.$length=e.length,this.$capacity=e.length,this.$val=this}).init=function(e){c.elem=e,c.comparable=!1,c.nativeArray=$nativeArray(e.kind),c.nil=new c([])};break;case $kindStruct:(c=function(e){this.$val=e}).wrapped=!0,c.ptr=$newType(4,$kindPtr,\"*\"+r,!1,i,a,o),c.ptr.elem=c,c.ptr.prototype.$get=function(){return this},c.ptr.prototype.$set=function(e){c.copy(this,e)},c.init=function(e,n){c.pkgPath=e,c.fields=n,n.forEach(function(e){e.typ.comparable||(c.comparable=!1)}),c.keyFor=function(e){var r=e.$val;return $mapArray(n,function(e){return String(e.typ.keyFor(r[e.prop])).replace(/\\\\/g,\"\\\\\\\\\").replace(/\\$/g,\"\\\\$\")}).join(\"$\")},c.copy=function(e,r){for(var t=0;t<n.length;t++){var i=n[t];switch(i.typ.kind){case $kindArray:case $kindStruct:i.typ.copy(e[i.prop],r[i.prop]);continue;default:e[i.prop]=r[i.prop];continue}}};var r={};n.forEach(function(e){r[e.prop]={get:$throwNilPointerError,set:$throwNilPointerError}}),c.ptr.nil=Object.create(o.prototype,r),c.ptr.nil.$val=c.ptr.nil,$addMethodSynthesizer(function(){var e=function(e,n,r){void 0===e.prototype[n.prop]&&(e.prototype[n.prop]=function(){var e=this.$val[r.prop];return r.typ===$jsObjectPtr&&(e=new $jsObjectPtr(e)),void 0===e.$val&&(e=new r.typ(e)),e[n.prop].apply(e,arguments)})};n.forEach(function(n){n.embedded&&($methodSet(n.typ).forEach(function(r){e(c,r,n),e(c.ptr,r,n)}),$methodSet($ptrType(n.typ)).forEach(function(r){e(c.ptr,r,n)}))})})};break;default:$panic(new $String(\"invalid kind: \"+n))}switch(n){case $kindBool:case $kindMap:c.zero=function(){return!1};break;case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindUnsafePointer:case $kindFloat32:case $kindFloat64:c.zero=function(){return 0};break;case $kindString:c.zero=function(){return\"\"};break;case $kindInt64:case $kindUint64:case $kindComplex64:case $kindComplex128:var $=new c(0,0);c.zero=function(){return $};break;case $kindPtr:case $kindSlice:c.zero=function(){return c.nil};break;case $kindChan:c.zero=function(){return $chanNil};break;case $kindFunc:c.zero=function(){return $throwNilPointerError};break;case $kindInterface:c.zero=function(){return $ifaceNil};break;case $kindArray:c.zero=function(){var e=$nativeArray(c.elem.kind);if(e!==Array)return new e(c.len);for(var n=new Array(c.len),r=0;r<c.len;r++)n[r]=c.elem.zero();return n};break;case $kindStruct:c.zero=function(){return new c.ptr};break;default:$panic(new $String(\"invalid kind: \"+n))}return c.id=$typeIDCounter,$typeIDCounter++,c.size=e,c.kind=n,c.string=r,c.named=t,c.pkg=i,c.exported=a,c.methods=[],c.methodSetCache=null,c.comparable=!0,c},$methodSet=function(e){if(null!==e.methodSetCache)return e.methodSetCache;var n={},r=e.kind===$kindPtr;if(r&&e.elem.kind===$kindInterface)return e.methodSetCache=[],[];for(var t=[{typ:r?e.elem:e,indirect:r}],i={};t.length>0;){var a=[],o=[];t.forEach(function(e){if(!i[e.typ.string])switch(i[e.typ.string]=!0,e.typ.named&&(o=o.concat(e.typ.methods),e.indirect&&(o=o.concat($ptrType(e.typ).methods))),e.typ.kind){case $kindStruct:e.typ.fields.forEach(function(n){if(n.embedded){var r=n.typ,t=r.kind===$kindPtr;a.push({typ:t?r.elem:r,indirect:e.indirect||t})}});break;case $kindInterface:o=o.concat(e.typ.methods)}}),o.forEach(function(e){void 0===n[e.name]&&(n[e.name]=e)}),t=a}return e.methodSetCache=[],Object.keys(n).sort().forEach(function(r){e.methodSetCache.push(n[r])}),e.methodSetCache},$Bool=$newType(1,$kindBool,\"bool\",!0,\"\",!1,null),$Int=$newType(4,$kindInt,\"int\",!0,\"\",!1,null),$Int8=$newType(1,$kindInt8,\"int8\",!0,\"\",!1,null),$Int16=$newType(2,$kindInt16,\"int16\",!0,\"\",!1,null),$Int32=$newType(4,$kindInt32,\"int32\",!0,\"\",!1,null),$Int64=$newType(8,$kindInt64,\"int64\",!0,\"\",!1,null),$Uint=$newType(4,$kindUint,\"uint\",!0,\"\",!1,null),$Uint8=$newType(1,$kindUint8,\"uint8\",!0,\"\",!1,null),$Uint16=$newType(2,$kindUint16,\"uint16\",!0,\"\",!1,null),$Uint32=$newType(4,$kindUint32,\"uint32\",!0,\"\",!1,null),$Uint64=$newType(8,$kindUint64,\"uint64\",!0,\"\",!1,null),$Uintptr=$newType(4,$kindUintptr,\"uintptr\",!0,\"\",!1,null),$Float32=$newType(4,$kindFloat32,\"float32\",!0,\"\",!1,null),$Float64=$newType(8,$kindFloat64,\"float64\",!0,\"\",!1,null),$Complex64=$newType(8,$kindComplex64,\"complex64\",!0,\"\",!1,null),$Complex128=$newType(16,$kindComplex128,\"complex128\",!0,\"\",!1,null),$String=$newType(8,$kindString,\"string\",!0,\"\",!1,null),$UnsafePointer=$newType(4,$kindUnsafePointer,\"unsafe.Pointer\",!0,\"unsafe\",!1,null),$nativeArray=function(e){switch(e){case $kindInt:return Int32Array;case $kindInt8:return Int8Array;case $kindInt16:return Int16Array;case $kindInt32:return Int32Array;case $kindUint:return Uint32Array;case $kindUint8:return Uint8Array;case $kindUint16:return Uint16Array;case $kindUint32:case $kindUintptr:return Uint32Array;case $kindFloat32:return Float32Array;case $kindFloat64:return Float64Array;default:return Array}},$toNativeArray=function(e,n){var r=$nativeArray(e);return r===Array?n:new r(n)},$arrayTypes={},$arrayType=function(e,n){var r=e.id+\"$\"+n,t=$arrayTypes[r];return void 0===t&&(t=$newType(12,$kindArray,\"[\"+n+\"]\"+e.string,!1,\"\",!1,null),$arrayTypes[r]=t,t.init(e,n)),t},$chanType=function(e,n,r){var t=(r?\"<-\":\"\")+\"chan\"+(n?\"<- \":\" \");n||r||\"<\"!=e.string[0]?t+=e.string:t+=\"(\"+e.string+\")\";var i=n?\"SendChan\":r?\"RecvChan\":\"Chan\",a=e[i];return void 0===a&&(a=$newType(4,$kindChan,t,!1,\"\",!1,null),e[i]=a,a.init(e,n,r)),a},$Chan=function(e,n){(n<0||n>2147483647)&&$throwRuntimeError(\"makechan: size out of range\"),this.$elem=e,this.$capacity=n,this.$buffer=[],this.$sendQueue=[],this.$recvQueue=[],this.$closed=!1},$chanNil=new $Chan(null,0);$chanNil.$sendQueue=$chanNil.$recvQueue={length:0,push:function(){},shift:function(){},indexOf:function(){return-1}};var $funcTypes={},$funcType=function(e,n,r){var t=$mapArray(e,function(e){return e.id}).join(\",\")+\"$\"+$mapArray(n,function(e){return e.id}).join(\",\")+\"$\"+r,i=$funcTypes[t];if(void 0===i){var a=$mapArray(e,function(e){return e.string});r&&(a[a.length-1]=\"...\"+a[a.length-1].substr(2));var o=\"func(\"+a.join(\", \")+\")\";1===n.length?o+=\" \"+n[0].string:n.length>1&&(o+=\" (\"+$mapArray(n,function(e){return e.string}).join(\", \")+\")\"),i=$newType(4,$kindFunc,o,!1,\"\",!1,null),$funcTypes[t]=i,i.init(e,n,r)}return i},$interfaceTypes={},$interfaceType=function(e){var n=$mapArray(e,function(e){return e.pkg+\",\"+e.name+\",\"+e.typ.id}).join(\"$\"),r=$interfaceTypes[n];if(void 0===r){var t=\"interface {}\";0!==e.length&&(t=\"interface { \"+$mapArray(e,function(e){return(\"\"!==e.pkg?e.pkg+\".\":\"\")+e.name+e.typ.string.substr(4)}).join(\"; \")+\" }\"),r=$newType(8,$kindInterface,t,!1,\"\",!1,null),$interfaceTypes[n]=r,r.init(e)}return r},$emptyInterface=$interfaceType([]),$ifaceNil={},$error=$newType(8,$kindInterface,\"error\",!0,\"\",!1,null);$error.init([{prop:\"Error\",name:\"Error\",pkg:\"\",typ:$funcType([],[$String],!1)}]);var $panicValue,$jsObjectPtr,$jsErrorPtr,$mapTypes={},$mapType=function(e,n){var r=e.id+\"$\"+n.id,t=$mapTypes[r];return void 0===t&&(t=$newType(4,$kindMap,\"map[\"+e.string+\"]\"+n.string,!1,\"\",!1,null),$mapTypes[r]=t,t.init(e,n)),t},$makeMap=function(e,n){for(var r=new Map,t=0;t<n.length;t++){var i=n[t];r.set(e(i.k),i)}return r},$ptrType=function(e){var n=e.ptr;return void 0===n&&(n=$newType(4,$kindPtr,\"*\"+e.string,!1,\"\",e.exported,null),e.ptr=n,n.init(e)),n},$newDataPointer=function(e,n){return n.elem.kind===$kindStruct?e:new n(function(){return e},function(n){e=n})},$indexPtr=function(e,n,r){if(e.buffer){var t=e.buffer.$ptr=e.buffer.$ptr||{},i=t[e.name]=t[e.name]||{},a=e.BYTES_PER_ELEMENT*n+e.byteOffset;return i[a]||(i[a]=new r(function(){return e[n]},function(r){e[n]=r}))}return e.$ptr=e.$ptr||{},e.$ptr[n]||(e.$ptr[n]=new r(function(){return e[n]},function(r){e[n]=r}))},$sliceType=function(e){var n=e.slice;return void 0===n&&(n=$newType(12,$kindSlice,\"[]\"+e.string,!1,\"\",!1,null),e.slice=n,n.init(e)),n},$makeSlice=function(e,n,r){r=r||n,(n<0||n>2147483647)&&$throwRuntimeError(\"makeslice: len out of range\"),(r<0||r<n||r>2147483647)&&$throwRuntimeError(\"makeslice: cap out of range\");var t=new e.nativeArray(r);if(e.nativeArray===Array)for(var i=0;i<r;i++)t[i]=e.elem.zero();var a=new e(t);return a.$length=n,a},$structTypes={},$structType=function(e,n){var r=$mapArray(n,function(e){return e.name+\",\"+e.typ.id+\",\"+e.tag}).join(\"$\"),t=$structTypes[r];if(void 0===t){var i=\"struct { \"+$mapArray(n,function(e){var n=e.typ.string+(\"\"!==e.tag?' \"'+e.tag.replace(/\\\\/g,\"\\\\\\\\\").replace(/\"/g,'\\\\\"')+'\"':\"\");return e.embedded?n:e.name+\" \"+n}).join(\"; \")+\" }\";0===n.length&&(i=\"struct {}\"),t=$newType(0,$kindStruct,i,!1,\"\",!1,function(){this.$val=this;for(var e=0;e<n.length;e++){var r=n[e];if(\"_\"!=r.name){var t=arguments[e];this[r.prop]=void 0!==t?t:r.typ.zero()}}}),$structTypes[r]=t,t.init(e,n)}return t},$assertType=function(e,n,r){var t,i=n.kind===$kindInterface,a=\"\";if(e===$ifaceNil)t=!1;else if(i){var o=e.constructor.string;if(void 0===(t=n.implementedBy[o])){t=!0;for(var c=$methodSet(e.constructor),$=n.methods,u=0;u<$.length;u++){for(var l=$[u],s=!1,f=0;f<c.length;f++){var d=c[f];if(d.name===l.name&&d.pkg===l.pkg&&d.typ===l.typ){s=!0;break}}if(!s){t=!1,n.missingMethodFor[o]=l.name;break}}n.implementedBy[o]=t}t||(a=n.missingMethodFor[o])}else t=e.constructor===n;if(!t){if(r)return[n.zero(),!1];$panic(new $packages.runtime.TypeAssertionError.ptr($packages.runtime._type.ptr.nil,e===$ifaceNil?$packages.runtime._type.ptr.nil:new $packages.runtime._type.ptr(e.constructor.string),new $packages.runtime._type.ptr(n.string),a))}return i||(e=e.$val),n===$jsObjectPtr&&(e=e.object),r?[e,!0]:e},$stackDepthOffset=0,$getStackDepth=function(){var e=new Error;if(void 0!==e.stack)return $stackDepthOffset+e.stack.split(\"\\n\").length},$panicStackDepth=null,$callDeferred=function(e,n,r){if(!r&&null!==e&&-1==$curGoroutine.deferStack.indexOf(e))throw n;if(null===n){if(!$curGoroutine.asleep){$stackDepthOffset--;var t=$panicStackDepth,i=$panicValue,a=$curGoroutine.panicStack.pop();void 0!==a&&($panicStackDepth=$getStackDepth(),$panicValue=a);try{for(;;){if(null===e&&void 0===(e=$curGoroutine.deferStack[$curGoroutine.deferStack.length-1])){if($panicStackDepth=null,a.Object instanceof Error)throw a.Object;var o;throw o=a.constructor===$String?a.$val:void 0!==a.Error?a.Error():void 0!==a.String?a.String():a,new Error(o)}var c=e.pop();if(void 0===c){if($curGoroutine.deferStack.pop(),void 0!==a){e=null;continue}return}var $=c[0].apply(c[2],c[1]);if($&&void 0!==$.$blk){if(e.push([$.$blk,[],$]),r)throw null;return}if(void 0!==a&&null===$panicStackDepth){if(r)throw null;return}}}catch(n){if(r)throw n;$callDeferred(e,n,r)}finally{void 0!==a&&(null!==$panicStackDepth&&$curGoroutine.panicStack.push(a),$panicStackDepth=t,$panicValue=i),$stackDepthOffset++}}}else{var u=null;try{$panic(new $jsErrorPtr(n))}catch(e){u=e}$callDeferred(e,u)}},$panic=function(e){$curGoroutine.panicStack.push(e),$callDeferred(null,null,!0)},$recover=function(){return null===$panicStackDepth||void 0!==$panicStackDepth&&$panicStackDepth!==$getStackDepth()-2?$ifaceNil:($panicStackDepth=null,$panicValue)},$throw=function(e){throw e},$noGoroutine={asleep:!1,exit:!1,deferStack:[],panicStack:[]},$curGoroutine=$noGoroutine,$totalGoroutines=0,$awakeGoroutines=0,$checkForDeadlock=!0,$exportedFunctions=0,$mainFinished=!1,$go=function(e,n){$totalGoroutines++,$awakeGoroutines++;var $goroutine=function(){try{$curGoroutine=$goroutine;var r=e.apply(void 0,n);if(r&&void 0!==r.$blk)return e=function(){return r.$blk()},void(n=[]);$goroutine.exit=!0}catch(e){if(!$goroutine.exit)throw e}finally{$curGoroutine=$noGoroutine,$goroutine.exit&&($totalGoroutines--,$goroutine.asleep=!0),$goroutine.asleep&&($awakeGoroutines--,!$mainFinished&&0===$awakeGoroutines&&$checkForDeadlock&&0===$exportedFunctions&&(console.error(\"fatal error: all goroutines are asleep - deadlock!\"),void 0!==$global.process&&$global.process.exit(2)))}};$goroutine.asleep=!1,$goroutine.exit=!1,$goroutine.deferStack=[],$goroutine.panicStack=[],$schedule($goroutine)},$scheduled=[],$runScheduled=function(){var e=setTimeout($runScheduled);try{for(var n,r=Date.now();void 0!==(n=$scheduled.shift());){n();var t=Date.now()-r;if(t>4||t<0)break}}finally{0==$scheduled.length&&clearTimeout(e)}},$schedule=function(e){e.asleep&&(e.asleep=!1,$awakeGoroutines++),$scheduled.push(e),$curGoroutine===$noGoroutine&&$runScheduled()},$setTimeout=function(e,n){return $awakeGoroutines++,setTimeout(function(){$awakeGoroutines--,e()},n)},$block=function(){$curGoroutine===$noGoroutine&&$throwRuntimeError(\"cannot block in JavaScript callback, fix by wrapping code in goroutine\"),$curGoroutine.asleep=!0},$restore=function(e,n){return void 0!==e&&void 0!==e.$blk?e:n},$send=function(e,n){e.$closed&&$throwRuntimeError(\"send on closed channel\");var r=e.$recvQueue.shift();if(void 0===r){if(!(e.$buffer.length<e.$capacity)){var t,i=$curGoroutine;return e.$sendQueue.push(function(e){return t=e,$schedule(i),n}),$block(),{$blk:function(){t&&$throwRuntimeError(\"send on closed channel\")}}}e.$buffer.push(n)}else r([n,!0])},$recv=function(e){var n=e.$sendQueue.shift();void 0!==n&&e.$buffer.push(n(!1));var r=e.$buffer.shift();if(void 0!==r)return[r,!0];if(e.$closed)return[e.$elem.zero(),!1];var t=$curGoroutine,i={$blk:function(){return this.value}};return e.$recvQueue.push(function(e){i.value=e,$schedule(t)}),$block(),i},$close=function(e){for(e.$closed&&$throwRuntimeError(\"close of closed channel\"),e.$closed=!0;;){var n=e.$sendQueue.shift();if(void 0===n)break;n(!0)}for(;;){var r=e.$recvQueue.shift();if(void 0===r)break;r([e.$elem.zero(),!1])}},$select=function(e){for(var n=[],r=-1,t=0;t<e.length;t++){var i,a=(i=e[t])[0];switch(i.length){case 0:r=t;break;case 1:(0!==a.$sendQueue.length||0!==a.$buffer.length||a.$closed)&&n.push(t);break;case 2:a.$closed&&$throwRuntimeError(\"send on closed channel\"),(0!==a.$recvQueue.length||a.$buffer.length<a.$capacity)&&n.push(t)}}if(0!==n.length&&(r=n[Math.floor(Math.random()*n.length)]),-1!==r)switch((i=e[r]).length){case 0:return[r];case 1:return[r,$recv(i[0])];case 2:return $send(i[0],i[1]),[r]}var o=[],c=$curGoroutine,$={$blk:function(){return this.selection}},u=function(){for(var e=0;e<o.length;e++){var n=o[e],r=n[0],t=r.indexOf(n[1]);-1!==t&&r.splice(t,1)}};for(t=0;t<e.length;t++)!function(n){var r=e[n];switch(r.length){case 1:var t=function(e){$.selection=[n,e],u(),$schedule(c)};o.push([r[0].$recvQueue,t]),r[0].$recvQueue.push(t);break;case 2:t=function(){return r[0].$closed&&$throwRuntimeError(\"send on closed channel\"),$.selection=[n],u(),$schedule(c),r[1]};o.push([r[0].$sendQueue,t]),r[0].$sendQueue.push(t)}}(t);return $block(),$},$needsExternalization=function(e){switch(e.kind){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindFloat32:case $kindFloat64:return!1;default:return e!==$jsObjectPtr}},$externalize=function(e,n,r){if(n===$jsObjectPtr)return e;switch(n.kind){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindFloat32:case $kindFloat64:return e;case $kindInt64:case $kindUint64:return $flatten64(e);case $kindArray:return $needsExternalization(n.elem)?$mapArray(e,function(e){return $externalize(e,n.elem,r)}):e;case $kindFunc:return $externalizeFunction(e,n,!1,r);case $kindInterface:return e===$ifaceNil?null:e.constructor===$jsObjectPtr?e.$val.object:$externalize(e.$val,e.constructor,r);case $kindMap:for(var t={},i=Array.from(e.keys()),a=0;a<i.length;a++){var o=e.get(i[a]);t[$externalize(o.k,n.key,r)]=$externalize(o.v,n.elem,r)}return t;case $kindPtr:return e===n.nil?null:$externalize(e.$get(),n.elem,r);case $kindSlice:return $needsExternalization(n.elem)?$mapArray($sliceToNativeArray(e),function(e){return $externalize(e,n.elem,r)}):$sliceToNativeArray(e);case $kindString:if($isASCII(e))return e;var c,$=\"\";for(a=0;a<e.length;a+=c[1]){var u=(c=$decodeRune(e,a))[0];if(u>65535){var l=Math.floor((u-65536)/1024)+55296,s=(u-65536)%1024+56320;$+=String.fromCharCode(l,s)}else $+=String.fromCharCode(u)}return $;case $kindStruct:var f=$packages.time;if(void 0!==f&&e.constructor===f.Time.ptr){var d=$div64(e.UnixNano(),new $Int64(0,1e6));return new Date($flatten64(d))}var p={},h=function(e,n){if(n===$jsObjectPtr)return e;switch(n.kind){case $kindPtr:return e===n.nil?p:h(e.$get(),n.elem);case $kindStruct:var r=n.fields[0];return h(e[r.prop],r.typ);case $kindInterface:return h(e.$val,e.constructor);default:return p}},k=h(e,n);if(k!==p)return k;if(void 0!==r)return r(e);k={};for(a=0;a<n.fields.length;a++){var y=n.fields[a];y.exported&&(k[y.name]=$externalize(e[y.prop],y.typ,r))}return k}$throwRuntimeError(\"cannot externalize \"+n.string)},$externalizeFunction=function(e,n,r,t){return e===$throwNilPointerError?null:(void 0===e.$externalizeWrapper&&($checkForDeadlock=!1,e.$externalizeWrapper=function(){for(var i=[],a=0;a<n.params.length;a++){if(n.variadic&&a===n.params.length-1){for(var o=n.params[a].elem,c=[],$=a;$<arguments.length;$++)c.push($internalize(arguments[$],o,t));i.push(new n.params[a](c));break}i.push($internalize(arguments[a],n.params[a],t))}var u=e.apply(r?this:void 0,i);switch(n.results.length){case 0:return;case 1:return $externalize($copyIfRequired(u,n.results[0]),n.results[0],t);default:for(a=0;a<n.results.length;a++)u[a]=$externalize($copyIfRequired(u[a],n.results[a]),n.results[a],t);return u}}),e.$externalizeWrapper)},$internalize=function(e,n,r,t,i){if(n===$jsObjectPtr)return e;if(n===$jsObjectPtr.elem&&$throwRuntimeError(\"cannot internalize js.Object, use *js.Object instead\"),e&&void 0!==e.__internal_object__)return $assertType(e.__internal_object__,n,!1);var a=$packages.time;if(void 0!==a&&n===a.Time)return null!==e&&void 0!==e&&e.constructor===Date||$throwRuntimeError(\"cannot internalize time.Time from \"+typeof e+\", must be Date\"),a.Unix(new $Int64(0,0),new $Int64(0,1e6*e.getTime()));if(void 0===t&&(t=new Map),t.has(n)||t.set(n,new Map),t.get(n).has(e))return t.get(n).get(e);switch(n.kind){case $kindBool:return!!e;case $kindInt:return parseInt(e);case $kindInt8:return parseInt(e)<<24>>24;case $kindInt16:return parseInt(e)<<16>>16;case $kindInt32:return parseInt(e)>>0;case $kindUint:return parseInt(e);case $kindUint8:return parseInt(e)<<24>>>24;case $kindUint16:return parseInt(e)<<16>>>16;case $kindUint32:case $kindUintptr:return parseInt(e)>>>0;case $kindInt64:case $kindUint64:return new n(0,e);case $kindFloat32:case $kindFloat64:return parseFloat(e);case $kindArray:return e.length!==n.len&&$throwRuntimeError(\"got array with wrong size from JavaScript native\"),$mapArray(e,function(e){return $internalize(e,n.elem,i)});case $kindFunc:return function(){for(var t=[],a=0;a<n.params.length;a++){if(n.variadic&&a===n.params.length-1){for(var o=n.params[a].elem,c=arguments[a],$=0;$<c.$length;$++)t.push($externalize(c.$array[c.$offset+$],o,i));break}t.push($externalize(arguments[a],n.params[a],i))}var u=e.apply(r,t);switch(n.results.length){case 0:return;case 1:return $internalize(u,n.results[0],i);default:for(a=0;a<n.results.length;a++)u[a]=$internalize(u[a],n.results[a],i);return u}};case $kindInterface:if(0!==n.methods.length&&$throwRuntimeError(\"cannot internalize \"+n.string),null===e)return $ifaceNil;if(void 0===e)return new $jsObjectPtr(void 0);switch(e.constructor){case Int8Array:return new($sliceType($Int8))(e);case Int16Array:return new($sliceType($Int16))(e);case Int32Array:return new($sliceType($Int))(e);case Uint8Array:return new($sliceType($Uint8))(e);case Uint16Array:return new($sliceType($Uint16))(e);case Uint32Array:return new($sliceType($Uint))(e);case Float32Array:return new($sliceType($Float32))(e);case Float64Array:return new($sliceType($Float64))(e);case Array:return $internalize(e,$sliceType($emptyInterface),i);case Boolean:return new $Bool(!!e);case Date:return void 0===a?new $jsObjectPtr(e):new a.Time($internalize(e,a.Time,i));case function(){}.constructor:var o=$funcType([$sliceType($emptyInterface)],[$jsObjectPtr],!0);return new o($internalize(e,o,i));case Number:return new $Float64(parseFloat(e));case String:return new $String($internalize(e,$String,i));default:if($global.Node&&e instanceof $global.Node)return new $jsObjectPtr(e);var c=$mapType($String,$emptyInterface);return new c($internalize(e,c,r,t,i))}case $kindMap:var $=new Map;t.get(n).set(e,$);for(var u=$keys(e),l=0;l<u.length;l++){var s=$internalize(u[l],n.key,r,t,i);$.set(n.key.keyFor(s),{k:s,v:$internalize(e[u[l]],n.elem,r,t,i)})}return $;case $kindPtr:if(n.elem.kind===$kindStruct)return $internalize(e,n.elem,i);case $kindSlice:return new n($mapArray(e,function(e){return $internalize(e,n.elem,i)}));case $kindString:if(e=String(e),$isASCII(e))return e;var f=\"\";for(l=0;l<e.length;){var d=e.charCodeAt(l);if(55296<=d&&d<=56319){var p=e.charCodeAt(l+1);f+=$encodeRune(1024*(d-55296)+p-56320+65536),l+=2}else f+=$encodeRune(d),l++}return f;case $kindStruct:var h={},k=function(n){if(n===$jsObjectPtr)return e;switch(n===$jsObjectPtr.elem&&$throwRuntimeError(\"cannot internalize js.Object, use *js.Object instead\"),n.kind){case $kindPtr:return k(n.elem);case $kindStruct:var r=n.fields[0],t=k(r.typ);if(t!==h){var i=new n.ptr;return i[r.prop]=t,i}return h;default:return h}},y=k(n);if(y!==h)return y}$throwRuntimeError(\"cannot internalize \"+n.string)},$copyIfRequired=function(e,n){if(e&&e.constructor&&e.constructor.copy)return new e.constructor($clone(e.$val,e.constructor));if(n.copy){var r=n.zero();return n.copy(r,e),r}return e},$isASCII=function(e){for(var n=0;n<e.length;n++)if(e.charCodeAt(n)>=128)return!1;return!0};\n"
+const Minified = "Error.stackTraceLimit=1/0;var $NaN=NaN,$global,$module;if(typeof window!=\"undefined\"?$global=window:typeof self!=\"undefined\"?$global=self:typeof global!=\"undefined\"?($global=global,typeof require!=\"undefined\"&&($global.require=require)):$global=this,$global===void 0||$global.Array===void 0)throw new Error(\"no global object found\");if(typeof module!=\"undefined\"&&($module=module),!$global.fs&&$global.require)try{var fs=$global.require(\"fs\");typeof fs==\"object\"&&fs!==null&&Object.keys(fs).length!==0&&($global.fs=fs)}catch(r){}if(!$global.fs){var outputBuf=\"\",decoder=new TextDecoder(\"utf-8\");$global.fs={constants:{O_WRONLY:-1,O_RDWR:-1,O_CREAT:-1,O_TRUNC:-1,O_APPEND:-1,O_EXCL:-1},writeSync:function(e,n){outputBuf+=decoder.decode(n);var t=outputBuf.lastIndexOf(`\n`);return t!=-1&&(console.log(outputBuf.substr(0,t)),outputBuf=outputBuf.substr(t+1)),n.length},write:function(e,n,t,i,u,o){if(t!==0||i!==n.length||u!==null){o(enosys());return}var a=this.writeSync(e,n);o(null,a)}}}var $linknames={},$packages={},$idCounter=0,$keys=function(r){return r?Object.keys(r):[]},$flushConsole=function(){},$throwRuntimeError,$throwNilPointerError=function(){$throwRuntimeError(\"invalid memory address or nil pointer dereference\")},$call=function(r,e,n){return r.apply(e,n)},$makeFunc=function(r){return function(){return $externalize(r(this,new($sliceType($jsObjectPtr))($global.Array.prototype.slice.call(arguments,[]))),$emptyInterface)}},$unused=function(r){},$print=console.log;if($global.process!==void 0&&$global.require)try{var util=$global.require(\"util\");$print=function(){$global.process.stderr.write(util.format.apply(this,arguments))}}catch(r){}var $println=console.log,$initAllLinknames=function(){for(var r=$keys($packages),e=0;e<r.length;e++){var n=$packages[r[e]].$initLinknames;typeof n==\"function\"&&n()}},$embedNames=function(r,e){for(var n=r.$embeds,t=[],i=0;i<e.length;i++){var u=n!==void 0?n.patterns[e[i]]:void 0;u===void 0&&$throwRuntimeError(\"go:embed: pattern \"+e[i]+\" has not been resolved by the build\");for(var o=0;o<u.length;o++)t.indexOf(u[o])===-1&&t.push(u[o])}return t.sort()},$embedString=function(r,e){var n=$embedNames(r,e);return n.length!==1&&$throwRuntimeError(\"go:embed: multiple files for a string or []byte variable\"),r.$embeds.files[n[0]]},$embedFS=function(r,e,n){for(var t=$embedNames(e,n),i={},u=0;u<t.length;u++){i[t[u]]=e.$embeds.files[t[u]];for(var o=t[u];o.lastIndexOf(\"/\")!==-1;)o=o.substring(0,o.lastIndexOf(\"/\")),i[o+\"/\"]=\"\"}var a=function(s){s[s.length-1]===\"/\"&&(s=s.substring(0,s.length-1));var h=s.lastIndexOf(\"/\");return h===-1?[\".\",s]:[s.substring(0,h),s.substring(h+1)]},l=$keys(i).sort(function(s,h){var d=a(s),v=a(h);return d[0]!==v[0]?d[0]<v[0]?-1:1:d[1]<v[1]?-1:d[1]>v[1]?1:0}),f=r.fields[0].typ,c=f.elem.elem,$=$mapArray(l,function(s){var h=new c.ptr;return h[c.fields[0].prop]=s,h[c.fields[1].prop]=i[s],h});return new r.ptr($newDataPointer(new f.elem($),f))},$mapArray=function(r,e){for(var n=new r.constructor(r.length),t=0;t<r.length;t++)n[t]=e(r[t]);return n},$mapIndex=function(r,e){return typeof r.get==\"function\"?r.get(e):void 0},$mapDelete=function(r,e){typeof r.delete==\"function\"&&r.delete(e)},$methodWrapper=function(r){return Object.defineProperty(r,\"name\",{value:\"$methodWrapper\"}),r},$methodVal=function(r,e){var n=r.$methodVals||{};r.$methodVals=n;var t=n[e];if(t!==void 0)return t;var i=r[e];return t=i.bind(r),n[e]=t,t},$methodExpr=function(r,e){var n=r.prototype[e];return n.$expr===void 0&&(n.$expr=$methodWrapper(function(){$stackDepthOffset--;try{return r.wrapped&&(arguments[0]=new r(arguments[0])),Function.call.apply(n,arguments)}finally{$stackDepthOffset++}})),n.$expr},$ifaceMethodExprs={},$ifaceMethodExpr=function(r){var e=$ifaceMethodExprs[\"$\"+r];return e===void 0&&(e=$ifaceMethodExprs[\"$\"+r]=$methodWrapper(function(){$stackDepthOffset--;try{return Function.call.apply(arguments[0][r],arguments)}finally{$stackDepthOffset++}})),e},$subslice=function(r,e,n,t){if(n===void 0&&(n=r.$length),t===void 0&&(t=r.$capacity),(e<0||n<e||t<n||n>r.$capacity||t>r.$capacity)&&$throwRuntimeError(\"slice bounds out of range\"),r===r.constructor.nil)return r;var i=new r.constructor(r.$array);return i.$offset=r.$offset+e,i.$length=n-e,i.$capacity=t-e,i},$substring=function(r,e,n){return(e<0||n<e||n>r.length)&&$throwRuntimeError(\"slice bounds out of range\"),r.substring(e,n)},$sliceToNativeArray=function(r){return r.$array.constructor!==Array?r.$array.subarray(r.$offset,r.$offset+r.$length):r.$array.slice(r.$offset,r.$offset+r.$length)},$sliceToGoArray=function(r,e){var n=e.elem;if(n!==void 0&&r.$length<n.len&&$throwRuntimeError(\"cannot convert slice with length \"+r.$length+\" to pointer to array with length \"+n.len),r==r.constructor.nil)return e.nil;if(r.$array.constructor!==Array)return r.$array.subarray(r.$offset,r.$offset+n.len);if(r.$offset==0&&r.$length==r.$capacity&&r.$length==n.len)return r.$array;if(n.len==0)return new n([]);$throwRuntimeError(\"gopherjs: non-numeric slice to underlying array conversion is not supported for subslices\")},$convertSliceType=function(r,e){return r==r.constructor.nil?e.nil:$subslice(new e(r.$array),r.$offset,r.$offset+r.$length)},$decodeRune=function(r,e){var n=r.charCodeAt(e);if(n<128)return[n,1];if(n!==n||n<192)return[65533,1];var t=r.charCodeAt(e+1);if(t!==t||t<128||192<=t)return[65533,1];if(n<224){var i=(n&31)<<6|t&63;return i<=127?[65533,1]:[i,2]}var u=r.charCodeAt(e+2);if(u!==u||u<128||192<=u)return[65533,1];if(n<240){var i=(n&15)<<12|(t&63)<<6|u&63;return i<=2047?[65533,1]:55296<=i&&i<=57343?[65533,1]:[i,3]}var o=r.charCodeAt(e+3);if(o!==o||o<128||192<=o)return[65533,1];if(n<248){var i=(n&7)<<18|(t&63)<<12|(u&63)<<6|o&63;return i<=65535||1114111<i?[65533,1]:[i,4]}return[65533,1]},$encodeRune=function(r){return(r<0||r>1114111||55296<=r&&r<=57343)&&(r=65533),r<=127?String.fromCharCode(r):r<=2047?String.fromCharCode(192|r>>6,128|r&63):r<=65535?String.fromCharCode(224|r>>12,128|r>>6&63,128|r&63):String.fromCharCode(240|r>>18,128|r>>12&63,128|r>>6&63,128|r&63)},$stringToBytes=function(r){for(var e=new Uint8Array(r.length),n=0;n<r.length;n++)e[n]=r.charCodeAt(n);return e},$bytesToString=function(r){if(r.$length===0)return\"\";for(var e=\"\",n=0;n<r.$length;n+=1e4)e+=String.fromCharCode.apply(void 0,r.$array.subarray(r.$offset+n,r.$offset+Math.min(r.$length,n+1e4)));return e},$stringToRunes=function(r){for(var e=new Int32Array(r.length),n,t=0,i=0;i<r.length;i+=n[1],t++)n=$decodeRune(r,i),e[t]=n[0];return e.subarray(0,t)},$runesToString=function(r){if(r.$length===0)return\"\";for(var e=\"\",n=0;n<r.$length;n++)e+=$encodeRune(r.$array[r.$offset+n]);return e},$copyString=function(r,e){for(var n=Math.min(e.length,r.$length),t=0;t<n;t++)r.$array[r.$offset+t]=e.charCodeAt(t);return n},$copySlice=function(r,e){var n=Math.min(e.$length,r.$length);return $copyArray(r.$array,e.$array,r.$offset,e.$offset,n,r.constructor.elem),n},$copyArray=function(r,e,n,t,i,u){if(!(i===0||r===e&&n===t)){if(e.subarray){r.set(e.subarray(t,t+i),n);return}switch(u.kind){case $kindArray:case $kindStruct:if(r===e&&n>t){for(var o=i-1;o>=0;o--)u.copy(r[n+o],e[t+o]);return}for(var o=0;o<i;o++)u.copy(r[n+o],e[t+o]);return}if(r===e&&n>t){for(var o=i-1;o>=0;o--)r[n+o]=e[t+o];return}for(var o=0;o<i;o++)r[n+o]=e[t+o]}},$clone=function(r,e){var n=e.zero();return e.copy(n,r),n},$pointerOfStructConversion=function(r,e){r.$proxies===void 0&&(r.$proxies={},r.$proxies[r.constructor.string]=r);var n=r.$proxies[e.string];if(n===void 0){for(var t={},i=0;i<e.elem.fields.length;i++)(function(u){t[u]={get:function(){return r[u]},set:function(o){r[u]=o}}})(e.elem.fields[i].prop);n=Object.create(e.prototype,t),n.$val=n,r.$proxies[e.string]=n,n.$proxies=r.$proxies}return n},$trackAllocations=!1,$mallocs=0,$totalAlloc=0,$append=function(r){return $internalAppend(r,arguments,1,arguments.length-1)},$appendSlice=function(r,e){if(e.constructor===String){var n=$stringToBytes(e);return $internalAppend(r,n,0,n.length)}return $internalAppend(r,e.$array,e.$offset,e.$length)},$internalAppend=function(r,e,n,t){if(t===0)return r;var i=r.$array,u=r.$offset,o=r.$length+t,a=r.$capacity;if(o>a)if(u=0,a=Math.max(o,r.$capacity<1024?r.$capacity*2:Math.floor(r.$capacity*5/4)),$trackAllocations&&($mallocs++,$totalAlloc+=a*r.constructor.elem.size),r.$array.constructor===Array){i=r.$array.slice(r.$offset,r.$offset+r.$length),i.length=a;for(var l=r.constructor.elem.zero,f=r.$length;f<a;f++)i[f]=l()}else i=new r.$array.constructor(a),i.set(r.$array.subarray(r.$offset,r.$offset+r.$length));$copyArray(i,e,u+r.$length,n,t,r.constructor.elem);var c=new r.constructor(i);return c.$offset=u,c.$length=o,c.$capacity=a,c},$equal=function(r,e,n){if(n===$jsObjectPtr)return r===e;switch(n.kind){case $kindComplex64:case $kindComplex128:return r.$real===e.$real&&r.$imag===e.$imag;case $kindInt64:case $kindUint64:return r.$high===e.$high&&r.$low===e.$low;case $kindArray:if(r.length!==e.length)return!1;for(var t=0;t<r.length;t++)if(!$equal(r[t],e[t],n.elem))return!1;return!0;case $kindStruct:for(var t=0;t<n.fields.length;t++){var i=n.fields[t];if(!$equal(r[i.prop],e[i.prop],i.typ))return!1}return!0;case $kindInterface:return $interfaceIsEqual(r,e);default:return r===e}},$interfaceIsEqual=function(r,e){return r===$ifaceNil||e===$ifaceNil?r===e:r.constructor!==e.constructor?!1:r.constructor===$jsObjectPtr?r.object===e.object:(r.constructor.comparable||$throwRuntimeError(\"comparing uncomparable type \"+r.constructor.string),$equal(r.$val,e.$val,r.constructor))},$min=Math.min,$mod=function(r,e){return r%e},$parseInt=parseInt,$parseFloat=function(r){return r!=null&&r.constructor===Number?r:parseFloat(r)},$froundBuf=new Float32Array(1),$fround=Math.fround||function(r){return $froundBuf[0]=r,$froundBuf[0]},$imul=Math.imul||function(r,e){var n=r>>>16&65535,t=r&65535,i=e>>>16&65535,u=e&65535;return t*u+(n*u+t*i<<16>>>0)>>0},$floatKey=function(r){return r!==r?($idCounter++,\"NaN$\"+$idCounter):String(r)},$flatten64=function(r){return r.$high*4294967296+r.$low},$shiftLeft64=function(r,e){return e===0?r:e<32?new r.constructor(r.$high<<e|r.$low>>>32-e,r.$low<<e>>>0):e<64?new r.constructor(r.$low<<e-32,0):new r.constructor(0,0)},$shiftRightInt64=function(r,e){return e===0?r:e<32?new r.constructor(r.$high>>e,(r.$low>>>e|r.$high<<32-e)>>>0):e<64?new r.constructor(r.$high>>31,r.$high>>e-32>>>0):r.$high<0?new r.constructor(-1,4294967295):new r.constructor(0,0)},$shiftRightUint64=function(r,e){return e===0?r:e<32?new r.constructor(r.$high>>>e,(r.$low>>>e|r.$high<<32-e)>>>0):e<64?new r.constructor(0,r.$high>>>e-32):new r.constructor(0,0)},$mul64=function(r,e){var n=r.$high>>>16,t=r.$high&65535,i=r.$low>>>16,u=r.$low&65535,o=e.$high>>>16,a=e.$high&65535,l=e.$low>>>16,f=e.$low&65535,c=0,$=0,s=0,h=0;h+=u*f,s+=h>>>16,h&=65535,s+=i*f,$+=s>>>16,s&=65535,s+=u*l,$+=s>>>16,s&=65535,$+=t*f,c+=$>>>16,$&=65535,$+=i*l,c+=$>>>16,$&=65535,$+=u*a,c+=$>>>16,$&=65535,c+=n*f+t*l+i*a+u*o,c&=65535;var d=(c<<16|$)>>>0,v=(s<<16|h)>>>0,g=new r.constructor(d,v);return g},$div64=function(r,e,n){e.$high===0&&e.$low===0&&$throwRuntimeError(\"integer divide by zero\");var t=1,i=1,u=r.$high,o=r.$low;u<0&&(t=-1,i=-1,u=-u,o!==0&&(u--,o=4294967296-o));var a=e.$high,l=e.$low;e.$high<0&&(t*=-1,a=-a,l!==0&&(a--,l=4294967296-l));for(var f=0,c=0,$=0;a<2147483648&&(u>a||u===a&&o>l);)a=(a<<1|l>>>31)>>>0,l=l<<1>>>0,$++;for(var s=0;s<=$;s++)f=f<<1|c>>>31,c=c<<1>>>0,(u>a||u===a&&o>=l)&&(u=u-a,o=o-l,o<0&&(u--,o+=4294967296),c++,c===4294967296&&(f++,c=0)),l=(l>>>1|a<<31)>>>0,a=a>>>1;return n?new r.constructor(u*i,o*i):new r.constructor(f*t,c*t)},$divComplex=function(r,e){var n=r.$real===1/0||r.$real===-1/0||r.$imag===1/0||r.$imag===-1/0,t=e.$real===1/0||e.$real===-1/0||e.$imag===1/0||e.$imag===-1/0,i=!n&&(r.$real!==r.$real||r.$imag!==r.$imag),u=!t&&(e.$real!==e.$real||e.$imag!==e.$imag);if(i||u)return new r.constructor(NaN,NaN);if(n&&!t)return new r.constructor(1/0,1/0);if(!n&&t)return new r.constructor(0,0);if(e.$real===0&&e.$imag===0)return r.$real===0&&r.$imag===0?new r.constructor(NaN,NaN):new r.constructor(1/0,1/0);var o=Math.abs(e.$real),a=Math.abs(e.$imag);if(o<=a){var l=e.$real/e.$imag,f=e.$real*l+e.$imag;return new r.constructor((r.$real*l+r.$imag)/f,(r.$imag*l-r.$real)/f)}var l=e.$imag/e.$real,f=e.$imag*l+e.$real;return new r.constructor((r.$imag*l+r.$real)/f,(r.$imag-r.$real*l)/f)},$kindBool=1,$kindInt=2,$kindInt8=3,$kindInt16=4,$kindInt32=5,$kindInt64=6,$kindUint=7,$kindUint8=8,$kindUint16=9,$kindUint32=10,$kindUint64=11,$kindUintptr=12,$kindFloat32=13,$kindFloat64=14,$kindComplex64=15,$kindComplex128=16,$kindArray=17,$kindChan=18,$kindFunc=19,$kindInterface=20,$kindMap=21,$kindPtr=22,$kindSlice=23,$kindString=24,$kindStruct=25,$kindUnsafePointer=26,$methodSynthesizers=[],$addMethodSynthesizer=function(r){if($methodSynthesizers===null){r();return}$methodSynthesizers.push(r)},$synthesizeMethods=function(){$methodSynthesizers.forEach(function(r){r()}),$methodSynthesizers=null},$ifaceKeyFor=function(r){if(r===$ifaceNil)return\"nil\";var e=r.constructor;return e.string+\"$\"+e.keyFor(r.$val)},$identity=function(r){return r},$typeIDCounter=0,$idKey=function(r){return r.$id===void 0&&($idCounter++,r.$id=$idCounter),String(r.$id)},$arrayPtrCtor=function(){return function(r){this.$get=function(){return r},this.$set=function(e){typ.copy(this,e)},this.$val=r}},$newType=function(r,e,n,t,i,u,o){var a;switch(e){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindUnsafePointer:a=function(f){this.$val=f},a.wrapped=!0,a.keyFor=$identity;break;case $kindString:a=function(f){this.$val=f},a.wrapped=!0,a.keyFor=function(f){return\"$\"+f};break;case $kindFloat32:case $kindFloat64:a=function(f){this.$val=f},a.wrapped=!0,a.keyFor=function(f){return $floatKey(f)};break;case $kindInt64:a=function(f,c){this.$high=f+Math.floor(Math.ceil(c)/4294967296)>>0,this.$low=c>>>0,this.$val=this},a.keyFor=function(f){return f.$high+\"$\"+f.$low};break;case $kindUint64:a=function(f,c){this.$high=f+Math.floor(Math.ceil(c)/4294967296)>>>0,this.$low=c>>>0,this.$val=this},a.keyFor=function(f){return f.$high+\"$\"+f.$low};break;case $kindComplex64:a=function(f,c){this.$real=$fround(f),this.$imag=$fround(c),this.$val=this},a.keyFor=function(f){return f.$real+\"$\"+f.$imag};break;case $kindComplex128:a=function(f,c){this.$real=f,this.$imag=c,this.$val=this},a.keyFor=function(f){return f.$real+\"$\"+f.$imag};break;case $kindArray:a=function(f){this.$val=f},a.wrapped=!0,a.ptr=$newType(4,$kindPtr,\"*\"+n,!1,\"\",!1,$arrayPtrCtor()),a.init=function(f,c){a.elem=f,a.len=c,a.comparable=f.comparable,a.keyFor=function($){return Array.prototype.join.call($mapArray($,function(s){return String(f.keyFor(s)).replace(/\\\\/g,\"\\\\\\\\\").replace(/\\$/g,\"\\\\$\")}),\"$\")},a.copy=function($,s){$copyArray($,s,0,0,s.length,f)},a.ptr.init(a),Object.defineProperty(a.ptr.nil,\"nilCheck\",{get:$throwNilPointerError})};break;case $kindChan:a=function(f){this.$val=f},a.wrapped=!0,a.keyFor=$idKey,a.init=function(f,c,$){a.elem=f,a.sendOnly=c,a.recvOnly=$};break;case $kindFunc:a=function(f){this.$val=f},a.wrapped=!0,a.init=function(f,c,$){a.params=f,a.results=c,a.variadic=$,a.comparable=!1};break;case $kindInterface:a={implementedBy:{},missingMethodFor:{}},a.keyFor=$ifaceKeyFor,a.init=function(f){a.methods=f,f.forEach(function(c){$ifaceNil[c.prop]=$throwNilPointerError})};break;case $kindMap:a=function(f){this.$val=f},a.wrapped=!0,a.init=function(f,c){a.key=f,a.elem=c,a.comparable=!1};break;case $kindPtr:a=o||function(f,c,$){this.$get=f,this.$set=c,this.$target=$,this.$val=this},a.keyFor=$idKey,a.init=function(f){a.elem=f,a.wrapped=f.kind===$kindArray,a.nil=new a($throwNilPointerError,$throwNilPointerError)};break;case $kindSlice:a=function(f){f.constructor!==a.nativeArray&&(f=new a.nativeArray(f)),this.$array=f,this.$offset=0,this.$length=f.length,this.$capacity=f.length,this.$val=this},a.init=function(f){a.elem=f,a.comparable=!1,a.nativeArray=$nativeArray(f.kind),a.nil=new a([])};break;case $kindStruct:a=function(f){this.$val=f},a.wrapped=!0,a.ptr=$newType(4,$kindPtr,\"*\"+n,!1,i,u,o),a.ptr.elem=a,a.ptr.prototype.$get=function(){return this},a.ptr.prototype.$set=function(f){a.copy(this,f)},a.init=function(f,c){a.pkgPath=f,a.fields=c,c.forEach(function(s){s.typ.comparable||(a.comparable=!1)}),a.keyFor=function(s){var h=s.$val;return $mapArray(c,function(d){return String(d.typ.keyFor(h[d.prop])).replace(/\\\\/g,\"\\\\\\\\\").replace(/\\$/g,\"\\\\$\")}).join(\"$\")},a.copy=function(s,h){for(var d=0;d<c.length;d++){var v=c[d];switch(v.typ.kind){case $kindArray:case $kindStruct:v.typ.copy(s[v.prop],h[v.prop]);continue;default:s[v.prop]=h[v.prop];continue}}};var $={};c.forEach(function(s){$[s.prop]={get:$throwNilPointerError,set:$throwNilPointerError}}),a.ptr.nil=Object.create(o.prototype,$),a.ptr.nil.$val=a.ptr.nil,$addMethodSynthesizer(function(){var s=function(h,d,v){h.prototype[d.prop]===void 0&&(h.prototype[d.prop]=$methodWrapper(function(){var g=this.$val[v.prop];return v.typ===$jsObjectPtr&&(g=new $jsObjectPtr(g)),g.$val===void 0&&(g=new v.typ(g)),g[d.prop].apply(g,arguments)}))};c.forEach(function(h){h.embedded&&($methodSet(h.typ).forEach(function(d){s(a,d,h),s(a.ptr,d,h)}),$methodSet($ptrType(h.typ)).forEach(function(d){s(a.ptr,d,h)}))})})};break;default:$panic(new $String(\"invalid kind: \"+e))}switch(e){case $kindBool:case $kindMap:a.zero=function(){return!1};break;case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindUnsafePointer:case $kindFloat32:case $kindFloat64:a.zero=function(){return 0};break;case $kindString:a.zero=function(){return\"\"};break;case $kindInt64:case $kindUint64:case $kindComplex64:case $kindComplex128:var l=new a(0,0);a.zero=function(){return l};break;case $kindPtr:case $kindSlice:a.zero=function(){return a.nil};break;case $kindChan:a.zero=function(){return $chanNil};break;case $kindFunc:a.zero=function(){return $throwNilPointerError};break;case $kindInterface:a.zero=function(){return $ifaceNil};break;case $kindArray:a.zero=function(){var f=$nativeArray(a.elem.kind);if(f!==Array)return new f(a.len);for(var c=new Array(a.len),$=0;$<a.len;$++)c[$]=a.elem.zero();return c};break;case $kindStruct:a.zero=function(){return new a.ptr};break;default:$panic(new $String(\"invalid kind: \"+e))}return a.id=$typeIDCounter,$typeIDCounter++,a.size=r,a.kind=e,a.string=n,a.named=t,a.pkg=i,a.exported=u,a.methods=[],a.methodSetCache=null,a.comparable=!0,a},$methodSet=function(r){if(r.methodSetCache!==null)return r.methodSetCache;var e={},n=r.kind===$kindPtr;if(n&&r.elem.kind===$kindInterface)return r.methodSetCache=[],[];for(var t=[{typ:n?r.elem:r,indirect:n}],i={};t.length>0;){var u=[],o=[];t.forEach(function(a){if(!i[a.typ.string])switch(i[a.typ.string]=!0,a.typ.named&&(o=o.concat(a.typ.methods),a.indirect&&(o=o.concat($ptrType(a.typ).methods))),a.typ.kind){case $kindStruct:a.typ.fields.forEach(function(l){if(l.embedded){var f=l.typ,c=f.kind===$kindPtr;u.push({typ:c?f.elem:f,indirect:a.indirect||c})}});break;case $kindInterface:o=o.concat(a.typ.methods);break}}),o.forEach(function(a){e[a.name]===void 0&&(e[a.name]=a)}),t=u}return r.methodSetCache=[],Object.keys(e).sort().forEach(function(a){r.methodSetCache.push(e[a])}),r.methodSetCache},$Bool=$newType(1,$kindBool,\"bool\",!0,\"\",!1,null),$Int=$newType(4,$kindInt,\"int\",!0,\"\",!1,null),$Int8=$newType(1,$kindInt8,\"int8\",!0,\"\",!1,null),$Int16=$newType(2,$kindInt16,\"int16\",!0,\"\",!1,null),$Int32=$newType(4,$kindInt32,\"int32\",!0,\"\",!1,null),$Int64=$newType(8,$kindInt64,\"int64\",!0,\"\",!1,null),$Uint=$newType(4,$kindUint,\"uint\",!0,\"\",!1,null),$Uint8=$newType(1,$kindUint8,\"uint8\",!0,\"\",!1,null),$Uint16=$newType(2,$kindUint16,\"uint16\",!0,\"\",!1,null),$Uint32=$newType(4,$kindUint32,\"uint32\",!0,\"\",!1,null),$Uint64=$newType(8,$kindUint64,\"uint64\",!0,\"\",!1,null),$Uintptr=$newType(4,$kindUintptr,\"uintptr\",!0,\"\",!1,null),$Float32=$newType(4,$kindFloat32,\"float32\",!0,\"\",!1,null),$Float64=$newType(8,$kindFloat64,\"float64\",!0,\"\",!1,null),$Complex64=$newType(8,$kindComplex64,\"complex64\",!0,\"\",!1,null),$Complex128=$newType(16,$kindComplex128,\"complex128\",!0,\"\",!1,null),$String=$newType(8,$kindString,\"string\",!0,\"\",!1,null),$UnsafePointer=$newType(4,$kindUnsafePointer,\"unsafe.Pointer\",!0,\"unsafe\",!1,null),$nativeArray=function(r){switch(r){case $kindInt:return Int32Array;case $kindInt8:return Int8Array;case $kindInt16:return Int16Array;case $kindInt32:return Int32Array;case $kindUint:return Uint32Array;case $kindUint8:return Uint8Array;case $kindUint16:return Uint16Array;case $kindUint32:return Uint32Array;case $kindUintptr:return Uint32Array;case $kindFloat32:return Float32Array;case $kindFloat64:return Float64Array;default:return Array}},$toNativeArray=function(r,e){var n=$nativeArray(r);return n===Array?e:new n(e)},$arrayTypes={},$arrayType=function(r,e){var n=r.id+\"$\"+e,t=$arrayTypes[n];return t===void 0&&(t=$newType(12,$kindArray,\"[\"+e+\"]\"+r.string,!1,\"\",!1,null),$arrayTypes[n]=t,t.init(r,e)),t},$chanType=function(r,e,n){var t=(n?\"<-\":\"\")+\"chan\"+(e?\"<- \":\" \");!e&&!n&&r.string[0]==\"<\"?t+=\"(\"+r.string+\")\":t+=r.string;var i=e?\"SendChan\":n?\"RecvChan\":\"Chan\",u=r[i];return u===void 0&&(u=$newType(4,$kindChan,t,!1,\"\",!1,null),r[i]=u,u.init(r,e,n)),u},$Chan=function(r,e){(e<0||e>2147483647)&&$throwRuntimeError(\"makechan: size out of range\"),this.$elem=r,this.$capacity=e,this.$buffer=[],this.$sendQueue=[],this.$recvQueue=[],this.$closed=!1},$chanNil=new $Chan(null,0);$chanNil.$sendQueue=$chanNil.$recvQueue={length:0,push:function(){},shift:function(){},indexOf:function(){return-1}};var $funcTypes={},$funcType=function(r,e,n){var t=$mapArray(r,function(a){return a.id}).join(\",\")+\"$\"+$mapArray(e,function(a){return a.id}).join(\",\")+\"$\"+n,i=$funcTypes[t];if(i===void 0){var u=$mapArray(r,function(a){return a.string});n&&(u[u.length-1]=\"...\"+u[u.length-1].substr(2));var o=\"func(\"+u.join(\", \")+\")\";e.length===1?o+=\" \"+e[0].string:e.length>1&&(o+=\" (\"+$mapArray(e,function(a){return a.string}).join(\", \")+\")\"),i=$newType(4,$kindFunc,o,!1,\"\",!1,null),$funcTypes[t]=i,i.init(r,e,n)}return i},$interfaceTypes={},$interfaceType=function(r){var e=$mapArray(r,function(i){return i.pkg+\",\"+i.name+\",\"+i.typ.id}).join(\"$\"),n=$interfaceTypes[e];if(n===void 0){var t=\"interface {}\";r.length!==0&&(t=\"interface { \"+$mapArray(r,function(i){return(i.pkg!==\"\"?i.pkg+\".\":\"\")+i.name+i.typ.string.substr(4)}).join(\"; \")+\" }\"),n=$newType(8,$kindInterface,t,!1,\"\",!1,null),$interfaceTypes[e]=n,n.init(r)}return n},$emptyInterface=$interfaceType([]),$ifaceNil={},$error=$newType(8,$kindInterface,\"error\",!0,\"\",!1,null);$error.init([{prop:\"Error\",name:\"Error\",pkg:\"\",typ:$funcType([],[$String],!1)}]);var $mapTypes={},$mapType=function(r,e){var n=r.id+\"$\"+e.id,t=$mapTypes[n];return t===void 0&&(t=$newType(4,$kindMap,\"map[\"+r.string+\"]\"+e.string,!1,\"\",!1,null),$mapTypes[n]=t,t.init(r,e)),t},$makeMap=function(r,e){$trackAllocations&&$mallocs++;for(var n=new Map,t=0;t<e.length;t++){var i=e[t];n.set(r(i.k),i)}return n},$ptrType=function(r){var e=r.ptr;return e===void 0&&(e=$newType(4,$kindPtr,\"*\"+r.string,!1,\"\",r.exported,null),r.ptr=e,e.init(r)),e},$newDataPointer=function(r,e){return e.elem.kind===$kindStruct||e.elem.kind===$kindArray?r:new e(function(){return r},function(n){r=n})},$indexPtr=function(r,e,n){if(r.buffer){var t=r.buffer.$ptr=r.buffer.$ptr||{},i=t[r.name]=t[r.name]||{},u=r.BYTES_PER_ELEMENT*e+r.byteOffset;return i[u]||(i[u]=new n(function(){return r[e]},function(o){r[e]=o}))}else return r.$ptr=r.$ptr||{},r.$ptr[e]||(r.$ptr[e]=new n(function(){return r[e]},function(o){r[e]=o}))},$sliceType=function(r){var e=r.slice;return e===void 0&&(e=$newType(12,$kindSlice,\"[]\"+r.string,!1,\"\",!1,null),r.slice=e,e.init(r)),e},$makeSlice=function(r,e,n){n=n||e,(e<0||e>2147483647)&&$throwRuntimeError(\"makeslice: len out of range\"),(n<0||n<e||n>2147483647)&&$throwRuntimeError(\"makeslice: cap out of range\"),$trackAllocations&&($mallocs++,$totalAlloc+=n*r.elem.size);var t=new r.nativeArray(n);if(r.nativeArray===Array)for(var i=0;i<n;i++)t[i]=r.elem.zero();var u=new r(t);return u.$length=e,u},$structTypes={},$structType=function(r,e){var n=$mapArray(e,function(u){return u.name+\",\"+u.typ.id+\",\"+u.tag}).join(\"$\"),t=$structTypes[n];if(t===void 0){var i=\"struct { \"+$mapArray(e,function(u){var o=u.typ.string+(u.tag!==\"\"?' \"'+u.tag.replace(/\\\\/g,\"\\\\\\\\\").replace(/\"/g,'\\\\\"')+'\"':\"\");return u.embedded?o:u.name+\" \"+o}).join(\"; \")+\" }\";e.length===0&&(i=\"struct {}\"),t=$newType(0,$kindStruct,i,!1,\"\",!1,function(){this.$val=this;for(var u=0;u<e.length;u++){var o=e[u];if(o.name!=\"_\"){var a=arguments[u];this[o.prop]=a!==void 0?a:o.typ.zero()}}}),$structTypes[n]=t,t.init(r,e)}return t},$assertType=function(r,e,n){var t=e.kind===$kindInterface,i,u=\"\";if(r===$ifaceNil)i=!1;else if(!t)i=r.constructor===e;else{var o=r.constructor.string;if(i=e.implementedBy[o],i===void 0){i=!0;for(var a=$methodSet(r.constructor),l=e.methods,f=0;f<l.length;f++){for(var c=l[f],$=!1,s=0;s<a.length;s++){var h=a[s];if(h.name===c.name&&h.pkg===c.pkg&&h.typ===c.typ){$=!0;break}}if(!$){i=!1,e.missingMethodFor[o]=c.name;break}}e.implementedBy[o]=i}i||(u=e.missingMethodFor[o])}if(!i){if(n)return[e.zero(),!1];$panic(new $packages.runtime.TypeAssertionError.ptr($packages.runtime._type.ptr.nil,r===$ifaceNil?$packages.runtime._type.ptr.nil:new $packages.runtime._type.ptr(r.constructor.string),new $packages.runtime._type.ptr(e.string),u))}return t||(r=r.$val),e===$jsObjectPtr&&(r=r.object),n?[r,!0]:r},$typeArgsKey=function(r){return $mapArray(r,function(e){return e.id}).join(\",\")},$typeArgsString=function(r){var e=function(n){if(n.named)return n.pkg===\"\"||n.string.indexOf(\".\")===-1?n.string:n.pkg+n.string.substr(n.string.indexOf(\".\"));switch(n.kind){case $kindPtr:return\"*\"+e(n.elem);case $kindSlice:return\"[]\"+e(n.elem);case $kindArray:return\"[\"+n.len+\"]\"+e(n.elem);case $kindMap:return\"map[\"+e(n.key)+\"]\"+e(n.elem);case $kindChan:return(n.recvOnly?\"<-\":\"\")+\"chan\"+(n.sendOnly?\"<- \":\" \")+e(n.elem);default:return n.string}};return $mapArray(r,e).join(\",\")},$genericFunc=function(r){var e=new Map;return function(){var n=Array.prototype.slice.call(arguments),t=$typeArgsKey(n),i=e.get(t);return i===void 0&&(i=r.apply(null,n),e.set(t,i)),i}},$genericType=function(r){var e=new Map,n=[],t=function(){var i=Array.prototype.slice.call(arguments),u=$typeArgsKey(i),o=e.get(u);if(o===void 0){var a=r.apply(null,i);o=a[0],o.typeArgs=i,e.set(u,o),a[1]();for(var l=0;l<n.length;l++)n[l].apply(null,o.typeArgs)}return o};return t.addMethods=function(i){n.push(i),e.forEach(function(u){i.apply(null,u.typeArgs)})},t},$genericFix=function(r,e){switch(e.kind){case $kindInt8:return r<<24>>24;case $kindUint8:return r<<24>>>24;case $kindInt16:return r<<16>>16;case $kindUint16:return r<<16>>>16;case $kindInt:case $kindInt32:return r>>0;case $kindUint:case $kindUint32:case $kindUintptr:return r>>>0;case $kindFloat32:return $fround(r);default:return r}},$genericIs64=function(r){return r.kind===$kindInt64||r.kind===$kindUint64},$genericIsComplex=function(r){return r.kind===$kindComplex64||r.kind===$kindComplex128},$genericIsInteger=function(r){return r.kind>=$kindInt&&r.kind<=$kindUintptr},$genericConst=function(r,e,n){return $genericIs64(r)?new r(Math.floor(e/4294967296),e>>>0):$genericIsComplex(r)?new r(e,n||0):r.kind===$kindFloat32?$fround(e):e},$genericIntConst=function(r,e,n){return $genericIs64(r)?new r(e,n):$genericIsComplex(r)?new r(e*4294967296+n,0):e*4294967296+n},$genericFlatten=function(r){return r.$high!==void 0?$flatten64(r):r},$genericBinary=function(r,e,n,t){if($genericIs64(t))switch(r){case\"+\":return new t(e.$high+n.$high,e.$low+n.$low);case\"-\":return new t(e.$high-n.$high,e.$low-n.$low);case\"*\":return $mul64(e,n);case\"/\":return $div64(e,n,!1);case\"%\":return $div64(e,n,!0);case\"&\":return new t(e.$high&n.$high,(e.$low&n.$low)>>>0);case\"|\":return new t(e.$high|n.$high,(e.$low|n.$low)>>>0);case\"^\":return new t(e.$high^n.$high,(e.$low^n.$low)>>>0);case\"&^\":return new t(e.$high&~n.$high,(e.$low&~n.$low)>>>0);case\"<<\":return $shiftLeft64(e,n);case\">>\":return t.kind===$kindInt64?$shiftRightInt64(e,n):$shiftRightUint64(e,n);case\"<\":return e.$high<n.$high||e.$high===n.$high&&e.$low<n.$low;case\"<=\":return e.$high<n.$high||e.$high===n.$high&&e.$low<=n.$low;case\">\":return e.$high>n.$high||e.$high===n.$high&&e.$low>n.$low;case\">=\":return e.$high>n.$high||e.$high===n.$high&&e.$low>=n.$low}if($genericIsComplex(t))switch(r){case\"+\":return new t(e.$real+n.$real,e.$imag+n.$imag);case\"-\":return new t(e.$real-n.$real,e.$imag-n.$imag);case\"*\":return new t(e.$real*n.$real-e.$imag*n.$imag,e.$real*n.$imag+e.$imag*n.$real);case\"/\":return $divComplex(e,n)}switch(r){case\"+\":return $genericFix(e+n,t);case\"-\":return $genericFix(e-n,t);case\"*\":switch(t.kind){case $kindInt:case $kindInt32:return $imul(e,n);case $kindUint:case $kindUint32:case $kindUintptr:return $imul(e,n)>>>0}return $genericFix(e*n,t);case\"/\":return $genericIsInteger(t)&&n===0&&$throwRuntimeError(\"integer divide by zero\"),$genericFix(e/n,t);case\"%\":return n===0&&$throwRuntimeError(\"integer divide by zero\"),e%n;case\"&\":return $genericFix(e&n,t);case\"|\":return $genericFix(e|n,t);case\"^\":return $genericFix(e^n,t);case\"&^\":return $genericFix(e&~n,t);case\"<<\":return n>=32?0:$genericFix(e<<n,t);case\">>\":switch(t.kind){case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:return n>=32?0:$genericFix(e>>>n,t)}return $genericFix(e>>$min(n,31),t);case\"<\":return e<n;case\"<=\":return e<=n;case\">\":return e>n;case\">=\":return e>=n}$throwRuntimeError(\"unsupported generic operation: \"+r+\" on \"+t.string)},$genericUnary=function(r,e,n){if($genericIs64(n))switch(r){case\"-\":return new n(-e.$high,-e.$low);case\"^\":return new n(~e.$high,~e.$low>>>0)}if($genericIsComplex(n))return new n(-e.$real,-e.$imag);switch(r){case\"-\":return $genericFix(-e,n);case\"^\":return $genericFix(~e,n)}$throwRuntimeError(\"unsupported generic operation: \"+r+\" on \"+n.string)},$genericConvert=function(r,e,n){if(e===n)return r;if(n.kind===$kindInterface)return $genericBox(r,e);var t=e.kind>=$kindInt&&e.kind<=$kindComplex128,i=n.kind>=$kindInt&&n.kind<=$kindComplex128;return t&&i?$genericIsComplex(n)?new n(r.$real,r.$imag):$genericIs64(e)?$genericIs64(n)?new n(r.$high,r.$low):n.kind===$kindFloat32||n.kind===$kindFloat64?$genericFix($flatten64(r),n):$genericFix(r.$low,n):$genericIs64(n)?(r=Math.trunc(r),new n(Math.floor(r/4294967296),r>>>0)):($genericIsInteger(n)&&!$genericIsInteger(e)&&(r=Math.trunc(r)),$genericFix(r,n)):n.kind===$kindString?t?$encodeRune($genericIs64(e)?r.$low:r):e.kind===$kindSlice?e.elem.kind===$kindInt32?$runesToString(r):$bytesToString(r):r:n.kind===$kindSlice&&e.kind===$kindString?new n(n.elem.kind===$kindInt32?$stringToRunes(r):$stringToBytes(r)):n.kind===$kindSlice?$convertSliceType(r,n):n.kind===$kindPtr&&e.kind===$kindPtr&&n.elem.kind===$kindStruct&&e.elem!==n.elem?$pointerOfStructConversion(r,n):n.kind===$kindStruct||n.kind===$kindArray?$clone(r,n):r},$genericBox=function(r,e){return e.kind===$kindInterface?r:e===$jsObjectPtr?new $jsObjectPtr(r):e.wrapped?new e(r):r},$genericClone=function(r,e){return e.copy?$clone(r,e):r},$genericDeref=function(r,e){return e.kind===$kindStruct||e.kind===$kindArray?r:r.$get()},$stackDepthOffset=0,$getStackDepth=function(){var r=new Error;if(r.stack!==void 0)return $stackDepthOffset+r.stack.split(`\n`).length},$panicStackDepth=null,$panicValue,$callDeferred=function(r,e,n){if(!n&&r!==null&&$curGoroutine.deferStack.indexOf(r)==-1)throw e;if(e!==null){var t=null;try{$panic(new $jsErrorPtr(e))}catch(c){t=c}$callDeferred(r,t);return}if(!$curGoroutine.asleep){$stackDepthOffset--;var i=$panicStackDepth,u=$panicValue,o=$curGoroutine.panicStack.pop();o!==void 0&&($panicStackDepth=$getStackDepth(),$panicValue=o);try{for(;;){if(r===null&&(r=$curGoroutine.deferStack[$curGoroutine.deferStack.length-1],r===void 0)){if($panicStackDepth=null,o.Object instanceof Error)throw o.Object;var a;throw o.constructor===$String?a=o.$val:o.Error!==void 0?a=o.Error():o.String!==void 0?a=o.String():a=o,new Error(a)}var l=r.pop();if(l===void 0){if($curGoroutine.deferStack.pop(),o!==void 0){r=null;continue}return}var f=l[0].apply(l[2],l[1]);if(f&&f.$blk!==void 0){if(r.push([f.$blk,[],f]),n)throw null;return}if(o!==void 0&&$panicStackDepth===null){if(n)throw null;return}}}catch(c){if(n)throw c;$callDeferred(r,c,n)}finally{o!==void 0&&($panicStackDepth!==null&&$curGoroutine.panicStack.push(o),$panicStackDepth=i,$panicValue=u),$stackDepthOffset++}}},$panic=function(r){$curGoroutine.panicStack.push(r),$callDeferred(null,null,!0)},$recover=function(){return $panicStackDepth===null||$panicStackDepth!==void 0&&$panicStackDepth!==$getStackDepth()-2?$ifaceNil:($panicStackDepth=null,$panicValue)},$throw=function(r){throw r},$noGoroutine={id:0,asleep:!1,exit:!1,deferStack:[],panicStack:[]},$curGoroutine=$noGoroutine,$totalGoroutines=0,$awakeGoroutines=0,$checkForDeadlock=!0,$exportedFunctions=0,$goroutines=new Map,$lastGoroutineID=0,$mainFinished=!1,$mainStarted=function(){},$go=function(r,e,n,t,i){$totalGoroutines++,$awakeGoroutines++;var $goroutine=function(){try{$curGoroutine=$goroutine,$goroutine.waitReason=void 0,$goroutine.suspended=void 0;var o=r.apply(void 0,e);if(o&&o.$blk!==void 0){r=function(){return o.$blk()},e=[],$goroutine.suspended=o;return}$goroutine.exit=!0}catch(l){if(!$goroutine.exit){if($goroutine.onPanic===void 0)throw l;$goroutine.onPanic(l)}}finally{if($curGoroutine=$noGoroutine,$goroutine.exit&&($totalGoroutines--,$goroutines.delete($goroutine.id),$goroutine.asleep=!0,$goroutine.onExit!==void 0&&$goroutine.onExit()),$goroutine.asleep&&($awakeGoroutines--,!$mainFinished&&$awakeGoroutines===0&&$checkForDeadlock&&$exportedFunctions===0)){var a=`fatal error: all goroutines are asleep - deadlock!\n`;$goroutines.forEach(function(l){a+=`\n`+$goroutineTrace(l)}),console.error(a.slice(0,-1)),$global.process!==void 0&&$global.process.exit(2)}}};$goroutine.asleep=!1,$goroutine.exit=!1,$goroutine.deferStack=[],$goroutine.panicStack=[],$goroutine.onExit=t,$goroutine.onPanic=i,$goroutine.id=++$lastGoroutineID,$goroutine.createdBy=n,$goroutine.waitReason=void 0,$goroutine.suspended=void 0,$goroutines.set($goroutine.id,$goroutine),$schedule($goroutine)},$goMain=function(r){return new Promise(function(e,n){$mainStarted=function(){$curGoroutine.onPanic=void 0,e()},$go(r,[],void 0,e,n)})},$blockedFrames=function(r){for(var e=[],n=r.suspended;n!=null;){if(n.$pos===void 0){n=n.$r;continue}for(var t=n.$pos.indexOf(\" \"),i=n.$pos.lastIndexOf(\" \"),u=n.$pos.substring(i+1).split(\",\"),o=void 0,a=0;a<u.length;a++){var l=u[a].split(\":\");if(Number(l[0])===n.$s){e.unshift({name:n.$pos.substring(0,t),file:n.$pos.substring(t+1,i),line:Number(l[1])}),o=n[l[2]];break}}n=o}return e},$goroutineTrace=function(r,e){var n=r.waitReason||\"waiting\";r===$curGoroutine?n=\"running\":r.asleep||(n=\"runnable\"),e===void 0&&(e=$blockedFrames(r));for(var t=\"goroutine \"+r.id+\" [\"+n+`]:\n`,i=0;i<e.length;i++)t+=e[i].name+`(...)\n\t`+e[i].file+\":\"+e[i].line+`\n`;if(r.createdBy!==void 0){var u=r.createdBy.indexOf(\" \");t+=\"created by \"+r.createdBy.substring(0,u)+`\n\t`+r.createdBy.substring(u+1)+`\n`}return t},$scheduled=[],$runScheduled=function(){var r=setTimeout($runScheduled);try{for(var e=Date.now(),n;(n=$scheduled.shift())!==void 0;){n();var t=Date.now()-e;if(t>4||t<0)break}}finally{$scheduled.length==0&&clearTimeout(r)}},$schedule=function(r){r.asleep&&(r.asleep=!1,$awakeGoroutines++),$scheduled.push(r),$curGoroutine===$noGoroutine&&$runScheduled()},$setTimeout=function(r,e){return $awakeGoroutines++,setTimeout(function(){$awakeGoroutines--,r()},e)},$block=function(r){$curGoroutine===$noGoroutine&&$throwRuntimeError(\"cannot block in JavaScript callback, fix by wrapping code in goroutine\"),$curGoroutine.asleep=!0,$curGoroutine.waitReason===void 0&&($curGoroutine.waitReason=r)},$restore=function(r,e){return r!==void 0&&r.$blk!==void 0?r:e},$send=function(r,e){r.$closed&&$throwRuntimeError(\"send on closed channel\");var n=r.$recvQueue.shift();if(n!==void 0){n([e,!0]);return}if(r.$buffer.length<r.$capacity){r.$buffer.push(e);return}var t=$curGoroutine,i;return r.$sendQueue.push(function(u){return i=u,$schedule(t),e}),$block(r===$chanNil?\"chan send (nil chan)\":\"chan send\"),{$blk:function(){i&&$throwRuntimeError(\"send on closed channel\")}}},$recv=function(r){var e=r.$sendQueue.shift();e!==void 0&&r.$buffer.push(e(!1));var n=r.$buffer.shift();if(n!==void 0)return[n,!0];if(r.$closed)return[r.$elem.zero(),!1];var t=$curGoroutine,i={$blk:function(){return this.value}},u=function(o){i.value=o,$schedule(t)};return r.$recvQueue.push(u),$block(r===$chanNil?\"chan receive (nil chan)\":\"chan receive\"),i},$close=function(r){for(r.$closed&&$throwRuntimeError(\"close of closed channel\"),r.$closed=!0;;){var e=r.$sendQueue.shift();if(e===void 0)break;e(!0)}for(;;){var n=r.$recvQueue.shift();if(n===void 0)break;n([r.$elem.zero(),!1])}},$select=function(r){for(var e=[],n=-1,t=0;t<r.length;t++){var i=r[t],u=i[0];switch(i.length){case 0:n=t;break;case 1:(u.$sendQueue.length!==0||u.$buffer.length!==0||u.$closed)&&e.push(t);break;case 2:u.$closed&&$throwRuntimeError(\"send on closed channel\"),(u.$recvQueue.length!==0||u.$buffer.length<u.$capacity)&&e.push(t);break}}if(e.length!==0&&(n=e[Math.floor(Math.random()*e.length)]),n!==-1){var i=r[n];switch(i.length){case 0:return[n];case 1:return[n,$recv(i[0])];case 2:return $send(i[0],i[1]),[n]}}for(var o=[],a=$curGoroutine,l={$blk:function(){return this.selection}},f=function(){for(var c=0;c<o.length;c++){var $=o[c],s=$[0],h=s.indexOf($[1]);h!==-1&&s.splice(h,1)}},t=0;t<r.length;t++)(function($){var s=r[$];switch(s.length){case 1:var h=function(d){l.selection=[$,d],f(),$schedule(a)};o.push([s[0].$recvQueue,h]),s[0].$recvQueue.push(h);break;case 2:var h=function(){return s[0].$closed&&$throwRuntimeError(\"send on closed channel\"),l.selection=[$],f(),$schedule(a),s[1]};o.push([s[0].$sendQueue,h]),s[0].$sendQueue.push(h);break}})(t);return $block(r.length===0?\"select (no cases)\":\"select\"),l},$jsObjectPtr,$jsErrorPtr,$needsExternalization=function(r){switch(r.kind){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindFloat32:case $kindFloat64:return!1;default:return r!==$jsObjectPtr}},$externalize=function(r,e,n){if(e===$jsObjectPtr)return r;switch(e.kind){case $kindBool:case $kindInt:case $kindInt8:case $kindInt16:case $kindInt32:case $kindUint:case $kindUint8:case $kindUint16:case $kindUint32:case $kindUintptr:case $kindFloat32:case $kindFloat64:return r;case $kindInt64:case $kindUint64:return $flatten64(r);case $kindArray:return $needsExternalization(e.elem)?$mapArray(r,function(F){return $externalize(F,e.elem,n)}):r;case $kindFunc:return $externalizeFunction(r,e,!1,n);case $kindInterface:return r===$ifaceNil?null:r.constructor===$jsObjectPtr?r.$val.object:$externalize(r.$val,r.constructor,n);case $kindMap:for(var t={},i=Array.from(r.keys()),u=0;u<i.length;u++){var o=r.get(i[u]);t[$externalize(o.k,e.key,n)]=$externalize(o.v,e.elem,n)}return t;case $kindPtr:return r===e.nil?null:$externalize(r.$get(),e.elem,n);case $kindSlice:return $needsExternalization(e.elem)?$mapArray($sliceToNativeArray(r),function(F){return $externalize(F,e.elem,n)}):$sliceToNativeArray(r);case $kindString:if($isASCII(r))return r;for(var a=\"\",l,u=0;u<r.length;u+=l[1]){l=$decodeRune(r,u);var f=l[0];if(f>65535){var c=Math.floor((f-65536)/1024)+55296,$=(f-65536)%1024+56320;a+=String.fromCharCode(c,$);continue}a+=String.fromCharCode(f)}return a;case $kindStruct:var s=$packages.time;if(s!==void 0&&r.constructor===s.Time.ptr){var h=$div64(r.UnixNano(),new $Int64(0,1e6));return new Date($flatten64(h))}var d={},v=function(F,p){if(p===$jsObjectPtr)return F;switch(p.kind){case $kindPtr:return F===p.nil?d:v(F.$get(),p.elem);case $kindStruct:var w=p.fields[0];return v(F[w.prop],w.typ);case $kindInterface:return v(F.$val,F.constructor);default:return d}},g=v(r,e);if(g!==d)return g;if(n!==void 0)return n(r);g={};for(var u=0;u<e.fields.length;u++){var k=e.fields[u];k.exported&&(g[k.name]=$externalize(r[k.prop],k.typ,n))}return g}$throwRuntimeError(\"cannot externalize \"+e.string)},$externalizeFunction=function(r,e,n,t){return r===$throwNilPointerError?null:(r.$externalizeWrapper===void 0&&($checkForDeadlock=!1,r.$externalizeWrapper=function(){var i=$internalizeArguments(arguments,e,t),u=r.apply(n?this:void 0,i);switch(e.results.length){case 0:return;case 1:return $externalize($copyIfRequired(u,e.results[0]),e.results[0],t);default:for(var o=0;o<e.results.length;o++)u[o]=$externalize($copyIfRequired(u[o],e.results[o]),e.results[o],t);return u}}),r.$externalizeWrapper)},$internalizeArguments=function(r,e,n){for(var t=[],i=0;i<e.params.length;i++){if(e.variadic&&i===e.params.length-1){for(var u=e.params[i].elem,o=[],a=i;a<r.length;a++)o.push($internalize(r[a],u,n));t.push(new e.params[i](o));break}t.push($internalize(r[i],e.params[i],n))}return t},$exportFunction=function(r,e,n,t){var i=function(u){var o=e.results.length,a=o===1?[u]:u;if(o>0&&e.results[o-1]===$error){var l=a[o-1];if(l!==$ifaceNil)throw new Error(l.Error());o--}for(var f=[],c=0;c<o;c++)f.push($externalize($copyIfRequired(a[c],e.results[c]),e.results[c],t));return o===1?f[0]:o===0?void 0:f};return n?function(){var u=$internalizeArguments(arguments,e,t);return new Promise(function(o,a){var l,f=function(){return r.apply(void 0,u)},c=function(){var $=f();if($&&$.$blk!==void 0)return f=function(){return $.$blk()},{$blk:c};l=$};$go(c,[],void 0,function(){try{o(i(l))}catch($){a($)}},a)})}:function(){return i(r.apply(void 0,$internalizeArguments(arguments,e,t)))}},$exportStructType=function(r,e){return function(n){var t=new r.ptr;if(n!=null)for(var i=0;i<r.fields.length;i++){var u=r.fields[i];u.exported&&n[u.name]!==void 0&&(t[u.prop]=$internalize(n[u.name],u.typ,e))}return e(t)}},$internalize=function(r,e,n,t,i){if(e===$jsObjectPtr)return r;if(e===$jsObjectPtr.elem&&$throwRuntimeError(\"cannot internalize js.Object, use *js.Object instead\"),r&&r.__internal_object__!==void 0)return $assertType(r.__internal_object__,e,!1);var u=$packages.time;if(u!==void 0&&e===u.Time)return r!=null&&r.constructor===Date||$throwRuntimeError(\"cannot internalize time.Time from \"+typeof r+\", must be Date\"),u.Unix(new $Int64(0,0),new $Int64(0,r.getTime()*1e6));if(t===void 0&&(t=new Map),t.has(e)||t.set(e,new Map),t.get(e).has(r))return t.get(e).get(r);switch(e.kind){case $kindBool:return!!r;case $kindInt:return parseInt(r);case $kindInt8:return parseInt(r)<<24>>24;case $kindInt16:return parseInt(r)<<16>>16;case $kindInt32:return parseInt(r)>>0;case $kindUint:return parseInt(r);case $kindUint8:return parseInt(r)<<24>>>24;case $kindUint16:return parseInt(r)<<16>>>16;case $kindUint32:case $kindUintptr:return parseInt(r)>>>0;case $kindInt64:case $kindUint64:return new e(0,r);case $kindFloat32:case $kindFloat64:return parseFloat(r);case $kindArray:return r.length!==e.len&&$throwRuntimeError(\"got array with wrong size from JavaScript native\"),$mapArray(r,function(p){return $internalize(p,e.elem,i)});case $kindFunc:return function(){for(var p=[],w=0;w<e.params.length;w++){if(e.variadic&&w===e.params.length-1){for(var b=e.params[w].elem,m=arguments[w],A=0;A<m.$length;A++)p.push($externalize(m.$array[m.$offset+A],b,i));break}p.push($externalize(arguments[w],e.params[w],i))}var y=r.apply(n,p);switch(e.results.length){case 0:return;case 1:return $internalize(y,e.results[0],i);default:for(var w=0;w<e.results.length;w++)y[w]=$internalize(y[w],e.results[w],i);return y}};case $kindInterface:if(e.methods.length!==0&&$throwRuntimeError(\"cannot internalize \"+e.string),r===null)return $ifaceNil;if(r===void 0)return new $jsObjectPtr(void 0);switch(r.constructor){case Int8Array:return new($sliceType($Int8))(r);case Int16Array:return new($sliceType($Int16))(r);case Int32Array:return new($sliceType($Int))(r);case Uint8Array:return new($sliceType($Uint8))(r);case Uint16Array:return new($sliceType($Uint16))(r);case Uint32Array:return new($sliceType($Uint))(r);case Float32Array:return new($sliceType($Float32))(r);case Float64Array:return new($sliceType($Float64))(r);case Array:return $internalize(r,$sliceType($emptyInterface),i);case Boolean:return new $Bool(!!r);case Date:return u===void 0?new $jsObjectPtr(r):new u.Time($internalize(r,u.Time,i));case function(){}.constructor:var o=$funcType([$sliceType($emptyInterface)],[$jsObjectPtr],!0);return new o($internalize(r,o,i));case Number:return new $Float64(parseFloat(r));case String:return new $String($internalize(r,$String,i));default:if($global.Node&&r instanceof $global.Node)return new $jsObjectPtr(r);var a=$mapType($String,$emptyInterface);return new a($internalize(r,a,n,t,i))}case $kindMap:var l=new Map;t.get(e).set(r,l);for(var f=$keys(r),s=0;s<f.length;s++){var c=$internalize(f[s],e.key,n,t,i);l.set(e.key.keyFor(c),{k:c,v:$internalize(r[f[s]],e.elem,n,t,i)})}return l;case $kindPtr:if(e.elem.kind===$kindStruct)return $internalize(r,e.elem,i);case $kindSlice:return new e($mapArray(r,function(p){return $internalize(p,e.elem,i)}));case $kindString:if(r=String(r),$isASCII(r))return r;for(var $=\"\",s=0;s<r.length;){var h=r.charCodeAt(s);if(55296<=h&&h<=56319){var d=r.charCodeAt(s+1),v=(h-55296)*1024+d-56320+65536;$+=$encodeRune(v),s+=2;continue}$+=$encodeRune(h),s++}return $;case $kindStruct:var g={},k=function(p){if(p===$jsObjectPtr)return r;switch(p===$jsObjectPtr.elem&&$throwRuntimeError(\"cannot internalize js.Object, use *js.Object instead\"),p.kind){case $kindPtr:return k(p.elem);case $kindStruct:var w=p.fields[0],b=k(w.typ);if(b!==g){var m=new p.ptr;return m[w.prop]=b,m}return g;default:return g}},F=k(e);if(F!==g)return F}$throwRuntimeError(\"cannot internalize \"+e.string)},$copyIfRequired=function(r,e){if(r&&r.constructor&&r.constructor.copy)return new r.constructor($clone(r.$val,r.constructor));if(e.copy){var n=e.zero();return e.copy(n,r),n}return r},$isASCII=function(r){for(var e=0;e<r.length;e++)if(r.charCodeAt(e)>=128)return!1;return!0};\n"
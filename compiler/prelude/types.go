@@ -283,7 +283,7 @@ var $newType = function(size, kind, string, named, pkg, exported, constructor) {
       $addMethodSynthesizer(function() {
         var synthesizeMethod = function(target, m, f) {
           if (target.prototype[m.prop] !== undefined) { return; }
-          target.prototype[m.prop] = function() {
+          target.prototype[m.prop] = $methodWrapper(function() {
             var v = this.$val[f.prop];
             if (f.typ === $jsObjectPtr) {
               v = new $jsObjectPtr(v);
@@ -292,7 +292,7 @@ var $newType = function(size, kind, string, named, pkg, exported, constructor) {
               v = new f.typ(v);
             }
             return v[m.prop].apply(v, arguments);
-          };
+          });
         };
         fields.forEach(function(f) {
           if (f.embedded) {
@@ -617,6 +617,9 @@ var $mapType = function(key, elem) {
   return typ;
 };
 var $makeMap = function(keyForFunc, entries) {
+  if ($trackAllocations) {
+    $mallocs++;
+  }
   var m = new Map();
   for (var i = 0; i < entries.length; i++) {
     var e = entries[i];
@@ -636,7 +639,7 @@ var $ptrType = function(elem) {
 };
 
 var $newDataPointer = function(data, constructor) {
-  if (constructor.elem.kind === $kindStruct) {
+  if (constructor.elem.kind === $kindStruct || constructor.elem.kind === $kindArray) {
     return data;
   }
   return new constructor(function() { return data; }, function(v) { data = v; });
@@ -673,6 +676,10 @@ var $makeSlice = function(typ, length, capacity) {
   if (capacity < 0 || capacity < length || capacity > 2147483647) {
     $throwRuntimeError("makeslice: cap out of range");
   }
+  if ($trackAllocations) {
+    $mallocs++;
+    $totalAlloc += capacity * typ.elem.size;
+  }
   var array = new typ.nativeArray(capacity);
   if (typ.nativeArray === Array) {
     for (var i = 0; i < capacity; i++) {
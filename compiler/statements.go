@@ -147,7 +147,10 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 			var bodyPrefix []ast.Stmt
 			if implicit := fc.pkgCtx.Implicits[clause]; implicit != nil {
 				value := refVar
-				if typesutil.IsJsObject(implicit.Type().Underlying()) {
+				if typesutil.IsTypeParam(implicit.Type()) {
+					// Whether the value is boxed depends on the type argument.
+					value = fmt.Sprintf("(%s.kind === $kindInterface ? %s : %s.$val)", fc.typeName(implicit.Type()), refVar, refVar)
+				} else if typesutil.IsJsObject(implicit.Type().Underlying()) {
 					value += ".$val.object"
 				} else if _, ok := implicit.Type().Underlying().(*types.Interface); !ok {
 					value += ".$val"
@@ -190,7 +193,7 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 		refVar := fc.newVariable("_ref")
 		fc.Printf("%s = %s;", refVar, fc.translateExpr(s.X))
 
-		switch t := fc.pkgCtx.TypeOf(s.X).Underlying().(type) {
+		switch t := typesutil.CoreType(fc.pkgCtx.TypeOf(s.X)).(type) {
 		case *types.Basic:
 			iVar := fc.newVariable("_i")
 			fc.Printf("%s = 0;", iVar)
@@ -466,12 +469,20 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 
 	case *ast.GoStmt:
 		callable, arglist := fc.delegatedCall(s.Call)
-		fc.Printf("$go(%s, %s);", callable, arglist)
+		if fc.pkgCtx.minify {
+			fc.Printf("$go(%s, %s);", callable, arglist)
+			break
+		}
+		// The creator of the goroutine is reported in goroutine dumps.
+		pos := fc.goPosition(s.Pos())
+		fc.Printf("$go(%s, %s, %s);", callable, arglist, encodeString(fmt.Sprintf("%s %s:%d", fc.funcName, pos.Filename, pos.Line)))
 
 	case *ast.SendStmt:
-		chanType := fc.pkgCtx.TypeOf(s.Chan).Underlying().(*types.Chan)
+		chanType := typesutil.CoreType(fc.pkgCtx.TypeOf(s.Chan)).(*types.Chan)
+		send := fc.newIdent("$send", types.NewSignature(nil, types.NewTuple(types.NewVar(0, nil, "", chanType), types.NewVar(0, nil, "", chanType.Elem())), nil, false))
+		send.NamePos = s.Pos() // The position of the statement, which is reported if the send blocks.
 		call := &ast.CallExpr{
-			Fun:  fc.newIdent("$send", types.NewSignature(nil, types.NewTuple(types.NewVar(0, nil, "", chanType), types.NewVar(0, nil, "", chanType.Elem())), nil, false)),
+			Fun:  send,
 			Args: []ast.Expr{s.Chan, fc.newIdent(fc.translateImplicitConversionWithCloning(s.Value, chanType.Elem()).String(), chanType.Elem())},
 		}
 		fc.Blocking[call] = true
@@ -494,7 +505,7 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 			case *ast.AssignStmt:
 				channels = append(channels, fc.formatExpr("[%e]", astutil.RemoveParens(comm.Rhs[0]).(*ast.UnaryExpr).X).String())
 			case *ast.SendStmt:
-				chanType := fc.pkgCtx.TypeOf(comm.Chan).Underlying().(*types.Chan)
+				chanType := typesutil.CoreType(fc.pkgCtx.TypeOf(comm.Chan)).(*types.Chan)
 				channels = append(channels, fc.formatExpr("[%e, %s]", comm.Chan, fc.translateImplicitConversionWithCloning(comm.Value, chanType.Elem())).String())
 			default:
 				panic(fmt.Sprintf("unhandled: %T", comm))
@@ -700,7 +711,7 @@ func (fc *funcContext) translateAssign(lhs, rhs ast.Expr, define bool) string {
 	}
 
 	if l, ok := lhs.(*ast.IndexExpr); ok {
-		if t, ok := fc.pkgCtx.TypeOf(l.X).Underlying().(*types.Map); ok {
+		if t, ok := typesutil.CoreType(fc.pkgCtx.TypeOf(l.X)).(*types.Map); ok {
 			if typesutil.IsJsObject(fc.pkgCtx.TypeOf(l.Index)) {
 				fc.pkgCtx.errList = append(fc.pkgCtx.errList, types.Error{Fset: fc.pkgCtx.fileSet, Pos: l.Index.Pos(), Msg: "cannot use js.Object as map key"})
 			}
@@ -724,6 +735,14 @@ func (fc *funcContext) translateAssign(lhs, rhs ast.Expr, define bool) string {
 		return fmt.Sprintf("%s = %s;", fc.translateExpr(lhs), rhsExpr) // skip $copy
 	}
 
+	if typesutil.IsTypeParam(lhsType) {
+		// Whether the value needs to be copied depends on the type argument.
+		if define {
+			return fmt.Sprintf("%s = $genericClone(%s, %s);", fc.translateExpr(lhs), rhsExpr, fc.typeName(lhsType))
+		}
+		return fmt.Sprintf("if (%[1]s.copy) { %[1]s.copy(%[2]s, %[3]s); } else { %[4]s }", fc.typeName(lhsType), fc.translateExpr(lhs), rhsExpr, fc.translatePlainAssign(lhs, rhsExpr))
+	}
+
 	isReflectValue := false
 	if named, ok := lhsType.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "reflect" && named.Obj().Name() == "Value" {
 		isReflectValue = true
@@ -738,6 +757,12 @@ func (fc *funcContext) translateAssign(lhs, rhs ast.Expr, define bool) string {
 		}
 	}
 
+	return fc.translatePlainAssign(lhs, rhsExpr)
+}
+
+// translatePlainAssign translates assignment of an already translated value,
+// which doesn't require copying.
+func (fc *funcContext) translatePlainAssign(lhs ast.Expr, rhsExpr *expression) string {
 	switch l := lhs.(type) {
 	case *ast.Ident:
 		return fmt.Sprintf("%s = %s;", fc.objectName(fc.pkgCtx.ObjectOf(l)), rhsExpr)
@@ -755,7 +780,7 @@ func (fc *funcContext) translateAssign(lhs, rhs ast.Expr, define bool) string {
 	case *ast.StarExpr:
 		return fmt.Sprintf("%s.$set(%s);", fc.translateExpr(l.X), rhsExpr)
 	case *ast.IndexExpr:
-		switch t := fc.pkgCtx.TypeOf(l.X).Underlying().(type) {
+		switch t := typesutil.CoreType(fc.pkgCtx.TypeOf(l.X)).(type) {
 		case *types.Array, *types.Pointer:
 			pattern := rangeCheck("%1e[%2f] = %3s", fc.pkgCtx.Types[l.Index].Value != nil, true)
 			if _, ok := t.(*types.Pointer); ok { // check pointer for nil (attribute getter causes a panic)
@@ -14,3 +14,164 @@ func IsJsObject(t types.Type) bool {
 	named, isNamed := ptr.Elem().(*types.Named)
 	return isNamed && IsJsPackage(named.Obj().Pkg()) && named.Obj().Name() == "Object"
 }
+
+// IsTypeParam returns true if the type is a type parameter of a generic
+// function or type.
+func IsTypeParam(t types.Type) bool {
+	_, ok := t.(*types.TypeParam)
+	return ok
+}
+
+// IsGeneric returns true if the type refers to any type parameters, which
+// means that it can only be fully resolved in a context of a specific
+// instantiation.
+func IsGeneric(t types.Type) bool {
+	return isGeneric(t, map[types.Type]bool{})
+}
+
+func isGeneric(t types.Type, visiting map[types.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		if t.TypeParams().Len() > 0 && t.TypeArgs().Len() == 0 {
+			return true // Uninstantiated generic type.
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if isGeneric(t.TypeArgs().At(i), visiting) {
+				return true
+			}
+		}
+		return false
+	case *types.Basic:
+		return false
+	case *types.Pointer:
+		return isGeneric(t.Elem(), visiting)
+	case *types.Slice:
+		return isGeneric(t.Elem(), visiting)
+	case *types.Array:
+		return isGeneric(t.Elem(), visiting)
+	case *types.Chan:
+		return isGeneric(t.Elem(), visiting)
+	case *types.Map:
+		return isGeneric(t.Key(), visiting) || isGeneric(t.Elem(), visiting)
+	case *types.Signature:
+		return isGeneric(t.Params(), visiting) || isGeneric(t.Results(), visiting)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if isGeneric(t.At(i).Type(), visiting) {
+				return true
+			}
+		}
+		return false
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if isGeneric(t.Field(i).Type(), visiting) {
+				return true
+			}
+		}
+		return false
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if isGeneric(t.ExplicitMethod(i).Type(), visiting) {
+				return true
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if isGeneric(t.EmbeddedType(i), visiting) {
+				return true
+			}
+		}
+		return false
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if isGeneric(t.Term(i).Type(), visiting) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// CoreType returns the underlying type of t. For a type parameter it returns
+// the underlying type shared by all types in its type set, or nil if there is
+// no such type.
+func CoreType(t types.Type) types.Type {
+	tp, ok := t.(*types.TypeParam)
+	if !ok {
+		return t.Underlying()
+	}
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	var core types.Type
+	var visit func(iface *types.Interface) bool
+	visit = func(iface *types.Interface) bool {
+		for i := 0; i < iface.NumEmbeddeds(); i++ {
+			var terms []types.Type
+			switch e := iface.EmbeddedType(i).(type) {
+			case *types.Union:
+				for j := 0; j < e.Len(); j++ {
+					terms = append(terms, e.Term(j).Type())
+				}
+			default:
+				if embedded, ok := e.Underlying().(*types.Interface); ok {
+					if !visit(embedded) {
+						return false
+					}
+					continue
+				}
+				terms = append(terms, e)
+			}
+			for _, term := range terms {
+				u := CoreType(term)
+				if u == nil {
+					return false
+				}
+				if core == nil {
+					core = u
+				} else if !types.Identical(core, u) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if !visit(iface) {
+		return nil
+	}
+	return core
+}
+
+// FuncOrigin returns the generic function or method the given function was
+// instantiated from, or the function itself if it is not an instance.
+func FuncOrigin(f *types.Func) *types.Func {
+	sig, ok := f.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return f
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok || named.Origin() == named {
+		return f
+	}
+	origin := named.Origin()
+	for i := 0; i < origin.NumMethods(); i++ {
+		if m := origin.Method(i); m.Name() == f.Name() {
+			return m
+		}
+	}
+	return f
+}
@@ -10,6 +10,8 @@ import (
 	"go/token"
 	"go/types"
 	"net/url"
+	"path"
+	"path/filepath"
 	"regexp"
 	"runtime/debug"
 	"sort"
@@ -17,6 +19,7 @@ import (
 	"strings"
 	"text/template"
 	"unicode"
+	"unicode/utf16"
 
 	"github.com/gopherjs/gopherjs/compiler/analysis"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
@@ -183,6 +186,10 @@ func (fc *funcContext) translateSelection(sel selection, pos token.Pos) ([]strin
 var nilObj = types.Universe.Lookup("nil")
 
 func (fc *funcContext) zeroValue(ty types.Type) ast.Expr {
+	if typesutil.IsTypeParam(ty) {
+		// Zero value of a type parameter depends on its type argument.
+		return fc.newIdent(fc.typeName(ty)+".zero()", ty)
+	}
 	switch t := ty.Underlying().(type) {
 	case *types.Basic:
 		switch {
@@ -346,13 +353,26 @@ func (fc *funcContext) typeName(ty types.Type) string {
 		if t.Obj().Name() == "error" {
 			return "$error"
 		}
+		if t.TypeArgs().Len() > 0 {
+			return fc.instanceName(t.Obj(), t.TypeArgs())
+		}
 		return fc.objectName(t.Obj())
+	case *types.TypeParam:
+		name, ok := fc.pkgCtx.objectNames[t.Obj()]
+		if !ok {
+			panic(bailout(fmt.Errorf("type parameter %v is not in scope", t)))
+		}
+		return name
 	case *types.Interface:
 		if t.Empty() {
 			return "$emptyInterface"
 		}
 	}
 
+	if typesutil.IsGeneric(ty) {
+		return fc.genericTypeName(ty)
+	}
+
 	anonType, ok := fc.pkgCtx.anonTypeMap.At(ty).(*types.TypeName)
 	if !ok {
 		fc.initArgs(ty) // cause all embedded types to be registered
@@ -571,6 +591,97 @@ func encodeString(s string) string {
 	return `"` + buffer.String() + `"`
 }
 
+// goFuncName returns the name of the function or method o, as the upstream Go
+// runtime reports it in stack traces, e.g. "pkg/path.F" or "pkg/path.(*T).M".
+func goFuncName(o *types.Func) string {
+	pkgPath := goPkgPath(o.Pkg())
+	sig := o.Type().(*types.Signature)
+	name := o.Name()
+	if sig.TypeParams().Len() > 0 {
+		name += "[...]"
+	}
+	recv := sig.Recv()
+	if recv == nil {
+		return pkgPath + "." + name
+	}
+	recvType := recv.Type()
+	ptr, isPointer := recvType.(*types.Pointer)
+	if isPointer {
+		recvType = ptr.Elem()
+	}
+	named := recvType.(*types.Named)
+	recvName := named.Obj().Name()
+	if named.TypeParams().Len() > 0 {
+		recvName += "[...]"
+	}
+	if isPointer {
+		recvName = "(*" + recvName + ")"
+	}
+	return pkgPath + "." + recvName + "." + name
+}
+
+// goPkgPath returns the path of the package pkg as it appears in Go function
+// names. The main package is always called "main" there.
+func goPkgPath(pkg *types.Package) string {
+	if pkg.Name() == "main" {
+		return "main"
+	}
+	return pkg.Path()
+}
+
+// goPosition returns the position pos, with the file name relative to the
+// import path of the package, like in the binaries the gc toolchain builds with
+// -trimpath. It's reported at run time, which shouldn't depend on the location
+// of the sources on the build machine.
+func (fc *funcContext) goPosition(pos token.Pos) token.Position {
+	p := fc.pkgCtx.fileSet.Position(pos)
+	p.Filename = path.Join(fc.pkgCtx.Pkg.Path(), filepath.Base(p.Filename))
+	return p
+}
+
+// funcLitName returns the Go name of the next function literal in the
+// function, following the gc conventions: "pkg.F.func1" for literals in F,
+// "pkg.F.func1.1" for literals nested in them and "pkg.glob..func1" for
+// literals in package-level variable initializers.
+func (fc *funcContext) funcLitName() string {
+	fc.funcLitCount++
+	// Names of function literals extend the name of the enclosing function.
+	if fc.parent != nil && strings.HasPrefix(fc.funcName, fc.parent.funcName+".") {
+		return fc.funcName + "." + strconv.Itoa(fc.funcLitCount)
+	}
+	return fc.funcName + ".func" + strconv.Itoa(fc.funcLitCount)
+}
+
+// namedFunction returns an expression, which evaluates to the function
+// expression fun with its name property set to name. Unlike the names in
+// function expressions, it may be an arbitrary string, such as a Go function
+// name. V8 reports this name in stack traces, which runtime.Callers parses.
+// It's not used in minified code.
+func namedFunction(name, fun string) string {
+	return fmt.Sprintf("Object.defineProperty(%s, \"name\", { value: %s })", fun, encodeUnicodeString(name))
+}
+
+// encodeUnicodeString returns a JavaScript string literal with the characters
+// of the UTF-8 encoded string s. It differs from encodeString, which encodes
+// the bytes of a Go string value.
+func encodeUnicodeString(s string) string {
+	buffer := bytes.NewBuffer(nil)
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buffer.WriteByte('\\')
+			buffer.WriteRune(r)
+		case r < 0x20 || r > 0x7E:
+			for _, c := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(buffer, `\u%04X`, c)
+			}
+		default:
+			buffer.WriteRune(r)
+		}
+	}
+	return `"` + buffer.String() + `"`
+}
+
 func getJsTag(tag string) string {
 	for tag != "" {
 		// skip leading space
@@ -764,7 +875,7 @@ func (st signatureTypes) Param(i int, ellipsis bool) types.Type {
 
 // ErrorAt annotates an error with a position in the source code.
 func ErrorAt(err error, fset *token.FileSet, pos token.Pos) error {
-	return fmt.Errorf("%s: %w", fset.Position(pos), err)
+	return &Diagnostic{Severity: SeverityError, Pos: fset.Position(pos), Msg: err.Error(), Err: err, pos: pos}
 }
 
 // FatalError is an error compiler panics with when it encountered a fatal error.
@@ -0,0 +1,236 @@
+// Package livereload watches the files served by "gopherjs serve" and notifies
+// web pages about changes to them, so that the pages can reload themselves.
+package livereload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EventsPath is the URL path of the Server-Sent Events stream, which sends
+	// a "reload" message whenever the watched files change.
+	EventsPath = "/$gopherjs/live"
+	// ClientPath is the URL path of the script that reloads the page when
+	// notified by the server.
+	ClientPath = "/$gopherjs/live.js"
+)
+
+// debounce is how long the server waits for more changes after the first one,
+// so that several files saved at once trigger a single reload.
+const debounce = 100 * time.Millisecond
+
+// client reloads the page when the server sends the "reload" message.
+const client = `(function() {
+  if (typeof window === "undefined" || typeof EventSource === "undefined" || window.$gopherjsLiveReload) {
+    return;
+  }
+  window.$gopherjsLiveReload = true;
+  var events = new EventSource(%q);
+  events.onmessage = function(e) {
+    if (e.data === "reload") {
+      location.reload();
+    }
+  };
+})();
+`
+
+// Server watches source directories and notifies connected pages about
+// changes.
+type Server struct {
+	watcher  *fsnotify.Watcher
+	onChange func(files []string)
+
+	mu      sync.Mutex
+	watched map[string]bool
+	clients map[chan string]bool
+}
+
+// New creates a server and starts watching for changes. If onChange is not nil,
+// it's called with the names of the changed files before the pages are
+// notified. The server must be closed by Close when no longer needed.
+//
+// If the file system can't be watched, the server still serves the client, but
+// the pages are only notified by Notify.
+func New(onChange func(files []string)) *Server {
+	s := &Server{
+		onChange: onChange,
+		watched:  map[string]bool{},
+		clients:  map[chan string]bool{},
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warningf("Failed to watch for changes: %v", err)
+		return s
+	}
+	s.watcher = watcher
+	go s.watch()
+	return s
+}
+
+// Close stops watching for changes.
+func (s *Server) Close() error {
+	if s.watcher == nil {
+		return nil
+	}
+	return s.watcher.Close()
+}
+
+// Watch adds the directories to the watched ones. Directories that can't be
+// watched are skipped.
+func (s *Server) Watch(dirs ...string) {
+	if s.watcher == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dir := range dirs {
+		if s.watched[dir] {
+			continue
+		}
+		if err := s.watcher.Add(dir); err != nil {
+			log.Warningf("Failed to watch %s: %v", dir, err)
+			continue
+		}
+		s.watched[dir] = true
+	}
+}
+
+// watch notifies the clients about changes in the watched directories.
+func (s *Server) watch() {
+	var pending <-chan time.Time
+	var files []string
+	for {
+		select {
+		case ev, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 || strings.HasPrefix(filepath.Base(ev.Name), ".") {
+				continue
+			}
+			log.Infof("Change detected: %s", ev.Name)
+			files = append(files, ev.Name)
+			if pending == nil {
+				pending = time.After(debounce)
+			}
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warningf("Watcher error: %v", err)
+		case <-pending:
+			if s.onChange != nil {
+				s.onChange(files)
+			}
+			pending, files = nil, nil
+			s.Notify("reload")
+		}
+	}
+}
+
+// Notify sends the message to all connected pages.
+func (s *Server) Notify(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c <- msg:
+		default: // The client is slow to receive the previous message.
+		}
+	}
+}
+
+// ServeHTTP serves the events stream at EventsPath and the client script at
+// ClientPath.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case ClientPath:
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprintf(w, client, EventsPath)
+	case EventsPath:
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan string, 1)
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case msg := <-c:
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// InjectClient adds the client script to the HTML page.
+func InjectClient(html []byte) []byte {
+	tag := []byte(`<script src="` + ClientPath + `"></script>`)
+	for _, end := range []string{"</head>", "</body>"} {
+		if i := bytes.Index(bytes.ToLower(html), []byte(end)); i >= 0 {
+			result := append([]byte{}, html[:i]...)
+			result = append(result, tag...)
+			return append(result, html[i:]...)
+		}
+	}
+	return append(append([]byte{}, html...), tag...)
+}
+
+// ErrorOverlay returns a script that displays the errors on top of the page.
+func ErrorOverlay(errs []string) []byte {
+	msgs, err := json.Marshal(strings.Join(errs, "\n"))
+	if err != nil {
+		panic(err) // Strings are always serializable.
+	}
+	return []byte(`(function(msg) {
+  if (typeof document === "undefined") {
+    return;
+  }
+  function show() {
+    var overlay = document.createElement("pre");
+    overlay.id = "$gopherjs-error-overlay";
+    overlay.style.cssText = "position:fixed;top:0;left:0;right:0;bottom:0;z-index:2147483647;margin:0;padding:2em;overflow:auto;background:rgba(0,0,0,0.85);color:#ff8080;font:14px monospace;white-space:pre-wrap";
+    overlay.textContent = msg;
+    document.body.appendChild(overlay);
+  }
+  if (document.body) {
+    show();
+  } else {
+    document.addEventListener("DOMContentLoaded", show);
+  }
+})(` + string(msgs) + `);
+`)
+}
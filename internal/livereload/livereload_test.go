@@ -0,0 +1,115 @@
+package livereload
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	changes := make(chan []string, 1)
+	s := New(func(files []string) { changes <- files })
+	defer s.Close()
+	dir := t.TempDir()
+	s.Watch(dir)
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + EventsPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to the events stream: %s", err)
+	}
+	defer resp.Body.Close()
+	if got, want := resp.Header.Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("Got events stream content type %q, want %q", got, want)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	if line := <-lines; line != ": connected" {
+		t.Fatalf("Got first events stream line %q, want the connection comment", line)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("Events stream ended before the reload message")
+			}
+			if line == "data: reload" {
+				if files := <-changes; len(files) == 0 || files[0] != filepath.Join(dir, "main.go") {
+					t.Errorf("Got changed files %q, want the written file first", files)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatalf("No reload message received after a file change")
+		}
+	}
+}
+
+func TestClientScript(t *testing.T) {
+	s := New(nil)
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + ClientPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `new EventSource("`+EventsPath+`")`) {
+		t.Errorf("Client script doesn't connect to the events stream:\n%s", body)
+	}
+}
+
+func TestInjectClient(t *testing.T) {
+	tag := `<script src="` + ClientPath + `"></script>`
+	tests := []struct {
+		html string
+		want string
+	}{{
+		html: `<html><head><title>x</title></head><body></body></html>`,
+		want: `<html><head><title>x</title>` + tag + `</head><body></body></html>`,
+	}, {
+		html: `<HTML><BODY>x</BODY></HTML>`,
+		want: `<HTML><BODY>x` + tag + `</BODY></HTML>`,
+	}, {
+		html: `<p>fragment</p>`,
+		want: `<p>fragment</p>` + tag,
+	}}
+	for _, test := range tests {
+		if got := string(InjectClient([]byte(test.html))); got != test.want {
+			t.Errorf("InjectClient(%q) returned %q, want %q", test.html, got, test.want)
+		}
+	}
+}
+
+func TestErrorOverlay(t *testing.T) {
+	got := string(ErrorOverlay([]string{`main.go:1:1: expected "package"`, "</script>"}))
+	if want := `})("main.go:1:1: expected \"package\"\n\u003c/script\u003e");`; !strings.Contains(got, want) {
+		t.Errorf("ErrorOverlay() returned:\n%s\nwant it to contain the escaped messages %s", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+// Package test2json converts the output of a test binary run with -test.v into
+// the JSON event stream printed by "go test -json".
+//
+// The events have the same format as the ones of cmd/test2json, see
+// "go doc test2json" for their description.
+package test2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a single event of the stream.
+type Event struct {
+	Time    time.Time
+	Action  string
+	Package string   `json:",omitempty"`
+	Test    string   `json:",omitempty"`
+	Elapsed *float64 `json:",omitempty"`
+	Output  string   `json:",omitempty"`
+}
+
+// markers of the lines which start or resume a test, mapped to the action.
+var markers = []struct{ prefix, action string }{
+	{"=== RUN   ", "run"},
+	{"=== PAUSE ", "pause"},
+	{"=== CONT  ", "cont"},
+}
+
+// reports of the lines which end a test, possibly indented for subtests,
+// mapped to the action.
+var reports = []struct{ prefix, action string }{
+	{"--- PASS: ", "pass"},
+	{"--- FAIL: ", "fail"},
+	{"--- SKIP: ", "skip"},
+	{"--- BENCH: ", "bench"},
+}
+
+// Converter is an io.Writer which converts the output of a test binary of the
+// package into events written to the underlying writer. The summary line of the
+// package as printed by "go test", e.g. "ok  \tpath\t0.123s" or
+// "FAIL\tpath [build failed]", ends the stream with the event for the package.
+//
+// Converter is safe for concurrent use, which allows writing both the standard
+// output and the standard error of the test binary to it.
+type Converter struct {
+	w   io.Writer
+	pkg string
+	now func() time.Time // For testing.
+
+	mu      sync.Mutex
+	started bool
+	test    string // The test the output belongs to.
+	partial []byte // The output after the last newline.
+	err     error
+}
+
+// NewConverter returns a converter of the package's test output writing to w.
+func NewConverter(w io.Writer, pkg string) *Converter {
+	return &Converter{w: w, pkg: pkg, now: time.Now}
+}
+
+// Write converts the complete lines of the output, and buffers the rest.
+func (c *Converter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.partial = append(c.partial, p...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(c.partial[:i+1])
+		c.partial = c.partial[i+1:]
+		c.handleLine(line)
+	}
+	return len(p), c.err
+}
+
+// Close converts the output remaining after the last newline.
+func (c *Converter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.partial) > 0 {
+		c.emit(Event{Action: "output", Test: c.test, Output: string(c.partial)})
+		c.partial = nil
+	}
+	return c.err
+}
+
+func (c *Converter) handleLine(line string) {
+	text := strings.TrimSuffix(line, "\n")
+
+	for _, m := range markers {
+		if strings.HasPrefix(text, m.prefix) {
+			c.test = strings.TrimSpace(text[len(m.prefix):])
+			c.emit(Event{Action: m.action, Test: c.test})
+			c.emit(Event{Action: "output", Test: c.test, Output: line})
+			return
+		}
+	}
+
+	trimmed := strings.TrimLeft(text, " ")
+	for _, r := range reports {
+		if !strings.HasPrefix(trimmed, r.prefix) {
+			continue
+		}
+		name, elapsed := parseReport(trimmed[len(r.prefix):])
+		c.test = name
+		c.emit(Event{Action: "output", Test: name, Output: line})
+		c.emit(Event{Action: r.action, Test: name, Elapsed: elapsed})
+		return
+	}
+
+	switch {
+	case text == "PASS" || text == "FAIL":
+		c.test = ""
+		c.emit(Event{Action: "output", Output: line})
+	case strings.HasPrefix(text, "ok  \t"+c.pkg+"\t"), strings.HasPrefix(text, "FAIL\t"+c.pkg+"\t"):
+		action := "pass"
+		if strings.HasPrefix(text, "FAIL") {
+			action = "fail"
+		}
+		c.test = ""
+		c.emit(Event{Action: "output", Output: line})
+		c.emit(Event{Action: action, Elapsed: parseElapsed(text[strings.LastIndexByte(text, '\t')+1:])})
+	case text == "FAIL\t"+c.pkg+" [build failed]":
+		c.test = ""
+		c.emit(Event{Action: "output", Output: line})
+		c.emit(Event{Action: "fail"})
+	case strings.HasPrefix(text, "?   \t"+c.pkg+"\t"):
+		c.test = ""
+		c.emit(Event{Action: "output", Output: line})
+		c.emit(Event{Action: "skip"})
+	default:
+		c.emit(Event{Action: "output", Test: c.test, Output: line})
+	}
+}
+
+// parseReport parses the test name and the elapsed time from the rest of
+// a report line, e.g. "TestFoo (0.01s)".
+func parseReport(s string) (string, *float64) {
+	i := strings.LastIndex(s, " (")
+	if i < 0 || !strings.HasSuffix(s, ")") {
+		return strings.TrimSpace(s), nil
+	}
+	return s[:i], parseElapsed(s[i+2 : len(s)-1])
+}
+
+// parseElapsed parses a duration in seconds, e.g. "0.01s".
+func parseElapsed(s string) *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+	if err != nil || !strings.HasSuffix(s, "s") {
+		return nil
+	}
+	return &f
+}
+
+// emit writes the event for the package, preceded by the start event of the
+// package if it's the first one.
+func (c *Converter) emit(e Event) {
+	if !c.started {
+		c.started = true
+		c.emit(Event{Action: "start"})
+	}
+	e.Time = c.now()
+	e.Package = c.pkg
+	data, err := json.Marshal(e)
+	if err != nil {
+		panic(err) // Events are always serializable.
+	}
+	if _, err := c.w.Write(append(data, '\n')); err != nil && c.err == nil {
+		c.err = err
+	}
+}
@@ -0,0 +1,107 @@
+package test2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConverter(t *testing.T) {
+	output := "=== RUN   TestA\n" +
+		"=== RUN   TestA/sub\n" +
+		"    a_test.go:10: log\n" +
+		"=== PAUSE TestA/sub\n" +
+		"=== CONT  TestA/sub\n" +
+		"    --- PASS: TestA/sub (0.01s)\n" +
+		"--- FAIL: TestA (0.02s)\n" +
+		"    a_test.go:12: failure\n" +
+		"FAIL\n" +
+		"FAIL\texample.com/a\t0.123s\n"
+
+	var buf bytes.Buffer
+	c := NewConverter(&buf, "example.com/a")
+	c.now = func() time.Time { return time.Time{} }
+	// Lines split across writes are converted once complete.
+	for _, chunk := range []string{output[:5], output[5:40], output[40:]} {
+		if _, err := io.WriteString(c, chunk); err != nil {
+			t.Fatalf("Write() returned error: %s", err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() returned error: %s", err)
+	}
+
+	var got []Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("Failed to decode event: %s", err)
+		}
+		got = append(got, e)
+	}
+
+	elapsed := func(f float64) *float64 { return &f }
+	want := []Event{
+		{Action: "start"},
+		{Action: "run", Test: "TestA"},
+		{Action: "output", Test: "TestA", Output: "=== RUN   TestA\n"},
+		{Action: "run", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA/sub", Output: "=== RUN   TestA/sub\n"},
+		{Action: "output", Test: "TestA/sub", Output: "    a_test.go:10: log\n"},
+		{Action: "pause", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA/sub", Output: "=== PAUSE TestA/sub\n"},
+		{Action: "cont", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA/sub", Output: "=== CONT  TestA/sub\n"},
+		{Action: "output", Test: "TestA/sub", Output: "    --- PASS: TestA/sub (0.01s)\n"},
+		{Action: "pass", Test: "TestA/sub", Elapsed: elapsed(0.01)},
+		{Action: "output", Test: "TestA", Output: "--- FAIL: TestA (0.02s)\n"},
+		{Action: "fail", Test: "TestA", Elapsed: elapsed(0.02)},
+		{Action: "output", Test: "TestA", Output: "    a_test.go:12: failure\n"},
+		{Action: "output", Output: "FAIL\n"},
+		{Action: "output", Output: "FAIL\texample.com/a\t0.123s\n"},
+		{Action: "fail", Elapsed: elapsed(0.123)},
+	}
+	for i := range want {
+		want[i].Package = "example.com/a"
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Converter produced diff in events (-want,+got):\n%s", diff)
+	}
+}
+
+func TestConverterPackageSummaries(t *testing.T) {
+	tests := []struct {
+		summary string
+		actions []string
+	}{
+		{summary: "?   \texample.com/b\t[no test files]\n", actions: []string{"start", "output", "skip"}},
+		{summary: "FAIL\texample.com/b [build failed]\n", actions: []string{"start", "output", "fail"}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		c := NewConverter(&buf, "example.com/b")
+		io.WriteString(c, test.summary)
+		c.Close()
+
+		var actions []string
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var e Event
+			if err := dec.Decode(&e); err != nil {
+				t.Fatalf("Failed to decode event: %s", err)
+			}
+			if e.Package != "example.com/b" {
+				t.Errorf("Got event of package %q for %q, want example.com/b", e.Package, test.summary)
+			}
+			actions = append(actions, e.Action)
+		}
+		if diff := cmp.Diff(test.actions, actions); diff != "" {
+			t.Errorf("Converter produced diff in actions for %q (-want,+got):\n%s", test.summary, diff)
+		}
+	}
+}
@@ -64,6 +64,12 @@ func (ef ExampleFunc) Executable() bool {
 	return ef.EmptyOutput || ef.Output != ""
 }
 
+// Cover describes the coverage analysis of the package under test.
+type Cover struct {
+	Mode string           // One of build.CoverModes.
+	Vars []build.CoverVar // Counters of the package's sources.
+}
+
 // TestMain is a helper type responsible for generation of the test main package.
 type TestMain struct {
 	Package    *build.PackageData
@@ -72,6 +78,9 @@ type TestMain struct {
 	Fuzz       []TestFunc
 	Examples   []ExampleFunc
 	TestMain   *TestFunc
+	// Coverage counters to register with the testing package, if the package
+	// under test is instrumented for coverage analysis.
+	Cover *Cover
 }
 
 // Scan package for tests functions.
@@ -267,6 +276,9 @@ import (
 {{- if .ImportXTest -}}
 	{{if .ExecutesXTest}}_xtest{{else}}_{{end}} {{.Package.ImportPath | printf "%s_test" | printf "%q"}}
 {{end}}
+{{- if and .Cover .Cover.Vars}}
+	_cover {{.Package.ImportPath | printf "%q"}}
+{{end}}
 )
 
 var tests = []testing.InternalTest{
@@ -294,8 +306,47 @@ var examples = []testing.InternalExample{
 {{- end }}
 {{- end }}
 }
+{{if .Cover}}
+var (
+	coverCounters = make(map[string][]uint32)
+	coverBlocks   = make(map[string][]testing.CoverBlock)
+)
+
+func init() {
+{{- range .Cover.Vars}}
+	coverRegisterFile({{.File | printf "%q"}}, _cover.{{.Var}}.Count[:], _cover.{{.Var}}.Pos[:], _cover.{{.Var}}.NumStmt[:])
+{{- end}}
+}
 
+func coverRegisterFile(fileName string, counter []uint32, pos []uint32, numStmts []uint16) {
+	if 3*len(counter) != len(pos) || len(counter) != len(numStmts) {
+		panic("coverage: mismatched sizes")
+	}
+	if coverCounters[fileName] != nil {
+		return
+	}
+	coverCounters[fileName] = counter
+	block := make([]testing.CoverBlock, len(counter))
+	for i := range counter {
+		block[i] = testing.CoverBlock{
+			Line0: pos[3*i+0],
+			Col0:  uint16(pos[3*i+2]),
+			Line1: pos[3*i+1],
+			Col1:  uint16(pos[3*i+2] >> 16),
+			Stmts: numStmts[i],
+		}
+	}
+	coverBlocks[fileName] = block
+}
+{{end}}
 func main() {
+{{- if .Cover}}
+	testing.RegisterCover(testing.Cover{
+		Mode:     {{.Cover.Mode | printf "%q"}},
+		Counters: coverCounters,
+		Blocks:   coverBlocks,
+	})
+{{- end}}
 	m := testing.MainStart(testdeps.TestDeps{}, tests, benchmarks, fuzzTargets, examples)
 {{with .TestMain}}
 	{{.Location}}.{{.Name}}(m)
@@ -101,6 +101,22 @@ func TestSynthesize(t *testing.T) {
 				},
 			},
 			wantSrc: importOnly,
+		}, {
+			descr: "coverage",
+			tm: TestMain{
+				Package: pkg,
+				Tests: []TestFunc{
+					{Location: LocExternal, Name: "TestYyy"},
+				},
+				Cover: &Cover{
+					Mode: "set",
+					Vars: []build.CoverVar{
+						{File: "foo/bar/a.go", Var: "GoCover_0"},
+						{File: "foo/bar/b.go", Var: "GoCover_1"},
+					},
+				},
+			},
+			wantSrc: coverage,
 		},
 	}
 
@@ -208,3 +224,69 @@ func main() {
 	os.Exit(m.Run())
 }
 `
+
+const coverage = `package main
+
+import (
+	"os"
+
+	"testing"
+	"testing/internal/testdeps"
+
+	_xtest "foo/bar_test"
+
+	_cover "foo/bar"
+)
+
+var tests = []testing.InternalTest{
+	{"TestYyy", _xtest.TestYyy},
+}
+
+var benchmarks = []testing.InternalBenchmark{}
+
+var fuzzTargets = []testing.InternalFuzzTarget{}
+
+var examples = []testing.InternalExample{}
+
+var (
+	coverCounters = make(map[string][]uint32)
+	coverBlocks   = make(map[string][]testing.CoverBlock)
+)
+
+func init() {
+	coverRegisterFile("foo/bar/a.go", _cover.GoCover_0.Count[:], _cover.GoCover_0.Pos[:], _cover.GoCover_0.NumStmt[:])
+	coverRegisterFile("foo/bar/b.go", _cover.GoCover_1.Count[:], _cover.GoCover_1.Pos[:], _cover.GoCover_1.NumStmt[:])
+}
+
+func coverRegisterFile(fileName string, counter []uint32, pos []uint32, numStmts []uint16) {
+	if 3*len(counter) != len(pos) || len(counter) != len(numStmts) {
+		panic("coverage: mismatched sizes")
+	}
+	if coverCounters[fileName] != nil {
+		return
+	}
+	coverCounters[fileName] = counter
+	block := make([]testing.CoverBlock, len(counter))
+	for i := range counter {
+		block[i] = testing.CoverBlock{
+			Line0: pos[3*i+0],
+			Col0:  uint16(pos[3*i+2]),
+			Line1: pos[3*i+1],
+			Col1:  uint16(pos[3*i+2] >> 16),
+			Stmts: numStmts[i],
+		}
+	}
+	coverBlocks[fileName] = block
+}
+
+func main() {
+	testing.RegisterCover(testing.Cover{
+		Mode:     "set",
+		Counters: coverCounters,
+		Blocks:   coverBlocks,
+	})
+	m := testing.MainStart(testdeps.TestDeps{}, tests, benchmarks, fuzzTargets, examples)
+
+	os.Exit(m.Run())
+}
+`
@@ -104,6 +104,12 @@ var Global *Object
 // modules (https://nodejs.org/api/modules.html). NodeJS supports it natively,
 // but in browsers it can only be used if GopherJS output is passed through a
 // bundler which implements CommonJS (for example, webpack or esbuild).
+//
+// When the program is built as an ECMAScript module (gopherjs build
+// --format=esm), js.Module is an object emulating the CommonJS one. Its
+// "exports" object becomes the default export of the module, and properties set
+// on it with constant names become named exports once the main function
+// returns.
 var Module *Object
 
 // Undefined gives the JavaScript value "undefined".
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"embed"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+//go:embed testdata/embed/hello.txt
+var embeddedString string
+
+//go:embed testdata/embed/hello.txt
+var embeddedBytes []byte
+
+//go:embed testdata/embed/dir
+var embeddedDir embed.FS
+
+//go:embed all:testdata/embed/dir testdata/embed/*.txt
+var embeddedAll embed.FS
+
+func TestEmbedString(t *testing.T) {
+	if want := "Hello, embed!\n"; embeddedString != want {
+		t.Errorf("embeddedString = %q, want %q", embeddedString, want)
+	}
+	if want := []byte("Hello, embed!\n"); !reflect.DeepEqual(embeddedBytes, want) {
+		t.Errorf("embeddedBytes = %q, want %q", embeddedBytes, want)
+	}
+}
+
+func TestEmbedFS(t *testing.T) {
+	data, err := embeddedDir.ReadFile("testdata/embed/dir/sub/two.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %s", err)
+	}
+	if string(data) != "two" {
+		t.Errorf("ReadFile() = %q, want %q", data, "two")
+	}
+
+	if _, err := embeddedDir.Open("testdata/embed/dir/.hidden.txt"); err == nil {
+		t.Errorf("Open() of a hidden file unexpectedly succeeded")
+	}
+
+	tests := []struct {
+		fsys embed.FS
+		want []string
+	}{{
+		fsys: embeddedDir,
+		want: []string{
+			".",
+			"testdata",
+			"testdata/embed",
+			"testdata/embed/dir",
+			"testdata/embed/dir/one.txt",
+			"testdata/embed/dir/sub",
+			"testdata/embed/dir/sub/two.txt",
+		},
+	}, {
+		fsys: embeddedAll,
+		want: []string{
+			".",
+			"testdata",
+			"testdata/embed",
+			"testdata/embed/dir",
+			"testdata/embed/dir/.hidden.txt",
+			"testdata/embed/dir/one.txt",
+			"testdata/embed/dir/sub",
+			"testdata/embed/dir/sub/two.txt",
+			"testdata/embed/hello.txt",
+		},
+	}}
+
+	for _, test := range tests {
+		var got []string
+		err := fs.WalkDir(test.fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			got = append(got, path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("fs.WalkDir() returned error: %s", err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("fs.WalkDir() visited %v, want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func FuzzSeedCorpus(f *testing.F) {
+	var seen []string
+	f.Cleanup(func() {
+		if flag.Lookup("test.fuzz").Value.String() != "" {
+			return // Inputs are tested by the fuzzing worker.
+		}
+		sort.Strings(seen)
+		// The seeds from f.Add and testdata/fuzz/FuzzSeedCorpus/file.
+		if got, want := strings.Join(seen, ","), "add 1,file 2"; got != want {
+			f.Errorf("Fuzz function got inputs %q, want %q", got, want)
+		}
+	})
+	f.Add("add", 1)
+	f.Fuzz(func(t *testing.T, s string, n int) {
+		seen = append(seen, fmt.Sprint(s, " ", n))
+	})
+}
@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/tests/otherpkg"
+)
+
+type number interface {
+	~int | ~int8 | ~uint32 | ~int64 | ~float32 | ~float64 | ~complex128
+}
+
+func genericSum[T number](xs ...T) T {
+	var sum T
+	for _, x := range xs {
+		sum += x
+	}
+	return sum
+}
+
+func genericMap[T, U any](xs []T, f func(T) U) []U {
+	result := make([]U, 0, len(xs))
+	for _, x := range xs {
+		result = append(result, f(x))
+	}
+	return result
+}
+
+func genericFilter[S ~[]E, E any](s S, f func(E) bool) S {
+	var result S
+	for _, e := range s {
+		if f(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+type wideInteger interface{ ~int64 | ~uint64 }
+
+func genericAddWide[T wideInteger](x T) T { return x + 1<<60 + 1 }
+
+func genericMaxUint64[T ~uint64]() T { return 1<<64 - 1 }
+
+func genericOnePlus[T ~int | ~complex128](x T) T { return 1 + x }
+
+func genericZero[T any]() T {
+	var zero T
+	return zero
+}
+
+func genericEqual[T comparable](a, b T) bool { return a == b }
+
+func genericPtr[T any](x T) *T { return &x }
+
+func genericDeref[T any](p *T) T { return *p }
+
+func genericSend[T any](v T) T {
+	c := make(chan T)
+	go func() { c <- v }()
+	return <-c
+}
+
+func genericTypeSwitch[T any](x interface{}) bool {
+	switch x.(type) {
+	case T:
+		return true
+	default:
+		return false
+	}
+}
+
+type genericStringer interface{ String() string }
+
+func genericString[T genericStringer](x T) string { return x.String() }
+
+type genericList[T any] struct {
+	head *genericNode[T]
+	len  int
+}
+
+type genericNode[T any] struct {
+	value T
+	next  *genericNode[T]
+}
+
+func (l *genericList[T]) Push(v T) {
+	l.head = &genericNode[T]{value: v, next: l.head}
+	l.len++
+}
+
+func (l genericList[T]) Values() []T {
+	var result []T
+	for n := l.head; n != nil; n = n.next {
+		result = append(result, n.value)
+	}
+	return result
+}
+
+type genericStack[T any] []T
+
+func (s *genericStack[T]) Push(v T) { *s = append(*s, v) }
+
+func (s *genericStack[T]) Pop() T {
+	v := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return v
+}
+
+type stringerInt int
+
+func (i stringerInt) String() string { return fmt.Sprintf("stringerInt(%d)", int(i)) }
+
+type genericPoint struct{ X, Y int }
+
+func TestGenericFunctions(t *testing.T) {
+	if got := genericSum(1, 2, 3); got != 6 {
+		t.Errorf("genericSum(1, 2, 3) = %v, want 6", got)
+	}
+	if got := genericSum[int8](100, 100); got != -56 {
+		t.Errorf("genericSum[int8](100, 100) = %v, want -56", got)
+	}
+	if got := genericSum[uint32](4294967295, 2); got != 1 {
+		t.Errorf("genericSum[uint32](4294967295, 2) = %v, want 1", got)
+	}
+	if got := genericSum[int64](1<<40, 1<<40); got != 1<<41 {
+		t.Errorf("genericSum[int64](1<<40, 1<<40) = %v, want %v", got, int64(1<<41))
+	}
+	// Constants above 2^53 aren't exact as JavaScript numbers.
+	if got := genericAddWide[int64](1); got != 1<<60+2 {
+		t.Errorf("genericAddWide[int64](1) = %v, want %v", got, int64(1<<60+2))
+	}
+	if got := genericAddWide[uint64](1 << 63); got != 1<<63+1<<60+1 {
+		t.Errorf("genericAddWide[uint64](1 << 63) = %v, want %v", got, uint64(1<<63+1<<60+1))
+	}
+	if got := genericMaxUint64[uint64](); got != 1<<64-1 {
+		t.Errorf("genericMaxUint64[uint64]() = %v, want %v", got, uint64(1<<64-1))
+	}
+	if got := genericSum[float32](0.1, 0.2); got != float32(0.1)+float32(0.2) {
+		t.Errorf("genericSum[float32](0.1, 0.2) = %v, want %v", got, float32(0.1)+float32(0.2))
+	}
+	if got := genericSum(1+2i, 3+4i); got != 4+6i {
+		t.Errorf("genericSum(1+2i, 3+4i) = %v, want (4+6i)", got)
+	}
+	// Untyped integer constants become complex values.
+	if got := genericOnePlus[complex128](2i); got != 1+2i {
+		t.Errorf("genericOnePlus[complex128](2i) = %v, want (1+2i)", got)
+	}
+	if got := genericOnePlus[int](2); got != 3 {
+		t.Errorf("genericOnePlus[int](2) = %v, want 3", got)
+	}
+
+	strs := genericMap([]int{1, 2, 3}, func(i int) string { return fmt.Sprint(i * 2) })
+	if want := []string{"2", "4", "6"}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("genericMap() = %v, want %v", strs, want)
+	}
+	evens := genericFilter([]int{1, 2, 3, 4, 5, 6}, func(i int) bool { return i%2 == 0 })
+	if want := []int{2, 4, 6}; !reflect.DeepEqual(evens, want) {
+		t.Errorf("genericFilter() = %v, want %v", evens, want)
+	}
+
+	if got := genericZero[genericPoint](); got != (genericPoint{}) {
+		t.Errorf("genericZero[genericPoint]() = %v, want zero value", got)
+	}
+	if got := genericZero[*int](); got != nil {
+		t.Errorf("genericZero[*int]() = %v, want nil", got)
+	}
+	if !genericEqual(genericPoint{1, 2}, genericPoint{1, 2}) || genericEqual("a", "b") {
+		t.Errorf("genericEqual() returned unexpected results")
+	}
+	if got := genericString(stringerInt(42)); got != "stringerInt(42)" {
+		t.Errorf("genericString(stringerInt(42)) = %q, want %q", got, "stringerInt(42)")
+	}
+	if got := genericSend(genericPoint{3, 4}); got != (genericPoint{3, 4}) {
+		t.Errorf("genericSend() = %v, want {3 4}", got)
+	}
+	if !genericTypeSwitch[int](1) || genericTypeSwitch[string](1) || genericTypeSwitch[error](nil) {
+		t.Errorf("genericTypeSwitch() returned unexpected results")
+	}
+
+	sum := genericSum[float64]
+	if got := sum(1, 2); got != 3 {
+		t.Errorf("sum(1, 2) = %v, want 3", got)
+	}
+}
+
+func TestGenericPointers(t *testing.T) {
+	p := genericPtr(genericPoint{1, 2})
+	p.Y = 3
+	if got := genericDeref(p); got != (genericPoint{1, 3}) {
+		t.Errorf("genericDeref(p) = %v, want {1 3}", got)
+	}
+
+	i := genericPtr(1)
+	*i = 2
+	if got := genericDeref(i); got != 2 {
+		t.Errorf("genericDeref(i) = %v, want 2", got)
+	}
+
+	a := genericPtr([2]int{1, 2})
+	a[0] = 3
+	if got := genericDeref(a); got != [2]int{3, 2} {
+		t.Errorf("genericDeref(a) = %v, want [3 2]", got)
+	}
+}
+
+func TestGenericTypes(t *testing.T) {
+	var l genericList[genericPoint]
+	l.Push(genericPoint{1, 2})
+	l.Push(genericPoint{3, 4})
+	if want := []genericPoint{{3, 4}, {1, 2}}; !reflect.DeepEqual(l.Values(), want) {
+		t.Errorf("l.Values() = %v, want %v", l.Values(), want)
+	}
+
+	var s genericStack[string]
+	push := s.Push
+	push("a")
+	push("b")
+	if got := s.Pop(); got != "b" || len(s) != 1 {
+		t.Errorf("s.Pop() = %q, len(s) = %d; want \"b\", 1", got, len(s))
+	}
+
+	values := genericList[string].Values
+	if got := values(genericList[string]{}); got != nil {
+		t.Errorf("values(genericList[string]{}) = %v, want nil", got)
+	}
+
+	var x interface{} = l
+	if _, ok := x.(genericList[genericPoint]); !ok {
+		t.Errorf("x.(genericList[genericPoint]) failed")
+	}
+	if _, ok := x.(genericList[int]); ok {
+		t.Errorf("x.(genericList[int]) unexpectedly succeeded")
+	}
+}
+
+func TestGenericTypeNames(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{genericList[int]{}, "tests.genericList[int]"},
+		{genericStack[*genericPoint]{}, "tests.genericStack[*github.com/gopherjs/gopherjs/tests.genericPoint]"},
+		{&genericList[[]string]{}, "*tests.genericList[[]string]"},
+		{otherpkg.Pair[int, string]{}, "otherpkg.Pair[int,string]"},
+	}
+
+	for _, test := range tests {
+		if got := reflect.TypeOf(test.value).String(); got != test.want {
+			t.Errorf("reflect.TypeOf(%#v).String() = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestGenericCrossPackage(t *testing.T) {
+	p := otherpkg.MakePair("answer", 41)
+	p.SetValue(42)
+	if p.Key != "answer" || p.Value != 42 {
+		t.Errorf("p = %v, want {answer 42}", p)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
@@ -49,3 +50,24 @@ func TestDeferBuiltin(t *testing.T) {
 		t.Fatalf("%v:\n%s", err, got)
 	}
 }
+
+func TestDeadlockReport(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("test meant to be run using normal Go compiler (needs os/exec)")
+	}
+
+	got, err := exec.Command("gopherjs", "run", filepath.Join("testdata", "deadlock.go")).CombinedOutput()
+	if err == nil {
+		t.Fatalf("gopherjs run succeeded, want the program to deadlock:\n%s", got)
+	}
+	for _, want := range []string{
+		`fatal error: all goroutines are asleep - deadlock!\n`,
+		`goroutine 1 \[chan receive\]:\nmain\.\(\*waiter\)\.wait\(\.\.\.\)\n\tmain/deadlock\.go:6\nmain\.main\(\.\.\.\)\n\tmain/deadlock\.go:19\n`,
+		`goroutine 2 \[chan send\]:\nmain\.send\(\.\.\.\)\n\tmain/deadlock\.go:10\ncreated by main\.main\n\tmain/deadlock\.go:15\n`,
+		`goroutine 3 \[select \(no cases\)\]:\nmain\.main\.func1\(\.\.\.\)\n\tmain/deadlock\.go:17\ncreated by main\.main\n\tmain/deadlock\.go:16\n`,
+	} {
+		if !regexp.MustCompile(want).Match(got) {
+			t.Errorf("gopherjs run wrote:\n%s\nwant a report matching %q", got, want)
+		}
+	}
+}
@@ -1,3 +1,16 @@
 package otherpkg
 
 var Test float32
+
+// Pair is a generic type used to test cross-package instantiation.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func (p *Pair[K, V]) SetValue(v V) { p.Value = v }
+
+// MakePair is a generic function used to test cross-package instantiation.
+func MakePair[K comparable, V any](k K, v V) Pair[K, V] {
+	return Pair[K, V]{Key: k, Value: v}
+}
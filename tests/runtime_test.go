@@ -3,9 +3,18 @@
 package tests
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"regexp"
 	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/gopherjs/gopherjs/js"
@@ -52,6 +61,11 @@ func Test_parseCallFrame(t *testing.T) {
 			input: "at REPLServer.runBound [as eval] (domain.js:440:12)",
 			want:  "eval domain.js 440 12",
 		},
+		{
+			name:  "Node.js v18.19.1, pointer method",
+			input: "    at main.(*T).wait (/tmp/main.js:3337:8)",
+			want:  "main.(*T).wait /tmp/main.js 3337 8",
+		},
 		{
 			name:  "Firefox 78.15.0esr Linux",
 			input: "getEvalResult@resource://devtools/server/actors/webconsole/eval-with-debugger.js:231:24",
@@ -80,9 +94,13 @@ func TestBuildPlatform(t *testing.T) {
 	}
 }
 
-type funcName string
+// minified reports whether the tests are built with --minify, in which case
+// the functions in stack traces don't have their Go names.
+func minified() bool {
+	return !strings.HasSuffix(js.InternalObject(minified).Get("name").String(), ".minified")
+}
 
-func masked(_ funcName) funcName { return "<MASKED>" }
+type funcName string
 
 type callStack []funcName
 
@@ -101,38 +119,35 @@ func (c *callStack) capture() {
 }
 
 func TestCallers(t *testing.T) {
-	got := callStack{}
-
-	// Some of the GopherJS function names don't match upstream Go, or even the
-	// function names in the Go source when minified.
-	// Until https://github.com/gopherjs/gopherjs/issues/1085 is resolved, the
-	// mismatch is difficult to avoid, but we can at least use "masked" frames to
-	// make sure the number of frames matches expected.
-	want := callStack{
-		masked("runtime.Callers"),
-		masked("github.com/gopherjs/gopherjs/tests.(*callerNames).capture"),
-		masked("github.com/gopherjs/gopherjs/tests.TestCallers.func{1,2}"),
-		masked("testing.tRunner"),
-		"runtime.goexit",
+	if minified() {
+		t.Skip("Go function names aren't known in minified code.")
 	}
-
-	opts := cmp.Comparer(func(a, b funcName) bool {
-		if a == masked("") || b == masked("") {
-			return true
-		}
-		return a == b
-	})
+	got := callStack{}
 
 	t.Run("Normal", func(t *testing.T) {
 		got.capture()
-		if diff := cmp.Diff(want, got, opts); diff != "" {
+		want := callStack{
+			"runtime.Callers",
+			"github.com/gopherjs/gopherjs/tests.(*callStack).capture",
+			"github.com/gopherjs/gopherjs/tests.TestCallers.func1",
+			"testing.tRunner",
+			"runtime.goexit",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("runtime.Callers() returned a diff (-want,+got):\n%s", diff)
 		}
 	})
 
 	t.Run("Deferred", func(t *testing.T) {
 		defer func() {
-			if diff := cmp.Diff(want, got, opts); diff != "" {
+			want := callStack{
+				"runtime.Callers",
+				"github.com/gopherjs/gopherjs/tests.(*callStack).capture",
+				"github.com/gopherjs/gopherjs/tests.TestCallers.func2",
+				"testing.tRunner",
+				"runtime.goexit",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
 				t.Errorf("runtime.Callers() returned a diff (-want,+got):\n%s", diff)
 			}
 		}()
@@ -145,18 +160,329 @@ func TestCallers(t *testing.T) {
 			got.capture()
 
 			want := callStack{
-				masked("runtime.Callers"),
-				masked("github.com/gopherjs/gopherjs/tests.(*callerNames).capture"),
-				masked("github.com/gopherjs/gopherjs/tests.TestCallers.func3.1"),
+				"runtime.Callers",
+				"github.com/gopherjs/gopherjs/tests.(*callStack).capture",
+				"github.com/gopherjs/gopherjs/tests.TestCallers.func3.1",
 				"runtime.gopanic",
-				masked("github.com/gopherjs/gopherjs/tests.TestCallers.func{1,2}"),
-				masked("testing.tRunner"),
+				"github.com/gopherjs/gopherjs/tests.TestCallers.func3",
+				"testing.tRunner",
 				"runtime.goexit",
 			}
-			if diff := cmp.Diff(want, got, opts); diff != "" {
+			if diff := cmp.Diff(want, got); diff != "" {
 				t.Errorf("runtime.Callers() returned a diff (-want,+got):\n%s", diff)
 			}
 		}()
 		panic("panic")
 	})
 }
+
+type embedsCallStack struct{ *callStack }
+
+func TestCallersMethodWrapper(t *testing.T) {
+	if minified() {
+		t.Skip("Go function names aren't known in minified code.")
+	}
+	// The promoted method is called through a wrapper, which is hidden the same
+	// way as in upstream Go.
+	got := callStack{}
+	var c interface{ capture() } = embedsCallStack{&got}
+	c.capture()
+	want := callStack{
+		"runtime.Callers",
+		"github.com/gopherjs/gopherjs/tests.(*callStack).capture",
+		"github.com/gopherjs/gopherjs/tests.TestCallersMethodWrapper",
+		"testing.tRunner",
+		"runtime.goexit",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("runtime.Callers() returned a diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestAllocsPerRun(t *testing.T) {
+	var (
+		ptr   *int
+		slice []int
+		m     map[int]int
+		ch    chan int
+		s     *struct{ a int }
+		arr   [2]int
+		buf   = make([]int, 0, 1)
+	)
+	tests := []struct {
+		name string
+		fn   func()
+		want float64
+	}{
+		{name: "new", fn: func() { ptr = new(int) }, want: 1},
+		{name: "make slice", fn: func() { slice = make([]int, 10) }, want: 1},
+		{name: "make map", fn: func() { m = make(map[int]int) }, want: 1},
+		{name: "make chan", fn: func() { ch = make(chan int) }, want: 1},
+		{name: "slice literal", fn: func() { slice = []int{1, 2} }, want: 1},
+		{name: "map literal", fn: func() { m = map[int]int{1: 2} }, want: 1},
+		{name: "struct literal address", fn: func() { s = &struct{ a int }{1} }, want: 1},
+		{name: "append with growth", fn: func() { slice = append([]int(nil), 1) }, want: 1},
+		{name: "append in place", fn: func() { buf = append(buf[:0], 1) }, want: 0},
+		{name: "array literal", fn: func() { arr = [2]int{1, 2} }, want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := testing.AllocsPerRun(10, test.fn); got != test.want {
+				t.Errorf("testing.AllocsPerRun() returned %v, want %v", got, test.want)
+			}
+		})
+	}
+	_, _, _, _, _, _ = ptr, slice, m, ch, s, arr
+}
+
+type finalized struct{ name string }
+
+// setFinalizer sets a finalizer on a new object, which is unreachable once it
+// returns, and reports the name it sees to done.
+func setFinalizer(done chan<- string) {
+	o := &finalized{name: "initial"}
+	runtime.SetFinalizer(o, func(o *finalized) { done <- o.name })
+	// Updates made after SetFinalizer are visible to the finalizer.
+	o.name = "updated"
+
+	removed := &finalized{}
+	runtime.SetFinalizer(removed, func(*finalized) { done <- "removed" })
+	runtime.SetFinalizer(removed, nil)
+}
+
+func TestSetFinalizer(t *testing.T) {
+	if js.Global.Get("gc") == js.Undefined {
+		require := js.Global.Get("require")
+		if require == js.Undefined {
+			t.Skip("Garbage collection can't be triggered outside of Node.js.")
+		}
+		require.Invoke("v8").Call("setFlagsFromString", "--expose-gc")
+		js.Global.Set("gc", require.Invoke("vm").Call("runInNewContext", "gc"))
+		defer js.Global.Delete("gc")
+	}
+
+	done := make(chan string, 2)
+	setFinalizer(done)
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		select {
+		case got := <-done:
+			if got != "updated" {
+				t.Errorf("Finalizer got object with name %q, want %q", got, "updated")
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Errorf("Finalizer wasn't called after the object was garbage-collected.")
+}
+
+func TestSetFinalizerErrors(t *testing.T) {
+	withFinalizer := &finalized{}
+	runtime.SetFinalizer(withFinalizer, func(*finalized) {})
+	tests := []struct {
+		name      string
+		obj       interface{}
+		finalizer interface{}
+		want      string
+	}{
+		{name: "nil", obj: nil, finalizer: func() {}, want: "runtime error: runtime.SetFinalizer: first argument is nil"},
+		{name: "not pointer", obj: 1, finalizer: func(int) {}, want: "runtime error: runtime.SetFinalizer: first argument is int, not pointer"},
+		{name: "pointer to array", obj: &[1]int{}, finalizer: func(*[1]int) {}, want: "runtime error: runtime.SetFinalizer: first argument is *[1]int, pointers to arrays are not supported by GopherJS"},
+		{name: "not function", obj: &finalized{}, finalizer: 1, want: "runtime error: runtime.SetFinalizer: second argument is int, not a function"},
+		{name: "wrong parameter", obj: &finalized{}, finalizer: func(*int) {}, want: "runtime error: runtime.SetFinalizer: cannot pass *tests.finalized to finalizer func(*int)"},
+		{name: "already set", obj: withFinalizer, finalizer: func(*finalized) {}, want: "runtime error: runtime.SetFinalizer: finalizer already set"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if got := fmt.Sprint(recover()); got != test.want {
+					t.Errorf("runtime.SetFinalizer() panicked with %q, want %q", got, test.want)
+				}
+			}()
+			runtime.SetFinalizer(test.obj, test.finalizer)
+		})
+	}
+}
+
+func TestReadMemStats(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	p := &struct{ a, b int64 }{}
+	s := make([]int32, 10)
+	runtime.ReadMemStats(&after)
+	_, _ = p, s
+
+	if got := after.Mallocs - before.Mallocs; got != 2 {
+		t.Errorf("Got %d allocations, want 2", got)
+	}
+	if got := after.TotalAlloc - before.TotalAlloc; got != 56 {
+		t.Errorf("Got %d bytes allocated, want 56", got)
+	}
+	if js.Global.Get("process") != js.Undefined && after.HeapAlloc == 0 {
+		t.Errorf("Got zero HeapAlloc in Node.js")
+	}
+	if after.Sys != after.HeapSys+after.OtherSys || after.HeapSys != after.HeapInuse+after.HeapIdle {
+		t.Errorf("Inconsistent MemStats: %+v", after)
+	}
+}
+
+func TestMetricsRead(t *testing.T) {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/sched/goroutines:goroutines"},
+		{Name: "/gc/pauses:seconds"},
+	}
+	metrics.Read(samples)
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	if got := samples[0].Value.Kind(); got != metrics.KindUint64 {
+		t.Fatalf("Got kind %v of %s, want KindUint64", got, samples[0].Name)
+	}
+	if samples[0].Value.Uint64() == 0 && m.Sys != 0 {
+		t.Errorf("Got zero %s, want MemStats.Sys", samples[0].Name)
+	}
+	if got := samples[1].Value.Uint64(); got == 0 {
+		t.Errorf("Got zero %s", samples[1].Name)
+	}
+	if got := samples[2].Value.Kind(); got != metrics.KindBad {
+		t.Errorf("Got kind %v of the unsupported %s, want KindBad", got, samples[2].Name)
+	}
+}
+
+//go:noinline
+func cpuHog(n int) (x float64) {
+	for i := 0; i < n; i++ {
+		x += float64(i % 7)
+	}
+	return x
+}
+
+func TestCPUProfile(t *testing.T) {
+	if minified() {
+		t.Skip("Go function names aren't known in minified code.")
+	}
+	if js.Global.Get("require") == js.Undefined {
+		t.Skip("CPU profiling is only supported in Node.js.")
+	}
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		t.Fatalf("pprof.StartCPUProfile() returned error: %v", err)
+	}
+	if err := pprof.StartCPUProfile(&buf); err == nil {
+		t.Errorf("pprof.StartCPUProfile() returned no error while profiling")
+	}
+	for start := time.Now(); time.Since(start) < 200*time.Millisecond; {
+		cpuHog(100000)
+	}
+	pprof.StopCPUProfile()
+
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decompress the profile: %v", err)
+	}
+	profile, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress the profile: %v", err)
+	}
+	// The string table of the profile contains the names and files of the
+	// sampled functions.
+	for _, want := range []string{"github.com/gopherjs/gopherjs/tests.cpuHog", "runtime_test.go", "cpu", "nanoseconds"} {
+		if !bytes.Contains(profile, []byte(want)) {
+			t.Errorf("Profile doesn't contain %q", want)
+		}
+	}
+}
+
+func blockedOnChannel(c chan int) {
+	<-c
+}
+
+func TestStack(t *testing.T) {
+	if minified() {
+		t.Skip("Go function names aren't known in minified code.")
+	}
+	c := make(chan int)
+	defer close(c)
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+	go blockedOnChannel(c)
+	go func() {
+		mu.Lock()
+		mu.Unlock()
+	}()
+	go time.Sleep(100 * time.Millisecond)
+	runtime.Gosched() // Let the goroutines block.
+
+	buf := make([]byte, 1<<20)
+	current := string(buf[:runtime.Stack(buf, false)])
+	all := string(buf[:runtime.Stack(buf, true)])
+	header := regexp.MustCompile(`^goroutine \d+ \[running\]:\ngithub.com/gopherjs/gopherjs/tests\.TestStack\(\.\.\.\)\n\t.+:\d+\n`)
+	if !header.MatchString(current) {
+		t.Errorf("runtime.Stack(buf, false) returned:\n%s\nwant the trace of the current goroutine", current)
+	}
+	if !header.MatchString(all) {
+		t.Errorf("runtime.Stack(buf, true) returned:\n%s\nwant the trace of the current goroutine first", all)
+	}
+	for _, want := range []string{
+		`goroutine \d+ \[chan receive\]:\ngithub.com/gopherjs/gopherjs/tests\.blockedOnChannel\(\.\.\.\)\n\t.+:\d+\ncreated by github.com/gopherjs/gopherjs/tests\.TestStack\n\t.+:\d+\n`,
+		`goroutine \d+ \[semacquire\]:\n(.+\n\t.+\n)*sync\.\(\*Mutex\)\.Lock\(\.\.\.\)\n`,
+		`goroutine \d+ \[sleep\]:\ntime\.Sleep\(\.\.\.\)\n`,
+	} {
+		if !regexp.MustCompile(want).MatchString(all) {
+			t.Errorf("runtime.Stack(buf, true) returned:\n%s\nwant a trace matching %q", all, want)
+		}
+		if regexp.MustCompile(want).MatchString(current) {
+			t.Errorf("runtime.Stack(buf, false) returned:\n%s\nwant no trace matching %q", current, want)
+		}
+	}
+}
+
+func TestGoroutineProfile(t *testing.T) {
+	if minified() {
+		t.Skip("Go function names aren't known in minified code.")
+	}
+	c := make(chan int)
+	defer close(c)
+	for i := 0; i < 3; i++ {
+		go blockedOnChannel(c)
+	}
+	runtime.Gosched() // Let the goroutines block.
+
+	p := pprof.Lookup("goroutine")
+	if p == nil {
+		t.Fatalf("pprof.Lookup(\"goroutine\") returned nil")
+	}
+	if got := p.Count(); got < 4 {
+		t.Errorf("Count() returned %d, want at least 4", got)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteTo(&buf, 1); err != nil {
+		t.Fatalf("WriteTo(w, 1) returned error: %v", err)
+	}
+	want := regexp.MustCompile(`(?m)^3 @ 0x[0-9a-f]+\n#\t0x[0-9a-f]+\tgithub.com/gopherjs/gopherjs/tests\.blockedOnChannel\+0x[0-9a-f]+\t.+:\d+\n`)
+	if !want.Match(buf.Bytes()) {
+		t.Errorf("WriteTo(w, 1) wrote:\n%s\nwant the 3 goroutines with the same stack", buf.String())
+	}
+
+	buf.Reset()
+	if err := p.WriteTo(&buf, 0); err != nil {
+		t.Fatalf("WriteTo(w, 0) returned error: %v", err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decompress the profile: %v", err)
+	}
+	profile, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress the profile: %v", err)
+	}
+	for _, want := range []string{"github.com/gopherjs/gopherjs/tests.blockedOnChannel", "goroutine", "count"} {
+		if !bytes.Contains(profile, []byte(want)) {
+			t.Errorf("Profile doesn't contain %q", want)
+		}
+	}
+}
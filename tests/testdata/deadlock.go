@@ -0,0 +1,20 @@
+package main
+
+type waiter struct{ c chan int }
+
+func (w *waiter) wait() int {
+	return <-w.c
+}
+
+func send(c chan int) {
+	c <- 1
+}
+
+func main() {
+	w := &waiter{c: make(chan int)}
+	go send(make(chan int))
+	go func() {
+		select {}
+	}()
+	println(w.wait())
+}
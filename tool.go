@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -13,12 +14,15 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,7 +33,9 @@ import (
 	gbuild "github.com/gopherjs/gopherjs/build"
 	"github.com/gopherjs/gopherjs/build/cache"
 	"github.com/gopherjs/gopherjs/compiler"
+	"github.com/gopherjs/gopherjs/internal/livereload"
 	"github.com/gopherjs/gopherjs/internal/sysutil"
+	"github.com/gopherjs/gopherjs/internal/test2json"
 	"github.com/gopherjs/gopherjs/internal/testmain"
 	"github.com/neelance/sourcemap"
 	log "github.com/sirupsen/logrus"
@@ -37,6 +43,7 @@ import (
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 var currentDirectory string
@@ -67,9 +74,12 @@ func init() {
 
 func main() {
 	var (
-		options = &gbuild.Options{CreateMapFile: true}
-		pkgObj  string
-		tags    string
+		options   = &gbuild.Options{CreateMapFile: true}
+		pkgObj    string
+		tags      string
+		format    string
+		buildMode string
+		dts       bool
 	)
 
 	flagVerbose := pflag.NewFlagSet("", 0)
@@ -83,10 +93,21 @@ func main() {
 	compilerFlags.StringVar(&tags, "tags", "", "a list of build tags to consider satisfied during the build")
 	compilerFlags.BoolVar(&options.MapToLocalDisk, "localmap", false, "use local paths for sourcemap")
 	compilerFlags.BoolVarP(&options.NoCache, "no_cache", "a", false, "rebuild all packages from scratch")
+	compilerFlags.BoolVarP(&options.AllErrors, "all_errors", "e", false, fmt.Sprintf("report all errors, rather than the first %d of each package", compiler.DefaultMaxErrors))
+	compilerFlags.BoolVar(&options.TrackAllocations, "track_allocs", false, "count heap allocations for runtime.ReadMemStats and testing.AllocsPerRun (enabled by default in gopherjs test)")
+
+	flagParallel := pflag.NewFlagSet("", 0)
+	flagParallel.IntVarP(&options.Parallelism, "p", "p", runtime.NumCPU(), "the number of packages that can be compiled in parallel")
 
 	flagWatch := pflag.NewFlagSet("", 0)
 	flagWatch.BoolVarP(&options.Watch, "watch", "w", false, "watch for changes to the source files")
 
+	flagJSON := pflag.NewFlagSet("", 0)
+	flagJSON.BoolVar(&options.JSON, "json", false, "print errors to stdout as JSON objects, one per line")
+
+	flagFormat := pflag.NewFlagSet("", 0)
+	flagFormat.StringVar(&format, "format", string(compiler.FormatIIFE), "output format of the generated program: \"iife\" (classic script or CommonJS module) or \"esm\" (ECMAScript module)")
+
 	cmdBuild := &cobra.Command{
 		Use:   "build [packages]",
 		Short: "compile packages and dependencies",
@@ -95,17 +116,29 @@ func main() {
 	cmdBuild.Flags().AddFlagSet(flagVerbose)
 	cmdBuild.Flags().AddFlagSet(flagQuiet)
 	cmdBuild.Flags().AddFlagSet(compilerFlags)
+	cmdBuild.Flags().AddFlagSet(flagParallel)
 	cmdBuild.Flags().AddFlagSet(flagWatch)
+	cmdBuild.Flags().AddFlagSet(flagFormat)
+	cmdBuild.Flags().AddFlagSet(flagJSON)
+	cmdBuild.Flags().StringVar(&buildMode, "buildmode", string(compiler.BuildModeExe), "build mode: \"exe\" builds a main package into a program, \"library\" builds a non-main package into a module exporting its API to JavaScript")
+	cmdBuild.Flags().BoolVar(&dts, "dts", true, "write TypeScript declarations for the exports of the program next to the output file")
 	cmdBuild.RunE = func(cmd *cobra.Command, args []string) error {
 		options.BuildTags = strings.Fields(tags)
+		options.CreateDeclarationFile = dts
+		var err error
+		if options.Format, err = compiler.ParseOutputFormat(format); err != nil {
+			return err
+		}
+		if options.BuildMode, err = compiler.ParseBuildMode(buildMode); err != nil {
+			return err
+		}
+		s, err := gbuild.NewSession(options)
+		if err != nil {
+			options.PrintError("%s\n", err)
+			return err
+		}
 		for {
-			s, err := gbuild.NewSession(options)
-			if err != nil {
-				options.PrintError("%s\n", err)
-				return err
-			}
-
-			err = func() error {
+			err := func() error {
 				// Handle "gopherjs build [files]" ad-hoc package mode.
 				if len(args) > 0 && (strings.HasSuffix(args[0], ".go") || strings.HasSuffix(args[0], ".inc.js")) {
 					for _, arg := range args {
@@ -156,7 +189,10 @@ func main() {
 						if pkgObj == "" {
 							pkgObj = filepath.Base(pkg.Dir) + ".js"
 						}
-						if pkg.IsCommand() && !pkg.UpToDate {
+						if options.BuildMode == compiler.BuildModeLibrary && pkg.IsCommand() {
+							return fmt.Errorf("-buildmode=library requires a non-main package, but %s is a command", pkg.ImportPath)
+						}
+						if (pkg.IsCommand() || options.BuildMode == compiler.BuildModeLibrary) && !pkg.UpToDate {
 							if err := s.WriteCommandPackage(archive, pkgObj); err != nil {
 								return err
 							}
@@ -182,16 +218,21 @@ func main() {
 	cmdInstall.Flags().AddFlagSet(flagVerbose)
 	cmdInstall.Flags().AddFlagSet(flagQuiet)
 	cmdInstall.Flags().AddFlagSet(compilerFlags)
+	cmdInstall.Flags().AddFlagSet(flagParallel)
 	cmdInstall.Flags().AddFlagSet(flagWatch)
+	cmdInstall.Flags().AddFlagSet(flagFormat)
 	cmdInstall.RunE = func(cmd *cobra.Command, args []string) error {
 		options.BuildTags = strings.Fields(tags)
+		var err error
+		if options.Format, err = compiler.ParseOutputFormat(format); err != nil {
+			return err
+		}
+		s, err := gbuild.NewSession(options)
+		if err != nil {
+			return err
+		}
 		for {
-			s, err := gbuild.NewSession(options)
-			if err != nil {
-				return err
-			}
-
-			err = func() error {
+			err := func() error {
 				// Expand import path patterns.
 				xctx := gbuild.NewBuildContext(s.InstallSuffix(), options.BuildTags)
 				pkgs, err := xctx.Match(args)
@@ -258,6 +299,7 @@ func main() {
 	cmdGet.Flags().AddFlagSet(flagVerbose)
 	cmdGet.Flags().AddFlagSet(flagQuiet)
 	cmdGet.Flags().AddFlagSet(compilerFlags)
+	cmdGet.Flags().AddFlagSet(flagParallel)
 	cmdGet.Run = cmdInstall.Run
 
 	cmdRun := &cobra.Command{
@@ -267,6 +309,7 @@ func main() {
 	cmdRun.Flags().AddFlagSet(flagVerbose)
 	cmdRun.Flags().AddFlagSet(flagQuiet)
 	cmdRun.Flags().AddFlagSet(compilerFlags)
+	cmdRun.Flags().AddFlagSet(flagParallel)
 	cmdRun.RunE = func(cmd *cobra.Command, args []string) error {
 		options.BuildTags = strings.Fields(tags)
 		lastSourceArg := 0
@@ -299,7 +342,7 @@ func main() {
 		if err := s.BuildFiles(args[:lastSourceArg], tempfile.Name(), currentDirectory); err != nil {
 			return err
 		}
-		if err := runNode(tempfile.Name(), args[lastSourceArg:], "", options.Quiet, nil); err != nil {
+		if err := runNode(tempfile.Name(), args[lastSourceArg:], "", options.Quiet, nil, 0); err != nil {
 			return err
 		}
 		return nil
@@ -317,8 +360,25 @@ func main() {
 	verbose := cmdTest.Flags().BoolP("verbose", "v", false, "Log all tests as they are run. Also print all text from Log and Logf calls even if the test succeeds.")
 	compileOnly := cmdTest.Flags().BoolP("compileonly", "c", false, "Compile the test binary to pkg.test.js but do not run it (where pkg is the last element of the package's import path). The file name can be changed with the -o flag.")
 	outputFilename := cmdTest.Flags().StringP("output", "o", "", "Compile the test binary to the named file. The test still runs (unless -c is specified).")
-	parallelTests := cmdTest.Flags().IntP("parallel", "p", runtime.NumCPU(), "Allow running tests in parallel for up to -p packages. Tests within the same package are still executed sequentially.")
+	cmdTest.Flags().IntVarP(&options.Parallelism, "p", "p", runtime.NumCPU(), "Allow building and running tests in parallel for up to -p packages.")
+	parallel := cmdTest.Flags().String("parallel", "", "Allow parallel execution of test functions that call t.Parallel within a package, up to the given number at a time.")
+	timeout := cmdTest.Flags().Duration("timeout", 10*time.Minute, "Panic with a dump of all goroutines if a test binary runs longer than the duration, or kill it if it doesn't respond. 0 disables the timeout.")
+	failfast := cmdTest.Flags().Bool("failfast", false, "Do not start new tests after the first test failure.")
+	shuffle := cmdTest.Flags().String("shuffle", "", "Randomize the execution order of tests and benchmarks: off, on, or the seed to use.")
+	list := cmdTest.Flags().String("list", "", "List tests, benchmarks, fuzz tests, or examples matching the regular expression instead of running them.")
+	skip := cmdTest.Flags().String("skip", "", "Run only those tests and examples not matching the regular expression.")
+	benchmem := cmdTest.Flags().Bool("benchmem", false, "Print memory allocation statistics for benchmarks.")
+	cpu := cmdTest.Flags().String("cpu", "", "Specify a list of GOMAXPROCS values for which the tests or benchmarks should be executed.")
+	fuzz := cmdTest.Flags().String("fuzz", "", "Run the fuzz test matching the regular expression, mutating its seed corpus at random, without coverage guidance. The tests of the package still run first.")
+	fuzztime := cmdTest.Flags().String("fuzztime", "", "Run enough iterations of the fuzz target during fuzzing to take t, specified as a time.Duration (for example, -fuzztime 1h30s), or run it N times with Nx. The default is to run forever.")
+	cmdTest.Flags().Bool("args", false, "Pass the remainder of the command line, everything after -args, to the test binaries uninterpreted and unchanged.")
+	cover := cmdTest.Flags().Bool("cover", false, "Enable coverage analysis of the tested packages.")
+	coverMode := cmdTest.Flags().String("covermode", "", "Set the mode for coverage analysis of the tested packages: set, count or atomic. The default is set. Implies --cover.")
+	coverProfile := cmdTest.Flags().String("coverprofile", "", "Write a coverage profile of the tested packages to the file. Implies --cover.")
 	cmdTest.Flags().AddFlagSet(compilerFlags)
+	// Unlike with the other commands, errors are printed as text, like "go test -json" does.
+	jsonEvents := cmdTest.Flags().Bool("json", false, "Convert test output to JSON events, as printed by 'go test -json'.")
+	var binaryArgs []string // Arguments following -args, see goTestArgs.
 	cmdTest.RunE = func(cmd *cobra.Command, args []string) error {
 		options.BuildTags = strings.Fields(tags)
 
@@ -335,11 +395,42 @@ func main() {
 		if *outputFilename != "" && len(matches) > 1 {
 			return errors.New("cannot use -o flag with multiple packages")
 		}
-		if *parallelTests < 1 {
-			return errors.New("--parallel cannot be less than 1")
+		if *fuzz != "" && len(matches) > 1 {
+			return errors.New("cannot use -fuzz flag with multiple packages")
+		}
+		if *fuzz != "" && !cmd.Flags().Changed("timeout") {
+			*timeout = 0 // Like "go test", don't limit fuzzing by default.
+		}
+		if !cmd.Flags().Changed("track_allocs") {
+			options.TrackAllocations = true // Needed for testing.AllocsPerRun.
+		}
+		if options.Parallelism < 1 {
+			return errors.New("-p cannot be less than 1")
 		}
 
-		parallelSlots := make(chan (bool), *parallelTests) // Semaphore for parallel test executions.
+		if *cover || *coverMode != "" || *coverProfile != "" {
+			options.CoverMode = *coverMode
+			if options.CoverMode == "" {
+				options.CoverMode = "set"
+			}
+			valid := false
+			for _, mode := range gbuild.CoverModes {
+				valid = valid || mode == options.CoverMode
+			}
+			if !valid {
+				return fmt.Errorf("invalid --covermode %q: must be one of %s", options.CoverMode, strings.Join(gbuild.CoverModes, ", "))
+			}
+		}
+		var profile *mergedCoverProfile
+		if *coverProfile != "" {
+			profile, err = newMergedCoverProfile(*coverProfile, options.CoverMode)
+			if err != nil {
+				return err
+			}
+			defer profile.Close()
+		}
+
+		parallelSlots := make(chan (bool), options.Parallelism) // Semaphore for parallel test executions.
 		if len(matches) == 1 {
 			// Disable output buffering if testing only one package.
 			parallelSlots = make(chan (bool), 1)
@@ -362,7 +453,13 @@ func main() {
 		for _, pkg := range pkgs {
 			pkg := pkg // Capture for the goroutine.
 			if len(pkg.TestGoFiles) == 0 && len(pkg.XTestGoFiles) == 0 {
-				fmt.Printf("?   \t%s\t[no test files]\n", pkg.ImportPath)
+				if *jsonEvents {
+					conv := test2json.NewConverter(os.Stdout, pkg.ImportPath)
+					fmt.Fprintf(conv, "?   \t%s\t[no test files]\n", pkg.ImportPath)
+					conv.Close()
+				} else {
+					fmt.Printf("?   \t%s\t[no test files]\n", pkg.ImportPath)
+				}
 				continue
 			}
 			localOpts := options
@@ -372,18 +469,45 @@ func main() {
 				return err
 			}
 
+			// With --json, like "go test -json", report the build errors of the
+			// package and its failure, and move on to the other packages.
+			buildFailed := func(err error) error {
+				if !*jsonEvents {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "# %s\n", pkg.ImportPath)
+				handleError(err, options, nil)
+				conv := test2json.NewConverter(os.Stdout, pkg.ImportPath)
+				fmt.Fprintf(conv, "FAIL\t%s [build failed]\n", pkg.ImportPath)
+				conv.Close()
+				exitErrMu.Lock()
+				if exitErr == nil {
+					exitErr = errTestBuildFailed
+				}
+				exitErrMu.Unlock()
+				return nil
+			}
 			_, err = s.BuildPackage(pkg.TestPackage())
 			if err != nil {
-				return err
+				if err := buildFailed(err); err != nil {
+					return err
+				}
+				continue
 			}
 			_, err = s.BuildPackage(pkg.XTestPackage())
 			if err != nil {
-				return err
+				if err := buildFailed(err); err != nil {
+					return err
+				}
+				continue
 			}
 
 			fset := token.NewFileSet()
 			tests := testmain.TestMain{Package: pkg}
 			tests.Scan(fset)
+			if options.CoverMode != "" {
+				tests.Cover = &testmain.Cover{Mode: options.CoverMode, Vars: pkg.CoverVars()}
+			}
 			mainPkg, mainFile, err := tests.Synthesize(fset)
 			if err != nil {
 				return fmt.Errorf("failed to generate testmain package for %s: %w", pkg.ImportPath, err)
@@ -392,9 +516,12 @@ func main() {
 				Packages: s.Types,
 				Import:   s.ImportResolverFor(mainPkg),
 			}
-			mainPkgArchive, err := compiler.Compile(mainPkg.ImportPath, []*ast.File{mainFile}, fset, importContext, options.Minify)
+			mainPkgArchive, err := compiler.Compile(mainPkg.ImportPath, []*ast.File{mainFile}, fset, importContext, compiler.CompileOptions{Minify: options.Minify, TrackAllocations: options.TrackAllocations})
 			if err != nil {
-				return fmt.Errorf("failed to compile testmain package for %s: %w", pkg.ImportPath, err)
+				if err := buildFailed(fmt.Errorf("failed to compile testmain package for %s: %w", pkg.ImportPath, err)); err != nil {
+					return err
+				}
+				continue
 			}
 
 			if *compileOnly && *outputFilename == "" {
@@ -446,28 +573,123 @@ func main() {
 			if *short {
 				args = append(args, "-test.short")
 			}
-			if *verbose {
+			if *verbose || *jsonEvents {
 				args = append(args, "-test.v")
 			}
+			if *parallel != "" {
+				args = append(args, "-test.parallel", *parallel)
+			}
+			if *timeout != 0 {
+				args = append(args, "-test.timeout", timeout.String())
+			}
+			if *failfast {
+				args = append(args, "-test.failfast")
+			}
+			if *shuffle != "" {
+				args = append(args, "-test.shuffle", *shuffle)
+			}
+			if *list != "" {
+				args = append(args, "-test.list", *list)
+			}
+			if *skip != "" {
+				args = append(args, "-test.skip", *skip)
+			}
+			if *benchmem {
+				args = append(args, "-test.benchmem")
+			}
+			if *cpu != "" {
+				args = append(args, "-test.cpu", *cpu)
+			}
+			if *fuzz != "" {
+				args = append(args, "-test.fuzz", *fuzz)
+			}
+			if *fuzztime != "" {
+				args = append(args, "-test.fuzztime", *fuzztime)
+			}
+			pkgProfile := outfile.Name() + ".coverprofile"
+			if profile != nil {
+				args = append(args, "-test.coverprofile", pkgProfile)
+			}
+			// Like "go test", only cache the results of runs with flags which don't
+			// affect them beyond the output, so --count=1 disables the cache.
+			var testKey string
+			testLog := outfile.Name() + ".testlog"
+			if *count == "" && *bench == "" && !*benchmem && *shuffle == "" && *fuzz == "" && profile == nil && len(binaryArgs) == 0 {
+				binary, err := os.ReadFile(outfile.Name())
+				if err != nil {
+					return err
+				}
+				// The source map URL contains the name of the temporary file.
+				binary = bytes.TrimSuffix(binary, []byte("//# sourceMappingURL="+filepath.Base(outfile.Name())+".map\n"))
+				testKey = cache.TestKey(binary, args, runTestDir(pkg))
+				args = append(args, "-test.testlogfile", testLog)
+			}
+			args = append(args, binaryArgs...)
 			executions.Go(func() error {
 				parallelSlots <- true              // Acquire slot
 				defer func() { <-parallelSlots }() // Release slot
 
 				status := "ok  "
 				start := time.Now()
-				var testOut io.ReadWriter
+				var testOut io.Writer
+				var buffered *bytes.Buffer
 				if cap(parallelSlots) > 1 {
 					// If running in parallel, capture test output in a temporary buffer to avoid mixing
 					// output from different tests and print it later.
-					testOut = &bytes.Buffer{}
+					buffered = &bytes.Buffer{}
+					testOut = buffered
+				}
+				var conv *test2json.Converter
+				if *jsonEvents {
+					// The events of each package are still printed together when running in parallel.
+					if testOut == nil {
+						testOut = os.Stdout
+					}
+					conv = test2json.NewConverter(testOut, pkg.ImportPath)
+					testOut = conv
 				}
 
-				err := runNode(outfile.Name(), args, runTestDir(pkg), options.Quiet, testOut)
+				var (
+					err    error
+					output []byte
+					cached bool
+					record *bytes.Buffer
+				)
+				if testKey != "" {
+					output, cached = cache.LoadTestResult(testKey)
+				}
+				if cached {
+					if testOut == nil {
+						testOut = os.Stdout
+					}
+					testOut.Write(output)
+				} else {
+					runOut := testOut
+					if testKey != "" {
+						// Record the output to cache it if the tests pass.
+						record = &bytes.Buffer{}
+						if runOut == nil {
+							runOut = os.Stdout
+						}
+						runOut = io.MultiWriter(runOut, record)
+					}
+					err = runNode(outfile.Name(), args, runTestDir(pkg), options.Quiet, runOut, testKillTimeout(*timeout))
+				}
 
 				cleanupTemp() // Eagerly cleanup temporary compiled files after execution.
+				if record != nil {
+					if err == nil {
+						if data, err := os.ReadFile(testLog); err == nil {
+							cache.StoreTestResult(testKey, runTestDir(pkg), record.Bytes(), data)
+						}
+					}
+					os.Remove(testLog)
+				}
 
-				if testOut != nil {
-					io.Copy(os.Stdout, testOut)
+				if profile != nil {
+					if err := profile.merge(pkgProfile); err != nil {
+						return fmt.Errorf("failed to merge coverage profile of %s: %w", pkg.ImportPath, err)
+					}
 				}
 
 				if err != nil {
@@ -479,7 +701,21 @@ func main() {
 					exitErrMu.Unlock()
 					status = "FAIL"
 				}
-				fmt.Printf("%s\t%s\t%.3fs\n", status, pkg.ImportPath, time.Since(start).Seconds())
+				elapsed := fmt.Sprintf("%.3fs", time.Since(start).Seconds())
+				if cached {
+					elapsed = "(cached)"
+				}
+				summary := fmt.Sprintf("%s\t%s\t%s\n", status, pkg.ImportPath, elapsed)
+				if conv != nil {
+					io.WriteString(conv, summary)
+					conv.Close()
+				}
+				if buffered != nil {
+					io.Copy(os.Stdout, buffered)
+				}
+				if conv == nil {
+					fmt.Print(summary)
+				}
 				return nil
 			})
 		}
@@ -497,8 +733,16 @@ func main() {
 	cmdServe.Flags().AddFlagSet(flagVerbose)
 	cmdServe.Flags().AddFlagSet(flagQuiet)
 	cmdServe.Flags().AddFlagSet(compilerFlags)
+	cmdServe.Flags().AddFlagSet(flagParallel)
+	cmdServe.Flags().AddFlagSet(flagJSON)
 	var addr string
 	cmdServe.Flags().StringVarP(&addr, "http", "", ":8080", "HTTP bind address to serve")
+	var live bool
+	cmdServe.Flags().BoolVar(&live, "live", false, "reload pages when their sources change and display compile errors on top of them")
+	var proxyFlags []string
+	cmdServe.Flags().StringArrayVar(&proxyFlags, "proxy", nil, "forward requests with a path prefix to a backend server, e.g. /api=http://localhost:9000 (can be repeated)")
+	var spa bool
+	cmdServe.Flags().BoolVar(&spa, "spa", false, "serve the nearest index.html for pages which don't exist, for client-side routing")
 	cmdServe.RunE = func(cmd *cobra.Command, args []string) error {
 		options.BuildTags = strings.Fields(tags)
 		var root string
@@ -507,17 +751,32 @@ func main() {
 			root = args[0]
 		}
 
-		// Create a new session eagerly to check if it fails, and report the error right away.
+		var proxies []*serveProxy
+		for _, flag := range proxyFlags {
+			p, err := parseServeProxy(flag)
+			if err != nil {
+				return err
+			}
+			proxies = append(proxies, p)
+		}
+
+		// Create the session eagerly to check if it fails, and report the error right away.
 		// Otherwise users will see it only after trying to serve a package, which is a bad experience.
-		_, err := gbuild.NewSession(options)
+		session, err := gbuild.NewSession(options)
 		if err != nil {
 			return err
 		}
-		sourceFiles := http.FileServer(serveCommandFileSystem{
+		fs := &serveCommandFileSystem{
 			serveRoot:  root,
 			options:    options,
+			session:    session,
 			sourceMaps: make(map[string][]byte),
-		})
+			dirStates:  make(map[string]dirState),
+			live:       live,
+		}
+		fs.watcher = livereload.New(fs.invalidate)
+		defer fs.watcher.Close()
+		handler := newServeHandler(fs, proxies, spa)
 
 		ln, err := net.Listen("tcp", addr)
 		if err != nil {
@@ -528,7 +787,7 @@ func main() {
 		} else { // Specific address.
 			fmt.Printf("serving at http://%s\n", tcpAddr)
 		}
-		fmt.Fprintln(os.Stderr, http.Serve(tcpKeepAliveListener{ln.(*net.TCPListener)}, sourceFiles))
+		fmt.Fprintln(os.Stderr, http.Serve(tcpKeepAliveListener{ln.(*net.TCPListener)}, handler))
 		return nil
 	}
 
@@ -545,8 +804,16 @@ func main() {
 		Use:   "clean",
 		Short: "clean GopherJS build cache",
 	}
+	olderThan := cmdClean.Flags().Duration("older-than", 0, "only remove build cache artifacts that haven't been used for the given duration, e.g. 72h")
 	cmdClean.RunE = func(cmd *cobra.Command, args []string) error {
-		return cache.Clear()
+		switch {
+		case *olderThan < 0:
+			return fmt.Errorf("invalid --older-than duration %s", *olderThan)
+		case *olderThan > 0:
+			return cache.RemoveOlderThan(*olderThan)
+		default:
+			return cache.Clear()
+		}
 	}
 
 	rootCmd := &cobra.Command{
@@ -603,7 +870,17 @@ func main() {
 			return nil
 		}
 	}
+	if cmd, _, err := rootCmd.Find(os.Args[1:]); err == nil && cmd == cmdTest {
+		flags := pflag.NewFlagSet("", 0)
+		flags.AddFlagSet(cmdTest.Flags())
+		flags.AddFlagSet(rootCmd.PersistentFlags())
+		var cmdArgs []string
+		cmdArgs, binaryArgs = goTestArgs(os.Args[1:], flags)
+		rootCmd.SetArgs(cmdArgs)
+	}
 	err := rootCmd.Execute()
+	// Let the build cache finish storing the compiled packages remotely.
+	cache.WaitForUploads()
 	if err != nil {
 		os.Exit(handleError(err, options, nil))
 	}
@@ -627,13 +904,303 @@ func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
 	return tc, nil
 }
 
+// mergedCoverProfile merges the coverage profiles of the tested packages into
+// a single file, like the one written by "go test -coverprofile".
+type mergedCoverProfile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newMergedCoverProfile(name, mode string) (*mergedCoverProfile, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "mode: %s\n", mode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &mergedCoverProfile{file: f}, nil
+}
+
+// merge appends the blocks from the package's profile, and removes it.
+func (p *mergedCoverProfile) merge(name string) error {
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil // The test binary exited before writing the profile.
+	} else if err != nil {
+		return err
+	}
+	os.Remove(name)
+	if bytes.HasPrefix(data, []byte("mode: ")) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.file.Write(data)
+	return err
+}
+
+func (p *mergedCoverProfile) Close() error {
+	return p.file.Close()
+}
+
+// serveHandler routes requests of the serve command to the backends, the live
+// reload server and the served files.
+type serveHandler struct {
+	fs      *serveCommandFileSystem
+	files   http.Handler
+	proxies []*serveProxy // Longest prefixes first.
+	spa     bool
+}
+
+func newServeHandler(fs *serveCommandFileSystem, proxies []*serveProxy, spa bool) *serveHandler {
+	proxies = append([]*serveProxy{}, proxies...)
+	sort.SliceStable(proxies, func(i, j int) bool { return len(proxies[i].prefix) > len(proxies[j].prefix) })
+	return &serveHandler{fs: fs, files: http.FileServer(fs), proxies: proxies, spa: spa}
+}
+
+func (h *serveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.fs.live && (r.URL.Path == livereload.EventsPath || r.URL.Path == livereload.ClientPath) {
+		h.fs.watcher.ServeHTTP(w, r)
+		return
+	}
+	// The compiled programs take precedence over the backends, so that they can
+	// be served even if a backend handles all paths, e.g. with --proxy /=URL.
+	if h.fs.isProgramOutput(r.URL.Path) {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+	for _, p := range h.proxies {
+		if p.matches(r.URL.Path) {
+			p.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	if h.spa && isPageRequest(r) {
+		if f, err := h.fs.Open(r.URL.Path); err == nil {
+			f.Close()
+		} else if os.IsNotExist(err) && h.serveFallbackPage(w, r) {
+			return
+		}
+	}
+	h.files.ServeHTTP(w, r)
+}
+
+// serveFallbackPage serves the index.html nearest to the requested path, which
+// lets the page handle the path by client-side routing. Returns false if there
+// is no such page.
+func (h *serveHandler) serveFallbackPage(w http.ResponseWriter, r *http.Request) bool {
+	for dir := path.Dir(path.Clean(r.URL.Path)); ; dir = path.Dir(dir) {
+		if f, err := h.fs.Open(path.Join(dir, "index.html")); err == nil {
+			defer f.Close()
+			http.ServeContent(w, r, "index.html", time.Time{}, f)
+			return true
+		}
+		if dir == "/" {
+			return false
+		}
+	}
+}
+
+// isPageRequest returns whether the request is likely to be a navigation to a
+// page, rather than a request for a resource used by the page.
+func isPageRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if strings.Contains(path.Base(r.URL.Path), ".") {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
+
+// serveProxy forwards requests with the path prefix to a backend server.
+type serveProxy struct {
+	prefix  string
+	handler http.Handler
+}
+
+// parseServeProxy parses a --proxy flag value of the form /prefix=URL.
+func parseServeProxy(flag string) (*serveProxy, error) {
+	prefix, target, ok := strings.Cut(flag, "=")
+	if !ok || !strings.HasPrefix(prefix, "/") {
+		return nil, fmt.Errorf("invalid --proxy %q: want /prefix=http://host:port", flag)
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid --proxy %q: %q is not an http or https URL", flag, target)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		// Backends often route by the host name, which must be their own.
+		r.Header.Set("X-Forwarded-Host", r.Host)
+		r.Host = u.Host
+	}
+	return &serveProxy{prefix: strings.TrimSuffix(prefix, "/"), handler: proxy}, nil
+}
+
+// matches returns whether the request path is forwarded by the proxy.
+func (p *serveProxy) matches(urlPath string) bool {
+	return urlPath == p.prefix || strings.HasPrefix(urlPath, p.prefix+"/")
+}
+
 type serveCommandFileSystem struct {
-	serveRoot  string
-	options    *gbuild.Options
-	sourceMaps map[string][]byte
+	serveRoot string
+	options   *gbuild.Options
+
+	// A single session is used for all requests, so that only the packages
+	// affected by changes to the sources are rebuilt. Requests use the session
+	// concurrently with the read lock held, while the changed files are
+	// invalidated with the write lock held.
+	session   *gbuild.Session
+	sessionMu sync.RWMutex
+	// Coalesces concurrent requests for the same program into a single build.
+	compiles singleflight.Group
+
+	sourceMapsMu sync.Mutex
+	sourceMaps   map[string][]byte
+
+	// Source directories of the served programs, mapped to the state of their
+	// files when they were last checked for changes. Changes are normally
+	// noticed by the watcher, but it may fail to watch some of the directories,
+	// or not have noticed a change yet when the program is requested again, so
+	// the directories are also checked on every request, see checkChanges.
+	dirStatesMu sync.Mutex
+	dirStates   map[string]dirState
+
+	watcher *livereload.Server
+	live    bool // Whether pages are reloaded when their sources change.
+}
+
+// invalidate marks the packages affected by the changed files out of date.
+func (fs *serveCommandFileSystem) invalidate(files []string) {
+	// The changes are accounted for, so checkChanges doesn't report them again.
+	fs.dirStatesMu.Lock()
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if _, ok := fs.dirStates[dir]; ok {
+			fs.dirStates[dir] = readDirState(dir)
+		}
+	}
+	fs.dirStatesMu.Unlock()
+
+	fs.sessionMu.Lock()
+	defer fs.sessionMu.Unlock()
+	fs.session.Invalidate(files)
+}
+
+// checkChanges invalidates the packages affected by the changes to the source
+// directories since they were last checked.
+func (fs *serveCommandFileSystem) checkChanges() {
+	var changed []string
+	fs.dirStatesMu.Lock()
+	for dir, old := range fs.dirStates {
+		state := readDirState(dir)
+		changed = append(changed, old.changedFiles(state)...)
+		fs.dirStates[dir] = state
+	}
+	fs.dirStatesMu.Unlock()
+	if len(changed) == 0 {
+		return
+	}
+	log.Infof("Changes detected: %s", strings.Join(changed, ", "))
+	fs.sessionMu.Lock()
+	defer fs.sessionMu.Unlock()
+	fs.session.Invalidate(changed)
+}
+
+// coarseModTimes is the precision of file modification times on the file
+// systems with the least precise ones.
+const coarseModTimes = 2 * time.Second
+
+// watchSources starts watching the source directories of the program built
+// since the given time, except for the standard library in GOROOT, which isn't
+// expected to change. Files modified since the build started may have been
+// missed by the build, so they are reported by the next checkChanges.
+func (fs *serveCommandFileSystem) watchSources(pkg *gbuild.PackageData, since time.Time) {
+	s := fs.session
+	goroot := s.XContext().Env().GOROOT
+	var dirs []string
+	for _, dir := range append(s.SourceDirs(), pkg.Dir) {
+		if rel, err := filepath.Rel(goroot, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	fs.watcher.Watch(dirs...)
+
+	fs.dirStatesMu.Lock()
+	defer fs.dirStatesMu.Unlock()
+	for _, dir := range dirs {
+		if _, ok := fs.dirStates[dir]; ok {
+			continue
+		}
+		state := readDirState(dir)
+		for file, modTime := range state {
+			if modTime.After(since.Add(-coarseModTimes)) {
+				state[file] = time.Time{}
+			}
+		}
+		fs.dirStates[dir] = state
+	}
+}
+
+// dirState maps names of the files in a directory to their modification times.
+type dirState map[string]time.Time
+
+// readDirState returns the current state of the directory, which is empty if
+// the directory can't be read.
+func readDirState(dir string) dirState {
+	state := dirState{}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			state[filepath.Join(dir, entry.Name())] = info.ModTime()
+		}
+	}
+	return state
 }
 
-func (fs serveCommandFileSystem) Open(requestName string) (http.File, error) {
+// changedFiles returns the files created, modified or removed since the old
+// state.
+func (old dirState) changedFiles(state dirState) []string {
+	var changed []string
+	for file, modTime := range state {
+		if oldModTime, ok := old[file]; !ok || !oldModTime.Equal(modTime) {
+			changed = append(changed, file)
+		}
+	}
+	for file := range old {
+		if _, ok := state[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	return changed
+}
+
+// isProgramOutput returns whether the request path is for a compiled program or
+// its source map, which are named after the directory of a main package.
+func (fs *serveCommandFileSystem) isProgramOutput(requestName string) bool {
+	if !strings.HasPrefix(requestName, "/") {
+		return false
+	}
+	name := path.Join(fs.serveRoot, requestName[1:]) // requestName[0] == '/'
+	dir, file := path.Split(name)
+	base := path.Base(dir)
+	if file != base+".js" && file != base+".js.map" {
+		return false
+	}
+	pkg, err := gbuild.Import(path.Dir(name), 0, fs.session.InstallSuffix(), fs.options.BuildTags)
+	return err == nil && pkg.Name == "main"
+}
+
+func (fs *serveCommandFileSystem) Open(requestName string) (http.File, error) {
 	name := path.Join(fs.serveRoot, requestName[1:]) // requestName[0] == '/'
 	log.Printf("Request: %s", name)
 
@@ -644,13 +1211,7 @@ func (fs serveCommandFileSystem) Open(requestName string) (http.File, error) {
 	isMap := file == base+".js.map"
 	isIndex := file == "index.html"
 
-	// Create a new session to pick up changes to source code on disk.
-	// TODO(dmitshur): might be possible to get a single session to detect changes to source code on disk
-	s, err := gbuild.NewSession(fs.options)
-	if err != nil {
-		return nil, err
-	}
-
+	s := fs.session
 	if isPkg || isMap || isIndex {
 		// If we're going to be serving our special files, make sure there's a Go command in this folder.
 		pkg, err := gbuild.Import(path.Dir(name), 0, s.InstallSuffix(), fs.options.BuildTags)
@@ -662,65 +1223,104 @@ func (fs serveCommandFileSystem) Open(requestName string) (http.File, error) {
 
 		switch {
 		case isPkg:
-			buf := new(bytes.Buffer)
-			browserErrors := new(bytes.Buffer)
-			err := func() error {
-				archive, err := s.BuildPackage(pkg)
-				if err != nil {
-					return err
-				}
-
-				sourceMapFilter := &compiler.SourceMapFilter{Writer: buf}
-				m := &sourcemap.Map{File: base + ".js"}
-				sourceMapFilter.MappingCallback = s.SourceMappingCallback(m)
-
-				deps, err := compiler.ImportDependencies(archive, s.BuildImportPath)
-				if err != nil {
-					return err
-				}
-				if err := compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease()); err != nil {
-					return err
-				}
-
-				mapBuf := new(bytes.Buffer)
-				m.WriteTo(mapBuf)
-				buf.WriteString("//# sourceMappingURL=" + base + ".js.map\n")
-				fs.sourceMaps[name+".map"] = mapBuf.Bytes()
-
-				return nil
-			}()
-			handleError(err, fs.options, browserErrors)
-			if err != nil {
-				buf = browserErrors
-			}
-			return newFakeFile(base+".js", buf.Bytes()), nil
+			fs.checkChanges()
+			code, _, _ := fs.compiles.Do(name, func() (interface{}, error) {
+				return fs.compile(pkg, name, base), nil
+			})
+			return newFakeFile(base+".js", code.([]byte)), nil
 
 		case isMap:
-			if content, ok := fs.sourceMaps[name]; ok {
+			fs.sourceMapsMu.Lock()
+			content, ok := fs.sourceMaps[name]
+			fs.sourceMapsMu.Unlock()
+			if ok {
 				return newFakeFile(base+".js.map", content), nil
 			}
 		}
 	}
 
 	// First try to serve the request with a root prefix supplied in the CLI.
-	if f, err := fs.serveSourceTree(s.XContext(), name); err == nil {
-		return f, nil
+	f, err := fs.serveSourceTree(s.XContext(), name)
+	if err != nil {
+		// If that didn't work, try without the prefix.
+		f, err = fs.serveSourceTree(s.XContext(), requestName)
 	}
-
-	// If that didn't work, try without the prefix.
-	if f, err := fs.serveSourceTree(s.XContext(), requestName); err == nil {
+	if err == nil {
+		if fs.live && file == "index.html" {
+			defer f.Close()
+			content, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			return newFakeFile("index.html", livereload.InjectClient(content)), nil
+		}
 		return f, nil
 	}
 
 	if isIndex {
-		// If there was no index.html file in any dirs, supply our own.
-		return newFakeFile("index.html", []byte(`<html><head><meta charset="utf-8"><script src="`+base+`.js"></script></head><body></body></html>`)), nil
+		// If there was no index.html file in any dirs, supply our own. The script
+		// path is absolute, so that the page works when served for other paths
+		// with --spa.
+		src := path.Join(path.Dir(requestName), base+".js")
+		index := []byte(`<html><head><meta charset="utf-8"><script src="` + src + `"></script></head><body></body></html>`)
+		if fs.live {
+			index = livereload.InjectClient(index)
+		}
+		return newFakeFile("index.html", index), nil
 	}
 
 	return nil, os.ErrNotExist
 }
 
-func (fs serveCommandFileSystem) serveSourceTree(xctx gbuild.XContext, reqPath string) (http.File, error) {
+// compile builds the main package and returns the program code to serve, or a
+// script reporting the build errors.
+func (fs *serveCommandFileSystem) compile(pkg *gbuild.PackageData, name, base string) []byte {
+	fs.sessionMu.RLock()
+	defer fs.sessionMu.RUnlock()
+	s := fs.session
+	// Watch the sources even if the build fails, so that the fix is picked up.
+	defer fs.watchSources(pkg, time.Now())
+
+	buf := new(bytes.Buffer)
+	err := func() error {
+		archive, err := s.BuildPackage(pkg)
+		if err != nil {
+			return err
+		}
+
+		sourceMapFilter := &compiler.SourceMapFilter{Writer: buf}
+		m := &sourcemap.Map{File: base + ".js"}
+		sourceMapFilter.MappingCallback = s.SourceMappingCallback(m)
+
+		deps, err := compiler.ImportDependencies(archive, s.BuildImportPath)
+		if err != nil {
+			return err
+		}
+		if err := compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease(), compiler.FormatIIFE, compiler.BuildModeExe); err != nil {
+			return err
+		}
+
+		mapBuf := new(bytes.Buffer)
+		m.WriteTo(mapBuf)
+		buf.WriteString("//# sourceMappingURL=" + base + ".js.map\n")
+		fs.sourceMapsMu.Lock()
+		fs.sourceMaps[name+".map"] = mapBuf.Bytes()
+		fs.sourceMapsMu.Unlock()
+
+		return nil
+	}()
+	if err != nil {
+		browserErrors := new(bytes.Buffer)
+		handleError(err, fs.options, browserErrors)
+		if fs.live {
+			browserErrors.Write(livereload.ErrorOverlay(errorMessages(err)))
+		}
+		return browserErrors.Bytes()
+	}
+	return buf.Bytes()
+}
+
+func (fs *serveCommandFileSystem) serveSourceTree(xctx gbuild.XContext, reqPath string) (http.File, error) {
 	parts := strings.Split(path.Clean(reqPath), "/")
 	// Under Go Modules different packages can be located in different module
 	// directories, which no longer align with import paths.
@@ -732,7 +1332,11 @@ func (fs serveCommandFileSystem) serveSourceTree(xctx gbuild.XContext, reqPath s
 		pkgPath := path.Clean(path.Join(parts[:i]...))
 		filePath := path.Clean(path.Join(parts[i:]...))
 		if pkg, err := xctx.Import(pkgPath, ".", build.FindOnly); err == nil {
-			return http.Dir(pkg.Dir).Open(filePath)
+			f, err := http.Dir(pkg.Dir).Open(filePath)
+			if err == nil && fs.live {
+				fs.watcher.Watch(filepath.Dir(filepath.Join(pkg.Dir, filepath.FromSlash(filePath))))
+			}
+			return f, err
 		}
 	}
 	return nil, os.ErrNotExist
@@ -784,9 +1388,16 @@ func (f *fakeFile) Sys() interface{} {
 	return nil
 }
 
+// errTestBuildFailed fails gopherjs test --json after the build errors were
+// reported along with the test events.
+var errTestBuildFailed = errors.New("build failed")
+
 // handleError handles err and returns an appropriate exit code.
 // If browserErrors is non-nil, errors are written for presentation in browser.
 func handleError(err error, options *gbuild.Options, browserErrors *bytes.Buffer) int {
+	if err == errTestBuildFailed {
+		return 1
+	}
 	switch err := err.(type) {
 	case nil:
 		return 0
@@ -803,10 +1414,27 @@ func handleError(err error, options *gbuild.Options, browserErrors *bytes.Buffer
 	}
 }
 
-// printError prints err to Stderr with options. If browserErrors is non-nil, errors are also written for presentation in browser.
+// errorMessages returns the messages of the errors err consists of.
+func errorMessages(err error) []string {
+	if list, ok := err.(compiler.ErrorList); ok {
+		msgs := make([]string, len(list))
+		for i, entry := range list {
+			msgs[i] = sprintError(entry)
+		}
+		return msgs
+	}
+	return []string{sprintError(err)}
+}
+
+// printError prints err to Stderr with options, or to Stdout as JSON if requested.
+// If browserErrors is non-nil, errors are also written for presentation in browser.
 func printError(err error, options *gbuild.Options, browserErrors *bytes.Buffer) {
 	e := sprintError(err)
-	options.PrintError("%s\n", e)
+	if options.JSON {
+		json.NewEncoder(os.Stdout).Encode(newJSONDiagnostic(err))
+	} else {
+		options.PrintError("%s\n", e)
+	}
 	if browserErrors != nil {
 		fmt.Fprintln(browserErrors, `console.error("`+template.JSEscapeString(e)+`");`)
 	}
@@ -822,6 +1450,11 @@ func sprintError(err error) string {
 	}
 
 	switch e := err.(type) {
+	case *compiler.Diagnostic:
+		if !e.Pos.IsValid() {
+			return e.Msg
+		}
+		return fmt.Sprintf("%s:%d:%d: %s", makeRel(e.Pos.Filename), e.Pos.Line, e.Pos.Column, e.Msg)
 	case *scanner.Error:
 		return fmt.Sprintf("%s:%d:%d: %s", makeRel(e.Pos.Filename), e.Pos.Line, e.Pos.Column, e.Msg)
 	case types.Error:
@@ -832,11 +1465,49 @@ func sprintError(err error) string {
 	}
 }
 
+// jsonDiagnostic is an error printed with --json.
+type jsonDiagnostic struct {
+	Package   string `json:"package,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+	Severity  string `json:"severity"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Message   string `json:"message"`
+}
+
+func newJSONDiagnostic(err error) jsonDiagnostic {
+	switch e := err.(type) {
+	case *compiler.Diagnostic:
+		return jsonDiagnostic{
+			Package:   e.Package,
+			Phase:     string(e.Phase),
+			Severity:  string(e.Severity),
+			File:      e.Pos.Filename,
+			Line:      e.Pos.Line,
+			Column:    e.Pos.Column,
+			EndLine:   e.End.Line,
+			EndColumn: e.End.Column,
+			Message:   e.Msg,
+		}
+	case *scanner.Error:
+		return jsonDiagnostic{Phase: string(compiler.PhaseParse), Severity: string(compiler.SeverityError), File: e.Pos.Filename, Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg}
+	case types.Error:
+		pos := e.Fset.Position(e.Pos)
+		return jsonDiagnostic{Phase: string(compiler.PhaseTypeCheck), Severity: string(compiler.SeverityError), File: pos.Filename, Line: pos.Line, Column: pos.Column, Message: e.Msg}
+	default:
+		return jsonDiagnostic{Severity: string(compiler.SeverityError), Message: err.Error()}
+	}
+}
+
 // runNode runs script with args using Node.js in directory dir.
 // If dir is empty string, current directory is used.
 // Is out is not nil, process stderr and stdout are redirected to it, otherwise
 // os.Stdout and os.Stderr are used.
-func runNode(script string, args []string, dir string, quiet bool, out io.Writer) error {
+// If timeout is positive, the process is killed once it runs longer.
+func runNode(script string, args []string, dir string, quiet bool, out io.Writer, timeout time.Duration) error {
 	var allArgs []string
 	if b, _ := strconv.ParseBool(os.Getenv("SOURCE_MAP_SUPPORT")); os.Getenv("SOURCE_MAP_SUPPORT") == "" || b {
 		allArgs = []string{"--require", "source-map-support/register"}
@@ -891,13 +1562,81 @@ func runNode(script string, args []string, dir string, quiet bool, out io.Writer
 		node.Stdout = os.Stdout
 		node.Stderr = os.Stderr
 	}
-	err := node.Run()
+	if err := node.Start(); err != nil {
+		return fmt.Errorf("could not run Node.js: %s", err.Error())
+	}
+	killed := make(chan struct{})
+	if timeout > 0 {
+		// The program may be stuck in a loop, which keeps it from handling
+		// timers or signals, so it can only be killed.
+		timer := time.AfterFunc(timeout, func() {
+			close(killed)
+			node.Process.Kill()
+		})
+		defer timer.Stop()
+	}
+	err := node.Wait()
+	select {
+	case <-killed:
+		if err != nil {
+			fmt.Fprintf(node.Stderr, "*** Test killed: ran too long (%s).\n", timeout)
+		}
+	default:
+	}
 	if _, ok := err.(*exec.ExitError); err != nil && !ok {
 		err = fmt.Errorf("could not run Node.js: %s", err.Error())
 	}
 	return err
 }
 
+// testKillTimeout returns how long a test binary run with the -test.timeout flag
+// may run before it's killed, or 0 if it's not limited. Like "go test", the
+// test binary is given a minute to report the timeout itself.
+func testKillTimeout(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return 0
+	}
+	return timeout + time.Minute
+}
+
+// goTestArgs converts the command line arguments of the test command written in
+// the style of "go test" into the ones the command accepts: long flags may start
+// with a single dash, e.g. -run=TestFoo, flags of the test binary may have the
+// "test." prefix, e.g. -test.run=TestFoo, and the arguments following -args are
+// returned separately, to be passed to the test binaries.
+func goTestArgs(args []string, flags *pflag.FlagSet) (cmdArgs []string, binaryArgs []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(cmdArgs, args[i:]...), nil
+		case arg == "-args" || arg == "--args":
+			return cmdArgs, args[i+1:]
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			cmdArgs = append(cmdArgs, arg)
+			continue
+		}
+
+		rest := strings.TrimPrefix(strings.TrimLeft(arg, "-"), "test.")
+		name, _, hasValue := strings.Cut(rest, "=")
+		var flag *pflag.Flag
+		if strings.HasPrefix(arg, "--") || len(name) > 1 {
+			if flag = flags.Lookup(name); flag != nil {
+				arg = "--" + rest
+			}
+		} else if flag = flags.ShorthandLookup(name); flag != nil {
+			arg = "-" + rest
+		}
+		cmdArgs = append(cmdArgs, arg)
+		// Keep the value of a flag given as a separate argument as is.
+		if flag != nil && !hasValue && flag.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			cmdArgs = append(cmdArgs, args[i])
+		}
+	}
+	return cmdArgs, nil
+}
+
 // runTestDir returns the directory for Node.js to use when running tests for package p.
 // Empty string means current directory.
 func runTestDir(p *gbuild.PackageData) string {
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	gbuild "github.com/gopherjs/gopherjs/build"
+	"github.com/gopherjs/gopherjs/internal/livereload"
+	"github.com/spf13/pflag"
+)
+
+func TestServeProxyMatches(t *testing.T) {
+	tests := []struct {
+		flag    string
+		path    string
+		matches bool
+	}{
+		{flag: "/api=http://localhost:9000", path: "/api", matches: true},
+		{flag: "/api=http://localhost:9000", path: "/api/users/42", matches: true},
+		{flag: "/api/=http://localhost:9000", path: "/api/users", matches: true},
+		{flag: "/api=http://localhost:9000", path: "/apis", matches: false},
+		{flag: "/api=http://localhost:9000", path: "/", matches: false},
+		{flag: "/api/v1=https://example.com", path: "/api/v2", matches: false},
+		{flag: "/=http://localhost:9000", path: "/", matches: true},
+		{flag: "/=http://localhost:9000", path: "/anything/at/all", matches: true},
+	}
+	for _, test := range tests {
+		p, err := parseServeProxy(test.flag)
+		if err != nil {
+			t.Fatalf("parseServeProxy(%q) returned error: %s", test.flag, err)
+		}
+		if got := p.matches(test.path); got != test.matches {
+			t.Errorf("Proxy %q matches %q: %t, want %t", test.flag, test.path, got, test.matches)
+		}
+	}
+
+	for _, flag := range []string{"", "/api", "api=http://localhost:9000", "/api=localhost:9000", "/api=ftp://localhost", "/api=http://"} {
+		if _, err := parseServeProxy(flag); err == nil {
+			t.Errorf("parseServeProxy(%q) returned no error, want an invalid flag error", flag)
+		}
+	}
+}
+
+func TestIsPageRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		accept string
+		page   bool
+	}{
+		{method: http.MethodGet, path: "/users/42", accept: "text/html,application/xhtml+xml", page: true},
+		{method: http.MethodHead, path: "/users/42", accept: "", page: true},
+		{method: http.MethodGet, path: "/users/42", accept: "*/*", page: true},
+		{method: http.MethodGet, path: "/users/42", accept: "application/json", page: false},
+		{method: http.MethodPost, path: "/users/42", accept: "text/html", page: false},
+		{method: http.MethodGet, path: "/static/logo.png", accept: "*/*", page: false},
+		{method: http.MethodGet, path: "/app/app.js", accept: "*/*", page: false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		if got := isPageRequest(r); got != test.page {
+			t.Errorf("isPageRequest(%s %s, Accept: %q) = %t, want %t", test.method, test.path, test.accept, got, test.page)
+		}
+	}
+}
+
+func TestServeHandler(t *testing.T) {
+	fs := newTestServeFileSystem(t, map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.18\n",
+		"main.go":    "package main\n\nfunc main() {}\n",
+		"index.html": "<html>app page</html>",
+		"style.css":  "body {}",
+	})
+	var proxied []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Path)
+		io.WriteString(w, "backend")
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		name    string
+		proxy   string
+		path    string
+		accept  string
+		status  int
+		body    string // Expected part of the response body.
+		proxied bool
+	}{
+		{name: "existing file", path: "/example.com/app/style.css", status: http.StatusOK, body: "body {}"},
+		{name: "page fallback", path: "/example.com/app/users/42", accept: "text/html", status: http.StatusOK, body: "app page"},
+		{name: "missing asset", path: "/example.com/app/users/logo.png", accept: "*/*", status: http.StatusNotFound},
+		{name: "missing data", path: "/example.com/app/users/42", accept: "application/json", status: http.StatusNotFound},
+		{name: "proxied prefix", proxy: "/api", path: "/api/users", status: http.StatusOK, body: "backend", proxied: true},
+		{name: "proxied page", proxy: "/", path: "/example.com/app/users/42", accept: "text/html", status: http.StatusOK, body: "backend", proxied: true},
+		{name: "compiled program", proxy: "/", path: "/example.com/app/app.js", status: http.StatusOK, body: "sourceMappingURL=app.js.map"},
+		{name: "compiled source map", proxy: "/", path: "/example.com/app/app.js.map", status: http.StatusOK, body: `"mappings"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxied = nil
+			var proxies []*serveProxy
+			if test.proxy != "" {
+				p, err := parseServeProxy(test.proxy + "=" + backend.URL)
+				if err != nil {
+					t.Fatalf("parseServeProxy() returned error: %s", err)
+				}
+				proxies = append(proxies, p)
+			}
+			h := newServeHandler(fs, proxies, true)
+
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Errorf("Got status %d, want %d", w.Code, test.status)
+			}
+			if !strings.Contains(w.Body.String(), test.body) {
+				t.Errorf("Got response body %q, want it to contain %q", w.Body.String(), test.body)
+			}
+			if got := len(proxied) > 0; got != test.proxied {
+				t.Errorf("Request proxied to the backend: %t, want %t", got, test.proxied)
+			}
+		})
+	}
+}
+
+// newTestServeFileSystem returns the file system of the serve command for a
+// module with the given files, which becomes the working directory.
+func newTestServeFileSystem(t *testing.T, files map[string]string) *serveCommandFileSystem {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("GOPHERJS_SKIP_VERSION_CHECK", "1")
+
+	options := &gbuild.Options{NoCache: true, Quiet: true}
+	session, err := gbuild.NewSession(options)
+	if err != nil {
+		t.Fatalf("NewSession() returned error: %s", err)
+	}
+	fs := &serveCommandFileSystem{
+		options:    options,
+		session:    session,
+		sourceMaps: map[string][]byte{},
+		dirStates:  map[string]dirState{},
+		watcher:    livereload.New(nil),
+	}
+	t.Cleanup(func() { fs.watcher.Close() })
+	return fs
+}
+
+func TestGoTestArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		cmdArgs    []string
+		binaryArgs []string
+		run        string
+		verbose    bool
+		short      bool
+		packages   int    // -p
+		parallel   string // --parallel
+		timeout    time.Duration
+		unknown    bool // Whether the arguments have flags unknown to the command.
+	}{
+		{name: "single dash", args: []string{"test", "-run=TestFoo", "./..."}, cmdArgs: []string{"test", "--run=TestFoo", "./..."}, run: "TestFoo"},
+		{name: "double dash", args: []string{"test", "--run=TestFoo"}, cmdArgs: []string{"test", "--run=TestFoo"}, run: "TestFoo"},
+		{name: "separate value", args: []string{"test", "-run", "TestFoo", "."}, cmdArgs: []string{"test", "--run", "TestFoo", "."}, run: "TestFoo"},
+		{name: "value looking like a flag", args: []string{"test", "-run", "-Foo"}, cmdArgs: []string{"test", "--run", "-Foo"}, run: "-Foo"},
+		{name: "test prefix", args: []string{"test", "-test.run=TestFoo"}, cmdArgs: []string{"test", "--run=TestFoo"}, run: "TestFoo"},
+		{name: "test prefix separate value", args: []string{"test", "-test.run", "TestFoo"}, cmdArgs: []string{"test", "--run", "TestFoo"}, run: "TestFoo"},
+		{name: "boolean flags", args: []string{"test", "-v", "-short", "."}, cmdArgs: []string{"test", "-v", "--short", "."}, verbose: true, short: true},
+		{name: "boolean flag values", args: []string{"test", "-test.v=true", "-short=false"}, cmdArgs: []string{"test", "-v=true", "--short=false"}, verbose: true},
+		{name: "packages in parallel", args: []string{"test", "-p", "2"}, cmdArgs: []string{"test", "-p", "2"}, packages: 2},
+		{name: "packages in parallel long", args: []string{"test", "--p=3"}, cmdArgs: []string{"test", "--p=3"}, packages: 3},
+		{name: "tests in parallel", args: []string{"test", "-parallel", "4"}, cmdArgs: []string{"test", "--parallel", "4"}, parallel: "4"},
+		{name: "tests in parallel with test prefix", args: []string{"test", "-test.parallel=4", "-p=1"}, cmdArgs: []string{"test", "--parallel=4", "-p=1"}, packages: 1, parallel: "4"},
+		{name: "duration", args: []string{"test", "-timeout", "30s"}, cmdArgs: []string{"test", "--timeout", "30s"}, timeout: 30 * time.Second},
+		{
+			name:       "args passthrough",
+			args:       []string{"test", "-v", ".", "-args", "-run=X", "-v", "--", "arg"},
+			cmdArgs:    []string{"test", "-v", "."},
+			binaryArgs: []string{"-run=X", "-v", "--", "arg"},
+			verbose:    true,
+		},
+		{name: "double dash args passthrough", args: []string{"test", "--args", "-x"}, cmdArgs: []string{"test"}, binaryArgs: []string{"-x"}},
+		{name: "end of flags", args: []string{"test", "-short", "--", "-args"}, cmdArgs: []string{"test", "--short", "--", "-args"}, short: true},
+		{name: "unknown flag", args: []string{"test", "-unknown=1", "-x"}, cmdArgs: []string{"test", "-unknown=1", "-x"}, unknown: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			run := flags.String("run", "", "")
+			verbose := flags.BoolP("verbose", "v", false, "")
+			short := flags.Bool("short", false, "")
+			packages := flags.IntP("p", "p", 0, "")
+			parallel := flags.String("parallel", "", "")
+			timeout := flags.Duration("timeout", 0, "")
+			flags.Bool("args", false, "")
+
+			cmdArgs, binaryArgs := goTestArgs(test.args, flags)
+			if diff := cmp.Diff(test.cmdArgs, cmdArgs); diff != "" {
+				t.Errorf("goTestArgs(%q) returned diff in command arguments (-want,+got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff(test.binaryArgs, binaryArgs); diff != "" {
+				t.Errorf("goTestArgs(%q) returned diff in test binary arguments (-want,+got):\n%s", test.args, diff)
+			}
+			if test.unknown {
+				return // Reported by the command.
+			}
+
+			if err := flags.Parse(cmdArgs); err != nil {
+				t.Fatalf("Parsing %q returned error: %s", cmdArgs, err)
+			}
+			if *run != test.run || *verbose != test.verbose || *short != test.short || *packages != test.packages || *parallel != test.parallel || *timeout != test.timeout {
+				t.Errorf("Parsed %q into run=%q v=%t short=%t p=%d parallel=%q timeout=%s, want run=%q v=%t short=%t p=%d parallel=%q timeout=%s",
+					cmdArgs, *run, *verbose, *short, *packages, *parallel, *timeout,
+					test.run, test.verbose, test.short, test.packages, test.parallel, test.timeout)
+			}
+		})
+	}
+}
+
+func TestTestKillTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{timeout: 0, want: 0},
+		{timeout: 10 * time.Minute, want: 11 * time.Minute},
+		{timeout: time.Second, want: time.Minute + time.Second},
+	}
+	for _, test := range tests {
+		if got := testKillTimeout(test.timeout); got != test.want {
+			t.Errorf("testKillTimeout(%s) = %s, want %s", test.timeout, got, test.want)
+		}
+	}
+}
+
+func TestRunNodeTimeout(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("Node.js is not available")
+	}
+	t.Setenv("SOURCE_MAP_SUPPORT", "false")
+	script := filepath.Join(t.TempDir(), "loop.js")
+	// A busy loop never lets the program handle timers or signals.
+	if err := os.WriteFile(script, []byte("for (;;) {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	start := time.Now()
+	err := runNode(script, nil, "", true, &out, 500*time.Millisecond)
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Errorf("runNode() returned error %v, want the exit error of the killed process", err)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("runNode() returned after %s, want the process killed after the timeout", elapsed)
+	}
+	if want := "*** Test killed: ran too long (500ms)."; !strings.Contains(out.String(), want) {
+		t.Errorf("Got output %q, want it to contain %q", out.String(), want)
+	}
+}